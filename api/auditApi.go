@@ -0,0 +1,130 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseTimeQuery reads an optional RFC 3339 timestamp query parameter
+func parseTimeQuery(c *gin.Context, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, utils.NewInvalidInputError("Invalid " + key + ": expected an RFC 3339 timestamp")
+	}
+	return &t, nil
+}
+
+func (h *Handler) ListAuditEvents(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to list audit events")
+
+	if !h.authorizeAdmin(c, ActionReadAudit, adminAudit) {
+		return
+	}
+
+	filter := models.AuditFilter{
+		Actor:        c.Query("actor"),
+		Action:       c.Query("action"),
+		ResourceType: c.Query("resourceType"),
+		Resource:     c.Query("resource"),
+		RequestID:    c.Query("requestId"),
+	}
+
+	var err error
+	if filter.Since, err = parseTimeQuery(c, "since"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.Until, err = parseTimeQuery(c, "until"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			logger.Error("Invalid audit page limit")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Limit must be a positive integer"})
+			return
+		}
+	}
+
+	page, err := h.svc.ListAuditEvents(c.Request.Context(), filter, c.Query("cursor"), limit)
+	if err != nil {
+		logger.Error("Failed to list audit events")
+		status, msg := utils.ErrorStatus(err, "Failed to retrieve audit events")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	logger.Infof("Successfully retrieved audit events, count: %d", len(page.Events))
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *Handler) VerifyAuditChain(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to verify the audit chain")
+
+	if !h.authorizeAdmin(c, ActionReadAudit, adminAudit) {
+		return
+	}
+
+	result, err := h.svc.VerifyAuditChain(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to verify audit chain")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify audit chain"})
+		return
+	}
+
+	if !result.Valid {
+		logger.Errorf("Audit chain broken at seq %d: %s", result.Break.Seq, result.Break.Reason)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) ExportAudit(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to export the audit chain")
+
+	if !h.authorizeAdmin(c, ActionReadAudit, adminAudit) {
+		return
+	}
+
+	var seqs [2]int64
+	for i, key := range []string{"fromSeq", "toSeq"} {
+		value := c.Query(key)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n <= 0 {
+			logger.Errorf("Invalid %s", key)
+			c.JSON(http.StatusBadRequest, gin.H{"error": key + " must be a positive integer"})
+			return
+		}
+		seqs[i] = n
+	}
+	if seqs[1] > 0 && seqs[0] > seqs[1] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fromSeq cannot be greater than toSeq"})
+		return
+	}
+
+	export, err := h.svc.ExportAudit(c.Request.Context(), seqs[0], seqs[1])
+	if err != nil {
+		logger.Error("Failed to export audit chain")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export audit chain"})
+		return
+	}
+	logger.Infof("Audit chain exported, events: %d", len(export.Events))
+	c.JSON(http.StatusOK, export)
+}
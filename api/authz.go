@@ -0,0 +1,230 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"kafka-governance/auth"
+	"kafka-governance/models"
+	"kafka-governance/service"
+	"kafka-governance/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 403 reason codes returned alongside service.ReasonSelfApproval
+const (
+	ReasonPolicyDenied         = "POLICY_DENIED"
+	ReasonClusterNotRegistered = "CLUSTER_NOT_REGISTERED"
+)
+
+const (
+	ActionCreateTopic  = "CreateTopic"
+	ActionUpdateTopic  = "UpdateTopic"
+	ActionApproveTopic = "ApproveTopic"
+	ActionRejectTopic  = "RejectTopic"
+	ActionImportTopics = "ImportTopics"
+	ActionDeleteTopic  = "DeleteTopic"
+
+	ActionRetryProvisioning = "RetryProvisioning"
+	// ActionManageClusters covers registering, updating and removing clusters
+	ActionManageClusters = "ManageClusters"
+
+	// Topic owners may always cancel deletions and manage clients; these
+	// actions let policies grant the same to others
+	ActionCancelTopicDeletion = "CancelTopicDeletion"
+	ActionRegisterTopicClient = "RegisterTopicClient"
+	ActionManageTopicOwners   = "ManageTopicOwners"
+	ActionTransferTopic       = "TransferTopic"
+)
+
+// Administrative actions act on the control plane itself rather than on a topic
+// or cluster. They are authorized against a single Admin::"<area>" resource per
+// area, so `"resource": "*"` or `"resource": "Admin::\"policies\""` scopes them.
+const (
+	ActionManagePolicies  = "ManagePolicies"
+	ActionReadPolicies    = "ReadPolicies"
+	ActionManageDirectory = "ManageDirectory"
+	ActionManageWorkflows = "ManageWorkflows"
+	ActionReadAudit       = "ReadAudit"
+
+	adminPolicies  = "policies"
+	adminDirectory = "directory"
+	adminWorkflows = "workflows"
+	adminAudit     = "audit"
+)
+
+// requirePrincipal returns the caller authenticated by auth.Middleware and
+// writes a 401 when there is none
+func requirePrincipal(c *gin.Context) (*models.Principal, bool) {
+	principal, ok := auth.PrincipalFrom(c)
+	if !ok {
+		utils.GetLogger().Error("Request has no authenticated principal")
+		apiErr := utils.NewUnauthorizedError("Authentication required")
+		c.JSON(apiErr.StatusCode, gin.H{"error": apiErr.Message})
+		return nil, false
+	}
+	return principal, true
+}
+
+// topicAuthzRequest builds the Cedar request for a user acting on a topic. The
+// topic is Topic::"cluster/name", since the same name may be taken on several
+// clusters, and is placed in its cluster, the cluster's environment and its
+// owner team so policies can be scoped to Cluster::"name", Environment::"prod"
+// or Team::"payments". cluster may be nil for topics on clusters that are not registered.
+func topicAuthzRequest(principal *models.Principal, action string, topic *models.Topic, cluster *models.Cluster) models.AuthzRequest {
+	req := models.AuthzRequest{
+		Principal: service.PrincipalEntity(principal),
+		Action:    utils.EntityUID("Action", action),
+		Resource: models.Entity{
+			UID:     service.TopicUID(topic.Cluster, topic.Name),
+			Parents: []string{utils.EntityUID("Cluster", topic.Cluster)},
+		},
+		Context: map[string]string{
+			"cluster": topic.Cluster,
+			"topic":   topic.Name,
+		},
+	}
+
+	if topic.OwnerTeam != "" {
+		req.Resource.Parents = append(req.Resource.Parents, utils.EntityUID("Team", topic.OwnerTeam))
+		req.Context["ownerTeam"] = topic.OwnerTeam
+	}
+	if cluster != nil {
+		req.Resource.Parents = append(req.Resource.Parents, utils.EntityUID("Environment", string(cluster.Environment)))
+		req.Context["environment"] = string(cluster.Environment)
+	}
+	return req
+}
+
+// clusterAuthzRequest builds the Cedar request for a user acting on a whole cluster
+func clusterAuthzRequest(principal *models.Principal, action string, cluster *models.Cluster) models.AuthzRequest {
+	return models.AuthzRequest{
+		Principal: service.PrincipalEntity(principal),
+		Action:    utils.EntityUID("Action", action),
+		Resource: models.Entity{
+			UID:     utils.EntityUID("Cluster", cluster.Name),
+			Parents: []string{utils.EntityUID("Environment", string(cluster.Environment))},
+		},
+		Context: map[string]string{
+			"cluster":     cluster.Name,
+			"environment": string(cluster.Environment),
+		},
+	}
+}
+
+// adminAuthzRequest builds the Cedar request for a user administering an area
+// of the control plane, e.g. Admin::"policies"
+func adminAuthzRequest(principal *models.Principal, action, area string) models.AuthzRequest {
+	return models.AuthzRequest{
+		Principal: service.PrincipalEntity(principal),
+		Action:    utils.EntityUID("Action", action),
+		Resource:  models.Entity{UID: utils.EntityUID("Admin", area)},
+		Context:   map[string]string{},
+	}
+}
+
+// authorizeAdmin checks that the caller may perform an administrative action,
+// writing the 401 or 403 response when not. Nothing is permitted by default.
+func (h *Handler) authorizeAdmin(c *gin.Context, action, area string) bool {
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return false
+	}
+	return h.authorize(c, adminAuthzRequest(principal, action, area))
+}
+
+// topicCluster looks up the registered cluster of a topic, returning nil when
+// the cluster is not registered
+func (h *Handler) topicCluster(c *gin.Context, topic *models.Topic) *models.Cluster {
+	cluster, err := h.svc.GetCluster(c.Request.Context(), topic.Cluster)
+	if err != nil {
+		utils.GetLogger().Warnf("Cluster %s of topic is not registered", topic.Cluster)
+		return nil
+	}
+	return cluster
+}
+
+// authorize checks the request against the policy engine and writes the error
+// response when it is not allowed. It returns true when the handler may continue.
+func (h *Handler) authorize(c *gin.Context, req models.AuthzRequest) bool {
+	logger := utils.GetLogger()
+
+	decision, err := h.svc.Authorize(c.Request.Context(), req)
+	if err != nil {
+		logger.Error("Authorization check failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to evaluate policies"})
+		return false
+	}
+
+	if !decision.Allowed {
+		logger.Error("Request denied by policy")
+		respondError(c, utils.NewForbiddenReason(
+			ReasonPolicyDenied,
+			"Not authorized to perform "+req.Action+" on "+req.Resource.UID,
+			map[string]interface{}{
+				"action":   req.Action,
+				"resource": req.Resource.UID,
+				"policies": decision.Policies,
+			},
+		), "Request denied")
+		return false
+	}
+	logger.Debug("Request authorized by policy")
+	return true
+}
+
+// authorizeApproval checks that the principal may approve topics on the topic's
+// cluster and environment. Unlike the topic-level check, a permit on the topic
+// alone is not enough: the policy must cover Cluster::"name", the environment,
+// or all clusters.
+func (h *Handler) authorizeApproval(c *gin.Context, principal *models.Principal, topic *models.Topic, cluster *models.Cluster) bool {
+	if cluster == nil {
+		utils.GetLogger().Errorf("Cannot approve topic on unregistered cluster %s", topic.Cluster)
+		respondError(c, utils.NewForbiddenReason(
+			ReasonClusterNotRegistered,
+			"Topics on unregistered clusters cannot be approved",
+			map[string]interface{}{"cluster": topic.Cluster},
+		), "Request denied")
+		return false
+	}
+	return h.authorize(c, clusterAuthzRequest(principal, ActionApproveTopic, cluster))
+}
+
+// authorizeTopic checks a topic action against the policy engine. The request
+// context says whether the principal owns the topic, so a policy with the
+// condition {"owner": "true"} covers every team's own topics.
+func (h *Handler) authorizeTopic(c *gin.Context, principal *models.Principal, action string, topic *models.Topic, cluster *models.Cluster) bool {
+	owner, err := h.svc.IsTopicOwner(c.Request.Context(), topic, principal)
+	if err != nil {
+		utils.GetLogger().Error("Failed to check topic ownership")
+		respondError(c, err, "Failed to check topic ownership")
+		return false
+	}
+	req := topicAuthzRequest(principal, action, topic, cluster)
+	req.Context["owner"] = strconv.FormatBool(owner)
+	return h.authorize(c, req)
+}
+
+// authorizeOwnerOr lets owners of the topic through and checks everyone else
+// against the policy engine for action
+func (h *Handler) authorizeOwnerOr(c *gin.Context, principal *models.Principal, action string, topic *models.Topic) bool {
+	owner, err := h.svc.IsTopicOwner(c.Request.Context(), topic, principal)
+	if err != nil {
+		utils.GetLogger().Error("Failed to check topic ownership")
+		respondError(c, err, "Failed to check topic ownership")
+		return false
+	}
+	if owner {
+		return true
+	}
+	req := topicAuthzRequest(principal, action, topic, h.topicCluster(c, topic))
+	req.Context["owner"] = "false"
+	return h.authorize(c, req)
+}
+
+// respondError writes err with its status, code and details
+func respondError(c *gin.Context, err error, fallback string) {
+	status, body := utils.ErrorBody(err, fallback)
+	c.JSON(status, body)
+}
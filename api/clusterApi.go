@@ -0,0 +1,308 @@
+package api
+
+import (
+	"net/http"
+
+	"kafka-governance/models"
+	"kafka-governance/service"
+	"kafka-governance/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+var securityProtocols = map[string]bool{
+	"PLAINTEXT":      true,
+	"SSL":            true,
+	"SASL_PLAINTEXT": true,
+	"SASL_SSL":       true,
+}
+
+var saslMechanisms = map[string]bool{
+	"PLAIN":         true,
+	"SCRAM-SHA-256": true,
+	"SCRAM-SHA-512": true,
+}
+
+// applyClusterDefaults fills in the settings a cluster request may leave out
+func applyClusterDefaults(cl *models.Cluster) {
+	if cl.Security.Protocol == "" {
+		cl.Security.Protocol = "PLAINTEXT"
+	}
+	if cl.DefaultPartitions == 0 {
+		cl.DefaultPartitions = 1
+	}
+	if cl.MaxReplicas == 0 {
+		cl.MaxReplicas = cl.BrokerCount
+	}
+	if cl.DefaultReplicas == 0 {
+		cl.DefaultReplicas = min(3, cl.MaxReplicas)
+	}
+}
+
+// validateCluster checks the fields shared by cluster create and update
+func (h *Handler) validateCluster(cl *models.Cluster) error {
+	if len(cl.BootstrapServers) == 0 {
+		return utils.NewInvalidInputError("At least one bootstrap server is required")
+	}
+
+	switch cl.Environment {
+	case models.EnvDev, models.EnvStaging, models.EnvProd:
+	default:
+		return utils.NewInvalidInputError("Environment must be one of 'dev', 'staging' or 'prod'")
+	}
+
+	if !securityProtocols[cl.Security.Protocol] {
+		return utils.NewInvalidInputError("Security protocol must be one of PLAINTEXT, SSL, SASL_PLAINTEXT or SASL_SSL")
+	}
+
+	if cl.Security.Protocol == "SASL_PLAINTEXT" || cl.Security.Protocol == "SASL_SSL" {
+		if !saslMechanisms[cl.Security.SASLMechanism] {
+			return utils.NewInvalidInputError("SASL mechanism must be one of PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512")
+		}
+		if cl.Security.Username == "" || cl.Security.PasswordEnv == "" {
+			return utils.NewInvalidInputError("SASL username and passwordEnv are required")
+		}
+	}
+	if err := h.svc.ValidateClusterSecurity(cl.Security); err != nil {
+		return err
+	}
+
+	if cl.BrokerCount <= 0 {
+		return utils.NewInvalidInputError("Broker count must be greater than 0")
+	}
+
+	if cl.MaxReplicas > cl.BrokerCount {
+		return utils.NewInvalidInputError("Max replicas cannot exceed the broker count")
+	}
+
+	if cl.DefaultReplicas <= 0 || cl.DefaultReplicas > cl.MaxReplicas {
+		return utils.NewInvalidInputError("Default replicas must be between 1 and max replicas")
+	}
+
+	if cl.DefaultPartitions <= 0 {
+		return utils.NewInvalidInputError("Default partitions must be greater than 0")
+	}
+
+	if cl.MaxPartitions < 0 || (cl.MaxPartitions > 0 && cl.DefaultPartitions > cl.MaxPartitions) {
+		return utils.NewInvalidInputError("Default partitions cannot exceed max partitions")
+	}
+	return service.ValidateConfigLimits(cl.ConfigLimits)
+}
+
+func (h *Handler) CreateCluster(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to register a cluster")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var cl models.Cluster
+	if err := c.ShouldBindJSON(&cl); err != nil {
+		logger.Error("Failed to decode cluster request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if cl.Name == "" {
+		logger.Error("Cluster name validation failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cluster name is required"})
+		return
+	}
+
+	applyClusterDefaults(&cl)
+	if err := h.validateCluster(&cl); err != nil {
+		logger.Errorf("Cluster validation failed: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	logger.Debug("Cluster validation passed")
+
+	if !h.authorize(c, clusterAuthzRequest(principal, ActionManageClusters, &cl)) {
+		return
+	}
+
+	created, err := h.svc.CreateCluster(c.Request.Context(), cl)
+	if err != nil {
+		logger.Error("Failed to register cluster")
+		status, msg := utils.ErrorStatus(err, "Failed to register cluster")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("Cluster registered successfully")
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *Handler) ListClusters(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to list clusters")
+
+	clusters, err := h.svc.ListClusters(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to list clusters")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve clusters"})
+		return
+	}
+
+	if clusters == nil {
+		clusters = []models.Cluster{}
+	}
+
+	logger.Infof("Successfully retrieved clusters list, count: %d", len(clusters))
+	c.JSON(http.StatusOK, clusters)
+}
+
+func (h *Handler) GetCluster(c *gin.Context) {
+	logger := utils.GetLogger()
+	name := c.Param("name")
+	logger.Info("Received a request to get cluster")
+
+	cluster, err := h.svc.GetCluster(c.Request.Context(), name)
+	if err != nil {
+		logger.Error("Failed to get cluster")
+		status, msg := utils.ErrorStatus(err, "Failed to retrieve cluster")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("Cluster retrieved successfully")
+	c.JSON(http.StatusOK, cluster)
+}
+
+func (h *Handler) UpdateCluster(c *gin.Context) {
+	logger := utils.GetLogger()
+	name := c.Param("name")
+	logger.Info("Received a request to update cluster")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var cl models.Cluster
+	if err := c.ShouldBindJSON(&cl); err != nil {
+		logger.Error("Failed to decode cluster request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	applyClusterDefaults(&cl)
+	if err := h.validateCluster(&cl); err != nil {
+		logger.Errorf("Cluster validation failed: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The caller needs a permit for the cluster as it is and as it will be, so
+	// moving a cluster to another environment is covered by both policies
+	existing, err := h.svc.GetCluster(c.Request.Context(), name)
+	if err != nil {
+		logger.Error("Failed to get cluster")
+		status, msg := utils.ErrorStatus(err, "Failed to retrieve cluster")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	cl.Name = existing.Name
+	if !h.authorize(c, clusterAuthzRequest(principal, ActionManageClusters, existing)) ||
+		!h.authorize(c, clusterAuthzRequest(principal, ActionManageClusters, &cl)) {
+		return
+	}
+
+	updated, err := h.svc.UpdateCluster(c.Request.Context(), name, cl)
+	if err != nil {
+		logger.Error("Failed to update cluster")
+		status, msg := utils.ErrorStatus(err, "Failed to update cluster")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("Cluster updated successfully")
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *Handler) DeleteCluster(c *gin.Context) {
+	logger := utils.GetLogger()
+	name := c.Param("name")
+	logger.Info("Received a request to delete cluster")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	cluster, err := h.svc.GetCluster(c.Request.Context(), name)
+	if err != nil {
+		logger.Error("Failed to get cluster")
+		status, msg := utils.ErrorStatus(err, "Failed to retrieve cluster")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	if !h.authorize(c, clusterAuthzRequest(principal, ActionManageClusters, cluster)) {
+		return
+	}
+
+	if err := h.svc.DeleteCluster(c.Request.Context(), name); err != nil {
+		logger.Error("Failed to delete cluster")
+		status, msg := utils.ErrorStatus(err, "Failed to delete cluster")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("Cluster deleted successfully")
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) GetClusterDrift(c *gin.Context) {
+	logger := utils.GetLogger()
+	name := c.Param("name")
+	logger.Info("Received a request to get cluster drift")
+
+	if _, err := h.svc.GetCluster(c.Request.Context(), name); err != nil {
+		logger.Error("Failed to get cluster")
+		status, msg := utils.ErrorStatus(err, "Failed to retrieve cluster")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	report, err := h.svc.GetDriftReport(c.Request.Context(), name)
+	if err != nil {
+		logger.Error("Failed to get drift report")
+		status, msg := utils.ErrorStatus(err, "Failed to retrieve drift report")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Infof("Drift report retrieved successfully, findings: %d", len(report.Findings))
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *Handler) ImportClusterTopics(c *gin.Context) {
+	logger := utils.GetLogger()
+	name := c.Param("name")
+	logger.Info("Received a request to import cluster topics")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	cluster, err := h.svc.GetCluster(c.Request.Context(), name)
+	if err != nil {
+		logger.Error("Failed to get cluster")
+		status, msg := utils.ErrorStatus(err, "Failed to retrieve cluster")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	if !h.authorize(c, clusterAuthzRequest(principal, ActionImportTopics, cluster)) {
+		return
+	}
+
+	result, err := h.svc.ImportTopics(c.Request.Context(), name, principal.Subject)
+	if err != nil {
+		logger.Error("Failed to import cluster topics")
+		status, msg := utils.ErrorStatus(err, "Failed to import topics")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("Cluster topics imported successfully")
+	c.JSON(http.StatusOK, result)
+}
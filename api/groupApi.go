@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) CreateGroup(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to create a group")
+
+	if !h.authorizeAdmin(c, ActionManageDirectory, adminDirectory) {
+		return
+	}
+
+	var g models.Group
+	if err := c.ShouldBindJSON(&g); err != nil {
+		logger.Error("Failed to decode group request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if g.Name == "" {
+		logger.Error("Group name validation failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Group name is required"})
+		return
+	}
+	g.Parents = uniqueNames(g.Parents)
+	g.Roles = uniqueNames(g.Roles)
+
+	created, err := h.svc.CreateGroup(c.Request.Context(), g)
+	if err != nil {
+		logger.Error("Failed to create group")
+		status, msg := utils.ErrorStatus(err, "Failed to create group")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("Group created successfully")
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *Handler) ListGroups(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to list groups")
+
+	groups, err := h.svc.ListGroups(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to list groups")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve groups"})
+		return
+	}
+
+	if groups == nil {
+		groups = []models.Group{}
+	}
+
+	logger.Infof("Successfully retrieved groups list, count: %d", len(groups))
+	c.JSON(http.StatusOK, groups)
+}
+
+func (h *Handler) GetGroup(c *gin.Context) {
+	logger := utils.GetLogger()
+	name := c.Param("name")
+	logger.Info("Received a request to get group")
+
+	group, err := h.svc.GetGroup(c.Request.Context(), name)
+	if err != nil {
+		logger.Error("Failed to get group")
+		status, msg := utils.ErrorStatus(err, "Failed to retrieve group")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("Group retrieved successfully")
+	c.JSON(http.StatusOK, group)
+}
+
+func (h *Handler) UpdateGroup(c *gin.Context) {
+	logger := utils.GetLogger()
+	name := c.Param("name")
+	logger.Info("Received a request to update group")
+
+	if !h.authorizeAdmin(c, ActionManageDirectory, adminDirectory) {
+		return
+	}
+
+	var g models.Group
+	if err := c.ShouldBindJSON(&g); err != nil {
+		logger.Error("Failed to decode group request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	g.Parents = uniqueNames(g.Parents)
+	g.Roles = uniqueNames(g.Roles)
+
+	updated, err := h.svc.UpdateGroup(c.Request.Context(), name, g)
+	if err != nil {
+		logger.Error("Failed to update group")
+		status, msg := utils.ErrorStatus(err, "Failed to update group")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("Group updated successfully")
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *Handler) DeleteGroup(c *gin.Context) {
+	logger := utils.GetLogger()
+	name := c.Param("name")
+	logger.Info("Received a request to delete group")
+
+	if !h.authorizeAdmin(c, ActionManageDirectory, adminDirectory) {
+		return
+	}
+
+	if err := h.svc.DeleteGroup(c.Request.Context(), name); err != nil {
+		logger.Error("Failed to delete group")
+		status, msg := utils.ErrorStatus(err, "Failed to delete group")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("Group deleted successfully")
+	c.Status(http.StatusNoContent)
+}
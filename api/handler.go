@@ -0,0 +1,13 @@
+package api
+
+import "kafka-governance/service"
+
+// Handler serves the HTTP API on top of a Service
+type Handler struct {
+	svc *service.Service
+}
+
+// NewHandler creates the API handlers for svc
+func NewHandler(svc *service.Service) *Handler {
+	return &Handler{svc: svc}
+}
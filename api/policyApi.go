@@ -4,18 +4,19 @@ import (
 	"net/http"
 
 	"kafka-governance/models"
-	"kafka-governance/service"
 	"kafka-governance/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-var policies []models.Policy
-
-func CreatePolicy(c *gin.Context) {
+func (h *Handler) CreatePolicy(c *gin.Context) {
 	logger := utils.GetLogger()
 	logger.Info("Received a request to create a policy")
 
+	if !h.authorizeAdmin(c, ActionManagePolicies, adminPolicies) {
+		return
+	}
+
 	var p models.Policy
 	if err := c.ShouldBindJSON(&p); err != nil {
 		logger.Error("Failed to decode policy request body")
@@ -24,38 +25,106 @@ func CreatePolicy(c *gin.Context) {
 	}
 	logger.Debug("Policy request body decoded successfully")
 
-	// Validate required fields
-	if p.Principal == "" {
-		logger.Error("Principal is required")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Principal is required"})
+	created, err := h.svc.CreatePolicy(c.Request.Context(), p)
+	if err != nil {
+		logger.Errorf("Failed to create policy: %s", err.Error())
+		respondError(c, err, "Failed to create policy")
 		return
 	}
+	logger.Info("Policy created successfully")
 
-	if p.Action == "" {
-		logger.Error("Action is required")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Action is required"})
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *Handler) ListPolicies(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to list policies")
+
+	if !h.authorizeAdmin(c, ActionReadPolicies, adminPolicies) {
 		return
 	}
 
-	if p.Resource == "" {
-		logger.Error("Resource is required")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Resource is required"})
+	filter := models.PolicyFilter{
+		Principal: c.Query("principal"),
+		Action:    c.Query("action"),
+		Resource:  c.Query("resource"),
+		Effect:    c.Query("effect"),
+	}
+
+	policies, err := h.svc.ListPolicies(c.Request.Context(), filter)
+	if err != nil {
+		logger.Error("Failed to list policies")
+		respondError(c, err, "Failed to retrieve policies")
 		return
 	}
 
-	if p.Effect != "permit" && p.Effect != "forbid" {
-		logger.Error("Effect must be either 'permit' or 'forbid'")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Effect must be either 'permit' or 'forbid'"})
+	if policies == nil {
+		policies = []models.Policy{}
+	}
+
+	logger.Infof("Successfully retrieved policies list, count: %d", len(policies))
+	c.JSON(http.StatusOK, policies)
+}
+
+func (h *Handler) GetPolicy(c *gin.Context) {
+	logger := utils.GetLogger()
+	id := c.Param("id")
+	logger.Info("Received a request to get policy")
+
+	if !h.authorizeAdmin(c, ActionReadPolicies, adminPolicies) {
 		return
 	}
-	logger.Debug("Policy validation passed")
 
-	if err := service.CreatePolicy(c.Request.Context(), p); err != nil {
-		logger.Error("Failed to create policy")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create policy"})
+	policy, err := h.svc.GetPolicy(c.Request.Context(), id)
+	if err != nil {
+		logger.Error("Failed to get policy")
+		respondError(c, err, "Failed to retrieve policy")
 		return
 	}
-	logger.Info("Policy created successfully")
+	logger.Info("Policy retrieved successfully")
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *Handler) UpdatePolicy(c *gin.Context) {
+	logger := utils.GetLogger()
+	id := c.Param("id")
+	logger.Info("Received a request to update policy")
 
-	c.JSON(http.StatusCreated, p)
+	if !h.authorizeAdmin(c, ActionManagePolicies, adminPolicies) {
+		return
+	}
+
+	var p models.Policy
+	if err := c.ShouldBindJSON(&p); err != nil {
+		logger.Error("Failed to decode policy request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	updated, err := h.svc.UpdatePolicy(c.Request.Context(), id, p)
+	if err != nil {
+		logger.Error("Failed to update policy")
+		respondError(c, err, "Failed to update policy")
+		return
+	}
+	logger.Info("Policy updated successfully")
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *Handler) DeletePolicy(c *gin.Context) {
+	logger := utils.GetLogger()
+	id := c.Param("id")
+	logger.Info("Received a request to delete policy")
+
+	if !h.authorizeAdmin(c, ActionManagePolicies, adminPolicies) {
+		return
+	}
+
+	if err := h.svc.DeletePolicy(c.Request.Context(), id); err != nil {
+		logger.Error("Failed to delete policy")
+		respondError(c, err, "Failed to delete policy")
+		return
+	}
+	logger.Info("Policy deleted successfully")
+	c.Status(http.StatusNoContent)
 }
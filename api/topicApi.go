@@ -2,7 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"kafka-governance/models"
 	"kafka-governance/service"
@@ -27,25 +31,51 @@ func sendSuccessResponse(w http.ResponseWriter, statusCode int, data interface{}
 	json.NewEncoder(w).Encode(data)
 }
 
-func CreateTopic(c *gin.Context) {
+// topicCreateRequest is the body of POST /topics: the fields a requester may
+// choose. Status, approval, provisioning and the other server-owned fields of a
+// topic are not accepted.
+type topicCreateRequest struct {
+	Name       string              `json:"name"`
+	Cluster    string              `json:"cluster"`
+	Partitions int                 `json:"partitions"`
+	Replicas   int                 `json:"replicas"`
+	Configs    models.TopicConfigs `json:"configs"`
+	OwnerTeam  string              `json:"ownerTeam"`
+	Owners     []string            `json:"owners"`
+	OnCall     string              `json:"onCall"`
+	Labels     map[string]string   `json:"labels"`
+}
+
+func (r topicCreateRequest) topic() models.Topic {
+	return models.Topic{
+		Name:       r.Name,
+		Cluster:    r.Cluster,
+		Partitions: r.Partitions,
+		Replicas:   r.Replicas,
+		Configs:    r.Configs,
+		OwnerTeam:  r.OwnerTeam,
+		Owners:     r.Owners,
+		OnCall:     r.OnCall,
+		Labels:     r.Labels,
+	}
+}
+
+func (h *Handler) CreateTopic(c *gin.Context) {
 	logger := utils.GetLogger()
 	logger.Info("Processing topic creation")
 
-	// Validate required header
-	requestedBy := c.GetHeader("X-User-Id")
-	if requestedBy == "" {
-		logger.Error("X-User-Id header missing")
-		c.JSON(http.StatusForbidden, gin.H{"error": "X-User-Id header is required"})
+	principal, ok := requirePrincipal(c)
+	if !ok {
 		return
 	}
-	logger.Debug("X-User-Id header validated")
 
-	var topic models.Topic
-	if err := c.ShouldBindJSON(&topic); err != nil {
+	var body topicCreateRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
 		logger.Error("Failed to decode request body")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
+	topic := body.topic()
 
 	if topic.Name == "" {
 		logger.Error("Topic name validation failed")
@@ -59,6 +89,23 @@ func CreateTopic(c *gin.Context) {
 		return
 	}
 
+	cluster, err := h.svc.GetCluster(c.Request.Context(), topic.Cluster)
+	if err != nil {
+		logger.Error("Cluster lookup failed")
+		if apiErr, ok := utils.IsAPIError(err); ok && apiErr.StatusCode == http.StatusNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown cluster: " + topic.Cluster})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up cluster"})
+		return
+	}
+
+	if err := service.ApplyClusterLimits(&topic, cluster); err != nil {
+		logger.Errorf("Cluster limit validation failed: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	if topic.Partitions <= 0 {
 		logger.Error("Partitions validation failed")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Partitions must be greater than 0"})
@@ -71,85 +118,968 @@ func CreateTopic(c *gin.Context) {
 		return
 	}
 
-	topic.RequestedBy = requestedBy
-	createdTopic, err := service.CreateTopic(c.Request.Context(), &topic)
+	if err := service.ValidateTopicConfigs(&topic, cluster); err != nil {
+		logger.Errorf("Topic config validation failed: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := service.ValidateTopicLabels(&topic); err != nil {
+		logger.Errorf("Topic label validation failed: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.ValidateTopicOwnership(c.Request.Context(), &topic); err != nil {
+		logger.Errorf("Topic ownership validation failed: %s", err.Error())
+		respondError(c, err, "Failed to validate topic ownership")
+		return
+	}
+
+	// The requester may name anyone as an owner, so only the owner team counts
+	// as ownership of a topic that does not exist yet
+	requested := topic
+	requested.Owners = nil
+	if !h.authorizeTopic(c, principal, ActionCreateTopic, &requested, cluster) {
+		return
+	}
+
+	topic.RequestedBy = principal.Subject
+	createdTopic, err := h.svc.CreateTopic(c.Request.Context(), &topic)
 	if err != nil {
 		logger.Error("Service layer returned error")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create topic"})
+		respondError(c, err, "Failed to create topic")
 		return
-
 	}
 
 	logger.Info("Topic created successfully")
 	c.JSON(http.StatusCreated, gin.H{"message": "Topic created successfully", "topic": createdTopic})
 }
 
-func ListTopics(c *gin.Context) {
+// topicFilterFromQuery reads the topic listing filters. status may list several
+// statuses separated by commas, and label may repeat as key=value, or as key to
+// match any value.
+func topicFilterFromQuery(c *gin.Context) (models.TopicFilter, error) {
+	filter := models.TopicFilter{
+		Cluster:     c.Query("cluster"),
+		RequestedBy: c.Query("requestedBy"),
+		OwnerTeam:   c.Query("ownerTeam"),
+		NamePrefix:  c.Query("namePrefix"),
+		NameRegex:   c.Query("nameRegex"),
+	}
+	for _, value := range c.QueryArray("status") {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				filter.Statuses = append(filter.Statuses, models.TopicStatus(strings.ToUpper(status)))
+			}
+		}
+	}
+	for _, label := range c.QueryArray("label") {
+		key, value, _ := strings.Cut(label, "=")
+		if filter.Labels == nil {
+			filter.Labels = map[string]string{}
+		}
+		filter.Labels[key] = value
+	}
+
+	var err error
+	if filter.CreatedSince, err = parseTimeQuery(c, "createdSince"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedUntil, err = parseTimeQuery(c, "createdUntil"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func (h *Handler) ListTopics(c *gin.Context) {
 	logger := utils.GetLogger()
 	logger.Info("Received a request to list topics")
 
-	topics, err := service.ListTopics(c.Request.Context())
+	filter, err := topicFilterFromQuery(c)
 	if err != nil {
-		logger.Error("Failed to list topics")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve topics"})
+		logger.Error("Invalid topic filter")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sort, err := service.ParseTopicSort(c.Query("sort"))
+	if err != nil {
+		logger.Error("Invalid topic sort")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if topics == nil {
-		topics = []models.Topic{}
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			logger.Error("Invalid topic page limit")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Limit must be a positive integer"})
+			return
+		}
 	}
 
-	logger.Infof("Successfully retrieved topics list, count: %d", len(topics))
-	c.JSON(http.StatusOK, topics)
+	page, err := h.svc.ListTopics(c.Request.Context(), filter, sort, c.Query("cursor"), limit)
+	if err != nil {
+		logger.Error("Failed to list topics")
+		respondError(c, err, "Failed to retrieve topics")
+		return
+	}
+
+	logger.Infof("Successfully retrieved topics list, count: %d", len(page.Topics))
+	c.JSON(http.StatusOK, page)
 }
 
-func GetTopic(c *gin.Context) {
+// topicFromPath loads the topic addressed by the request path, either
+// /clusters/:name/topics/:topic or /topics/:name. The name-only form answers 409
+// with the candidate clusters when the name exists on more than one cluster. It
+// writes the error response and returns false when there is no single topic.
+func (h *Handler) topicFromPath(c *gin.Context) (*models.Topic, bool) {
 	logger := utils.GetLogger()
-	name := c.Param("name")
-	logger.Info("Received a request to get topic")
 
-	if name == "" {
-		logger.Error("Topic name is required")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Topic name is required"})
-		return
+	var topic *models.Topic
+	var err error
+	if name := c.Param("topic"); name != "" {
+		topic, err = h.svc.GetTopic(c.Request.Context(), c.Param("name"), name)
+	} else {
+		topic, err = h.svc.ResolveTopic(c.Request.Context(), c.Param("name"))
 	}
-
-	topic, err := service.GetTopic(c.Request.Context(), name)
 	if err != nil {
-		logger.Error("Topic not found")
-		c.JSON(http.StatusNotFound, gin.H{"error": "Topic not found"})
+		logger.Error("Failed to look up topic")
+		respondError(c, err, "Failed to retrieve topic")
+		return nil, false
+	}
+	return topic, true
+}
+
+func (h *Handler) GetTopic(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to get topic")
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
 		return
 	}
 	logger.Info("Topic retrieved successfully")
 	c.JSON(http.StatusOK, topic)
 }
 
-func ApproveTopic(c *gin.Context) {
+// topicDecisionRequest is the optional body of approve and the required body of reject
+type topicDecisionRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *Handler) ApproveTopic(c *gin.Context) {
 	logger := utils.GetLogger()
-	name := c.Param("name")
 	logger.Info("Received a request to approve topic")
 
-	if name == "" {
-		logger.Error("Topic name is required")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Topic name is required"})
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var body topicDecisionRequest
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		logger.Error("Failed to decode approval request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
 
-	// Validate admin header
-	admin := c.GetHeader("X-User-Id")
-	if admin == "" {
-		logger.Error("X-User-Id header is required for approval")
-		c.JSON(http.StatusForbidden, gin.H{"error": "X-User-Id header is required"})
+	topic, ok := h.topicFromPath(c)
+	if !ok {
 		return
 	}
-	logger.Debug("X-User-Id header validated")
 
-	if err := service.ApproveTopic(c.Request.Context(), name, admin); err != nil {
+	cluster := h.topicCluster(c, topic)
+	if !h.authorizeTopic(c, principal, ActionApproveTopic, topic, cluster) {
+		return
+	}
+	if !h.authorizeApproval(c, principal, topic, cluster) {
+		return
+	}
+
+	approved, err := h.svc.ApproveTopic(c.Request.Context(), topic.Cluster, topic.Name, principal, body.Reason)
+	if err != nil {
 		logger.Error("Failed to approve topic")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve topic"})
+		respondError(c, err, "Failed to approve topic")
+		return
+	}
+
+	if approved.Status == models.TopicPending {
+		logger.Info("Topic approval vote recorded")
+		c.JSON(http.StatusOK, gin.H{"status": "pending", "topic": approved})
 		return
 	}
 	logger.Info("Topic approved successfully")
 
-	c.JSON(http.StatusOK, gin.H{"status": "approved"})
+	c.JSON(http.StatusOK, gin.H{"status": "approved", "topic": approved})
+}
+
+func (h *Handler) RejectTopic(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to reject topic")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var body topicDecisionRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logger.Error("Failed to decode rejection request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if body.Reason == "" {
+		logger.Error("Rejection reason is required")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reason is required"})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeTopic(c, principal, ActionRejectTopic, topic, h.topicCluster(c, topic)) {
+		return
+	}
+
+	rejected, err := h.svc.RejectTopic(c.Request.Context(), topic.Cluster, topic.Name, principal.Subject, body.Reason)
+	if err != nil {
+		logger.Error("Failed to reject topic")
+		respondError(c, err, "Failed to reject topic")
+		return
+	}
+	logger.Info("Topic rejected successfully")
+
+	c.JSON(http.StatusOK, gin.H{"status": "rejected", "topic": rejected})
+}
+
+// topicChangeRequest is the body of PATCH on a topic. Replicas is only decoded
+// to reject it: changing the replication factor needs a partition reassignment.
+type topicChangeRequest struct {
+	Partitions *int               `json:"partitions"`
+	Replicas   *int               `json:"replicas"`
+	Configs    map[string]*string `json:"configs"`
+	Reason     string             `json:"reason"`
+}
+
+func (h *Handler) UpdateTopic(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to change topic")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var body topicChangeRequest
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		logger.Error("Failed to decode change request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: only partitions, configs and reason can be changed"})
+		return
+	}
+	if body.Replicas != nil {
+		logger.Error("Replication factor change requested")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The replication factor of a topic cannot be changed"})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeTopic(c, principal, ActionUpdateTopic, topic, h.topicCluster(c, topic)) {
+		return
+	}
+
+	changed, err := h.svc.RequestTopicChange(c.Request.Context(), topic.Cluster, topic.Name, models.TopicChange{
+		Partitions:  body.Partitions,
+		Configs:     body.Configs,
+		Reason:      body.Reason,
+		RequestedBy: principal.Subject,
+	})
+	if err != nil {
+		logger.Error("Failed to request topic change")
+		respondError(c, err, "Failed to request topic change")
+		return
+	}
+
+	logger.Info("Topic change requested successfully")
+	c.JSON(http.StatusAccepted, gin.H{"status": "pending", "topic": changed})
+}
+
+func (h *Handler) ApproveTopicChange(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to approve topic change")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var body topicDecisionRequest
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		logger.Error("Failed to decode approval request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	cluster := h.topicCluster(c, topic)
+	if !h.authorizeTopic(c, principal, ActionApproveTopic, topic, cluster) {
+		return
+	}
+	if !h.authorizeApproval(c, principal, topic, cluster) {
+		return
+	}
+
+	approved, err := h.svc.ApproveTopicChange(c.Request.Context(), topic.Cluster, topic.Name, principal, body.Reason)
+	if err != nil {
+		logger.Error("Failed to approve topic change")
+		respondError(c, err, "Failed to approve topic change")
+		return
+	}
+
+	if approved.Change != nil && approved.Change.Status == models.ChangePending {
+		logger.Info("Topic change approval vote recorded")
+		c.JSON(http.StatusOK, gin.H{"status": "pending", "topic": approved})
+		return
+	}
+	logger.Info("Topic change approved successfully")
+	c.JSON(http.StatusOK, gin.H{"status": "approved", "topic": approved})
+}
+
+func (h *Handler) RejectTopicChange(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to reject topic change")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var body topicDecisionRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logger.Error("Failed to decode rejection request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if body.Reason == "" {
+		logger.Error("Rejection reason is required")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reason is required"})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeTopic(c, principal, ActionRejectTopic, topic, h.topicCluster(c, topic)) {
+		return
+	}
+
+	rejected, err := h.svc.RejectTopicChange(c.Request.Context(), topic.Cluster, topic.Name, principal.Subject, body.Reason)
+	if err != nil {
+		logger.Error("Failed to reject topic change")
+		respondError(c, err, "Failed to reject topic change")
+		return
+	}
+	logger.Info("Topic change rejected successfully")
+
+	c.JSON(http.StatusOK, gin.H{"status": "rejected", "topic": rejected})
+}
+
+func (h *Handler) DeleteTopic(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to delete topic")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var body topicDecisionRequest
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		logger.Error("Failed to decode deletion request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeTopic(c, principal, ActionDeleteTopic, topic, h.topicCluster(c, topic)) {
+		return
+	}
+
+	requested, err := h.svc.RequestTopicDeletion(c.Request.Context(), topic.Cluster, topic.Name, principal.Subject, body.Reason)
+	if err != nil {
+		logger.Error("Failed to request topic deletion")
+		respondError(c, err, "Failed to request topic deletion")
+		return
+	}
+
+	logger.Info("Topic deletion requested successfully")
+	c.JSON(http.StatusAccepted, gin.H{"status": "pending", "topic": requested})
+}
+
+func (h *Handler) GetTopicImpact(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to analyse topic impact")
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	impact, err := h.svc.AnalyzeTopicImpact(c.Request.Context(), topic.Cluster, topic.Name)
+	if err != nil {
+		logger.Error("Failed to analyse topic impact")
+		respondError(c, err, "Failed to analyse topic impact")
+		return
+	}
+	logger.Info("Topic impact analysed successfully")
+	c.JSON(http.StatusOK, impact)
+}
+
+func (h *Handler) CancelTopicDeletion(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to cancel topic deletion")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var body topicDecisionRequest
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		logger.Error("Failed to decode cancellation request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeOwnerOr(c, principal, ActionCancelTopicDeletion, topic) {
+		return
+	}
+
+	cancelled, err := h.svc.CancelTopicDeletion(c.Request.Context(), topic.Cluster, topic.Name, principal.Subject, body.Reason)
+	if err != nil {
+		logger.Error("Failed to cancel topic deletion")
+		respondError(c, err, "Failed to cancel topic deletion")
+		return
+	}
+	logger.Info("Topic deletion cancelled successfully")
+	c.JSON(http.StatusOK, gin.H{"status": "cancelled", "topic": cancelled})
+}
+
+// RetryTopicProvisioning moves a FAILED topic back to PROVISIONING
+func (h *Handler) RetryTopicProvisioning(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to retry topic provisioning")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var body topicDecisionRequest
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		logger.Error("Failed to decode retry request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeTopic(c, principal, ActionRetryProvisioning, topic, h.topicCluster(c, topic)) {
+		return
+	}
+
+	retried, err := h.svc.RetryTopicProvisioning(c.Request.Context(), topic.Cluster, topic.Name, principal.Subject, body.Reason)
+	if err != nil {
+		logger.Error("Failed to retry topic provisioning")
+		respondError(c, err, "Failed to retry topic provisioning")
+		return
+	}
+	logger.Info("Topic provisioning retried successfully")
+	c.JSON(http.StatusOK, gin.H{"status": "provisioning", "topic": retried})
+}
+
+// RetryTopicChange moves a FAILED change back to APPROVED
+func (h *Handler) RetryTopicChange(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to retry topic change")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var body topicDecisionRequest
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		logger.Error("Failed to decode retry request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeTopic(c, principal, ActionRetryProvisioning, topic, h.topicCluster(c, topic)) {
+		return
+	}
+
+	retried, err := h.svc.RetryTopicChange(c.Request.Context(), topic.Cluster, topic.Name, principal.Subject, body.Reason)
+	if err != nil {
+		logger.Error("Failed to retry topic change")
+		respondError(c, err, "Failed to retry topic change")
+		return
+	}
+	logger.Info("Topic change retried successfully")
+	c.JSON(http.StatusOK, gin.H{"status": "approved", "topic": retried})
+}
+
+// CancelTopicChange closes a FAILED change without applying it
+func (h *Handler) CancelTopicChange(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to cancel topic change")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var body topicDecisionRequest
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		logger.Error("Failed to decode cancellation request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeTopic(c, principal, ActionRetryProvisioning, topic, h.topicCluster(c, topic)) {
+		return
+	}
+
+	cancelled, err := h.svc.CancelTopicChange(c.Request.Context(), topic.Cluster, topic.Name, principal.Subject, body.Reason)
+	if err != nil {
+		logger.Error("Failed to cancel topic change")
+		respondError(c, err, "Failed to cancel topic change")
+		return
+	}
+	logger.Info("Topic change cancelled successfully")
+	c.JSON(http.StatusOK, gin.H{"status": "cancelled", "topic": cancelled})
+}
+
+// topicClientRequest is the body of a client registration
+type topicClientRequest struct {
+	Application string            `json:"application" binding:"required"`
+	Role        models.ClientRole `json:"role" binding:"required"`
+}
+
+func (h *Handler) RegisterTopicClient(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to register topic client")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var body topicClientRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logger.Error("Failed to decode client registration body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: application and role are required"})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeOwnerOr(c, principal, ActionRegisterTopicClient, topic) {
+		return
+	}
+
+	registered, err := h.svc.RegisterTopicClient(c.Request.Context(), topic.Cluster, topic.Name, models.TopicClient{
+		Application:  body.Application,
+		Role:         body.Role,
+		RegisteredBy: principal.Subject,
+	})
+	if err != nil {
+		logger.Error("Failed to register topic client")
+		respondError(c, err, "Failed to register topic client")
+		return
+	}
+	logger.Info("Topic client registered successfully")
+	c.JSON(http.StatusCreated, registered)
+}
+
+func (h *Handler) UnregisterTopicClient(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to remove topic client")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeOwnerOr(c, principal, ActionRegisterTopicClient, topic) {
+		return
+	}
+
+	role := models.ClientRole(c.Query("role"))
+	unregistered, err := h.svc.UnregisterTopicClient(c.Request.Context(), topic.Cluster, topic.Name, c.Param("application"), role)
+	if err != nil {
+		logger.Error("Failed to remove topic client")
+		respondError(c, err, "Failed to remove topic client")
+		return
+	}
+	logger.Info("Topic client removed successfully")
+	c.JSON(http.StatusOK, unregistered)
+}
+
+// revisionQuery reads an optional positive revision number query parameter
+func revisionQuery(c *gin.Context, key string) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+	revision, err := strconv.Atoi(value)
+	if err != nil || revision <= 0 {
+		return 0, utils.NewInvalidInputError("Invalid " + key + ": expected a positive revision number")
+	}
+	return revision, nil
+}
+
+func (h *Handler) GetTopicHistory(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to get topic history")
+
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			logger.Error("Invalid topic history page limit")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Limit must be a positive integer"})
+			return
+		}
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	page, err := h.svc.TopicHistory(c.Request.Context(), topic.Cluster, topic.Name, c.Query("cursor"), limit)
+	if err != nil {
+		logger.Error("Failed to retrieve topic history")
+		respondError(c, err, "Failed to retrieve topic history")
+		return
+	}
+	logger.Infof("Topic history retrieved successfully, count: %d", len(page.Revisions))
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *Handler) GetTopicRevision(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to get topic revision")
+
+	revision, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || revision <= 0 {
+		logger.Error("Invalid topic revision")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Revision must be a positive integer"})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	found, err := h.svc.GetTopicRevision(c.Request.Context(), topic.Cluster, topic.Name, revision)
+	if err != nil {
+		logger.Error("Failed to retrieve topic revision")
+		respondError(c, err, "Failed to retrieve topic revision")
+		return
+	}
+	logger.Info("Topic revision retrieved successfully")
+	c.JSON(http.StatusOK, found)
+}
+
+func (h *Handler) DiffTopicRevisions(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to compare topic revisions")
+
+	from, err := revisionQuery(c, "from")
+	if err != nil || from == 0 {
+		logger.Error("Invalid revision to compare from")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from: expected a positive revision number"})
+		return
+	}
+	to, err := revisionQuery(c, "to")
+	if err != nil {
+		logger.Error("Invalid revision to compare to")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	diff, err := h.svc.DiffTopicRevisions(c.Request.Context(), topic.Cluster, topic.Name, from, to)
+	if err != nil {
+		logger.Error("Failed to compare topic revisions")
+		respondError(c, err, "Failed to compare topic revisions")
+		return
+	}
+	logger.Info("Topic revisions compared successfully")
+	c.JSON(http.StatusOK, diff)
+}
+
+// topicOwnershipRequest is the body of an ownership update, and of a transfer
+// acceptance where both fields are optional
+type topicOwnershipRequest struct {
+	Owners []string `json:"owners"`
+	OnCall string   `json:"onCall"`
+}
+
+// topicTransferRequest is the body of an ownership transfer request
+type topicTransferRequest struct {
+	ToTeam string   `json:"toTeam" binding:"required"`
+	Owners []string `json:"owners"`
+	OnCall string   `json:"onCall"`
+	Reason string   `json:"reason"`
+}
+
+func (h *Handler) UpdateTopicOwnership(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to update topic ownership")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var body topicOwnershipRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logger.Error("Failed to decode ownership request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeOwnerOr(c, principal, ActionManageTopicOwners, topic) {
+		return
+	}
+
+	updated, err := h.svc.UpdateTopicOwnership(c.Request.Context(), topic.Cluster, topic.Name, body.Owners, body.OnCall)
+	if err != nil {
+		logger.Error("Failed to update topic ownership")
+		respondError(c, err, "Failed to update topic ownership")
+		return
+	}
+	logger.Info("Topic ownership updated successfully")
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *Handler) RequestTopicTransfer(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to transfer topic ownership")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var body topicTransferRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logger.Error("Failed to decode transfer request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: toTeam is required"})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeOwnerOr(c, principal, ActionTransferTopic, topic) {
+		return
+	}
+
+	requested, err := h.svc.RequestOwnershipTransfer(c.Request.Context(), topic.Cluster, topic.Name, models.OwnershipTransfer{
+		ToTeam:      body.ToTeam,
+		Owners:      body.Owners,
+		OnCall:      body.OnCall,
+		Reason:      body.Reason,
+		RequestedBy: principal.Subject,
+	})
+	if err != nil {
+		logger.Error("Failed to request topic ownership transfer")
+		respondError(c, err, "Failed to request topic ownership transfer")
+		return
+	}
+	logger.Info("Topic ownership transfer requested successfully")
+	c.JSON(http.StatusAccepted, gin.H{"status": "pending", "topic": requested})
+}
+
+func (h *Handler) AcceptTopicTransfer(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to accept topic ownership transfer")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var body topicOwnershipRequest
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		logger.Error("Failed to decode transfer acceptance body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	// Only the receiving team can accept, which the service checks
+	accepted, err := h.svc.AcceptOwnershipTransfer(c.Request.Context(), topic.Cluster, topic.Name, principal, body.Owners, body.OnCall)
+	if err != nil {
+		logger.Error("Failed to accept topic ownership transfer")
+		respondError(c, err, "Failed to accept topic ownership transfer")
+		return
+	}
+	logger.Info("Topic ownership transfer accepted successfully")
+	c.JSON(http.StatusOK, gin.H{"status": "accepted", "topic": accepted})
+}
+
+func (h *Handler) DeclineTopicTransfer(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to decline topic ownership transfer")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var body topicDecisionRequest
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		logger.Error("Failed to decode transfer decline body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	recipient, err := h.svc.IsTransferRecipient(c.Request.Context(), topic, principal)
+	if err != nil {
+		logger.Error("Failed to check receiving team membership")
+		respondError(c, err, "Failed to check receiving team membership")
+		return
+	}
+	if topic.Transfer != nil && !recipient {
+		logger.Error("Transfer declined by a non-member of the receiving team")
+		respondError(c, utils.NewForbiddenReason(
+			service.ReasonNotReceivingTeam,
+			"Only members of "+topic.Transfer.ToTeam+" can decline the transfer",
+			map[string]interface{}{"toTeam": topic.Transfer.ToTeam},
+		), "Request denied")
+		return
+	}
+
+	declined, err := h.svc.DeclineOwnershipTransfer(c.Request.Context(), topic.Cluster, topic.Name, principal.Subject, body.Reason, "topic.transfer_decline")
+	if err != nil {
+		logger.Error("Failed to decline topic ownership transfer")
+		respondError(c, err, "Failed to decline topic ownership transfer")
+		return
+	}
+	logger.Info("Topic ownership transfer declined successfully")
+	c.JSON(http.StatusOK, gin.H{"status": "declined", "topic": declined})
+}
+
+func (h *Handler) WithdrawTopicTransfer(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to withdraw topic ownership transfer")
+
+	principal, ok := requirePrincipal(c)
+	if !ok {
+		return
+	}
+
+	topic, ok := h.topicFromPath(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeOwnerOr(c, principal, ActionTransferTopic, topic) {
+		return
+	}
+
+	withdrawn, err := h.svc.DeclineOwnershipTransfer(c.Request.Context(), topic.Cluster, topic.Name, principal.Subject, c.Query("reason"), "topic.transfer_withdraw")
+	if err != nil {
+		logger.Error("Failed to withdraw topic ownership transfer")
+		respondError(c, err, "Failed to withdraw topic ownership transfer")
+		return
+	}
+	logger.Info("Topic ownership transfer withdrawn successfully")
+	c.JSON(http.StatusOK, gin.H{"status": "withdrawn", "topic": withdrawn})
+}
+
+func (h *Handler) ListOrphanedTopics(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to list orphaned topics")
+
+	orphaned, err := h.svc.OrphanedTopics(c.Request.Context(), c.Query("cluster"))
+	if err != nil {
+		logger.Error("Failed to list orphaned topics")
+		respondError(c, err, "Failed to list orphaned topics")
+		return
+	}
+	logger.Infof("Orphaned topics listed successfully, count: %d", len(orphaned))
+	c.JSON(http.StatusOK, orphaned)
 }
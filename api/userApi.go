@@ -0,0 +1,144 @@
+package api
+
+import (
+	"net/http"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// normalizeUser drops empty and duplicate memberships and keeps lists non-nil
+func normalizeUser(u *models.User) {
+	u.Groups = uniqueNames(u.Groups)
+	u.Roles = uniqueNames(u.Roles)
+}
+
+func uniqueNames(names []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, name := range names {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func (h *Handler) CreateUser(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to create a user")
+
+	if !h.authorizeAdmin(c, ActionManageDirectory, adminDirectory) {
+		return
+	}
+
+	var u models.User
+	if err := c.ShouldBindJSON(&u); err != nil {
+		logger.Error("Failed to decode user request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if u.Username == "" {
+		logger.Error("Username validation failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username is required"})
+		return
+	}
+	normalizeUser(&u)
+
+	created, err := h.svc.CreateUser(c.Request.Context(), u)
+	if err != nil {
+		logger.Error("Failed to create user")
+		status, msg := utils.ErrorStatus(err, "Failed to create user")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("User created successfully")
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *Handler) ListUsers(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to list users")
+
+	users, err := h.svc.ListUsers(c.Request.Context(), c.Query("group"))
+	if err != nil {
+		logger.Error("Failed to list users")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		return
+	}
+
+	if users == nil {
+		users = []models.User{}
+	}
+
+	logger.Infof("Successfully retrieved users list, count: %d", len(users))
+	c.JSON(http.StatusOK, users)
+}
+
+func (h *Handler) GetUser(c *gin.Context) {
+	logger := utils.GetLogger()
+	username := c.Param("username")
+	logger.Info("Received a request to get user")
+
+	user, err := h.svc.GetUser(c.Request.Context(), username)
+	if err != nil {
+		logger.Error("Failed to get user")
+		status, msg := utils.ErrorStatus(err, "Failed to retrieve user")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("User retrieved successfully")
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *Handler) UpdateUser(c *gin.Context) {
+	logger := utils.GetLogger()
+	username := c.Param("username")
+	logger.Info("Received a request to update user")
+
+	if !h.authorizeAdmin(c, ActionManageDirectory, adminDirectory) {
+		return
+	}
+
+	var u models.User
+	if err := c.ShouldBindJSON(&u); err != nil {
+		logger.Error("Failed to decode user request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	normalizeUser(&u)
+
+	updated, err := h.svc.UpdateUser(c.Request.Context(), username, u)
+	if err != nil {
+		logger.Error("Failed to update user")
+		status, msg := utils.ErrorStatus(err, "Failed to update user")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("User updated successfully")
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *Handler) DeleteUser(c *gin.Context) {
+	logger := utils.GetLogger()
+	username := c.Param("username")
+	logger.Info("Received a request to delete user")
+
+	if !h.authorizeAdmin(c, ActionManageDirectory, adminDirectory) {
+		return
+	}
+
+	if err := h.svc.DeleteUser(c.Request.Context(), username); err != nil {
+		logger.Error("Failed to delete user")
+		status, msg := utils.ErrorStatus(err, "Failed to delete user")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("User deleted successfully")
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"net/http"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// validateWorkflow checks the fields shared by workflow create and update
+func validateWorkflow(w *models.ApprovalWorkflow) error {
+	if (w.Cluster == "") == (w.Environment == "") {
+		return utils.NewInvalidInputError("Workflow must be attached to either a cluster or an environment")
+	}
+
+	switch w.Environment {
+	case "", models.EnvDev, models.EnvStaging, models.EnvProd:
+	default:
+		return utils.NewInvalidInputError("Environment must be one of 'dev', 'staging' or 'prod'")
+	}
+
+	if len(w.Stages) == 0 {
+		return utils.NewInvalidInputError("At least one stage is required")
+	}
+
+	names := map[string]bool{}
+	for i := range w.Stages {
+		stage := &w.Stages[i]
+		if stage.Group == "" {
+			return utils.NewInvalidInputError("Every stage needs an approver group")
+		}
+		if stage.MinApprovals == 0 {
+			stage.MinApprovals = 1
+		}
+		if stage.MinApprovals < 0 {
+			return utils.NewInvalidInputError("Minimum approvals must be greater than 0")
+		}
+		if stage.Name == "" {
+			stage.Name = stage.Group
+		}
+		if names[stage.Name] {
+			return utils.NewInvalidInputError("Stage names must be unique")
+		}
+		names[stage.Name] = true
+	}
+	return nil
+}
+
+func (h *Handler) CreateWorkflow(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to create an approval workflow")
+
+	if !h.authorizeAdmin(c, ActionManageWorkflows, adminWorkflows) {
+		return
+	}
+
+	var w models.ApprovalWorkflow
+	if err := c.ShouldBindJSON(&w); err != nil {
+		logger.Error("Failed to decode workflow request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if w.Name == "" {
+		logger.Error("Workflow name validation failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Workflow name is required"})
+		return
+	}
+
+	if err := validateWorkflow(&w); err != nil {
+		logger.Errorf("Workflow validation failed: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.svc.CreateWorkflow(c.Request.Context(), w)
+	if err != nil {
+		logger.Error("Failed to create workflow")
+		status, msg := utils.ErrorStatus(err, "Failed to create workflow")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("Workflow created successfully")
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *Handler) ListWorkflows(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Received a request to list workflows")
+
+	workflows, err := h.svc.ListWorkflows(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to list workflows")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve workflows"})
+		return
+	}
+
+	if workflows == nil {
+		workflows = []models.ApprovalWorkflow{}
+	}
+
+	logger.Infof("Successfully retrieved workflows list, count: %d", len(workflows))
+	c.JSON(http.StatusOK, workflows)
+}
+
+func (h *Handler) GetWorkflow(c *gin.Context) {
+	logger := utils.GetLogger()
+	name := c.Param("name")
+	logger.Info("Received a request to get workflow")
+
+	workflow, err := h.svc.GetWorkflow(c.Request.Context(), name)
+	if err != nil {
+		logger.Error("Failed to get workflow")
+		status, msg := utils.ErrorStatus(err, "Failed to retrieve workflow")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("Workflow retrieved successfully")
+	c.JSON(http.StatusOK, workflow)
+}
+
+func (h *Handler) UpdateWorkflow(c *gin.Context) {
+	logger := utils.GetLogger()
+	name := c.Param("name")
+	logger.Info("Received a request to update workflow")
+
+	if !h.authorizeAdmin(c, ActionManageWorkflows, adminWorkflows) {
+		return
+	}
+
+	var w models.ApprovalWorkflow
+	if err := c.ShouldBindJSON(&w); err != nil {
+		logger.Error("Failed to decode workflow request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := validateWorkflow(&w); err != nil {
+		logger.Errorf("Workflow validation failed: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.svc.UpdateWorkflow(c.Request.Context(), name, w)
+	if err != nil {
+		logger.Error("Failed to update workflow")
+		status, msg := utils.ErrorStatus(err, "Failed to update workflow")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("Workflow updated successfully")
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *Handler) DeleteWorkflow(c *gin.Context) {
+	logger := utils.GetLogger()
+	name := c.Param("name")
+	logger.Info("Received a request to delete workflow")
+
+	if !h.authorizeAdmin(c, ActionManageWorkflows, adminWorkflows) {
+		return
+	}
+
+	if err := h.svc.DeleteWorkflow(c.Request.Context(), name); err != nil {
+		logger.Error("Failed to delete workflow")
+		status, msg := utils.ErrorStatus(err, "Failed to delete workflow")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	logger.Info("Workflow deleted successfully")
+	c.Status(http.StatusNoContent)
+}
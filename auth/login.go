@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"kafka-governance/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const stateCookie = "oidc_state"
+
+// LoginConfig holds the OIDC client registration used for the login flow
+type LoginConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// OIDCLogin implements the authorization code flow against the provider the
+// verifier discovered. The callback returns the provider's tokens so clients can
+// send the ID token as their bearer token.
+type OIDCLogin struct {
+	cfg      LoginConfig
+	verifier *OIDCVerifier
+}
+
+func NewOIDCLogin(verifier *OIDCVerifier, cfg LoginConfig) (*OIDCLogin, error) {
+	if cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("OIDC login needs a client id and redirect URL")
+	}
+	if verifier.metadata.AuthorizationEndpoint == "" || verifier.metadata.TokenEndpoint == "" {
+		return nil, errors.New("OIDC provider does not advertise authorization and token endpoints")
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	}
+	return &OIDCLogin{cfg: cfg, verifier: verifier}, nil
+}
+
+// Login redirects the browser to the provider with a fresh state value
+func (l *OIDCLogin) Login(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Starting OIDC login")
+
+	state, err := randomState()
+	if err != nil {
+		logger.Error("Failed to generate OIDC state")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(stateCookie, state, 600, "/", "", c.Request.TLS != nil, true)
+
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {l.cfg.ClientID},
+		"redirect_uri":  {l.cfg.RedirectURL},
+		"scope":         {strings.Join(l.cfg.Scopes, " ")},
+		"state":         {state},
+	}
+	c.Redirect(http.StatusFound, l.verifier.metadata.AuthorizationEndpoint+"?"+query.Encode())
+}
+
+// Callback exchanges the authorization code for tokens and verifies the ID token
+func (l *OIDCLogin) Callback(c *gin.Context) {
+	logger := utils.GetLogger()
+	logger.Info("Handling OIDC login callback")
+
+	if errParam := c.Query("error"); errParam != "" {
+		logger.Errorf("OIDC provider returned error: %s", errParam)
+		abortUnauthorized(c, utils.NewUnauthorizedError("Login failed: "+errParam))
+		return
+	}
+
+	cookieState, err := c.Cookie(stateCookie)
+	state := c.Query("state")
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookieState), []byte(state)) != 1 {
+		logger.Error("OIDC state mismatch")
+		abortUnauthorized(c, utils.NewUnauthorizedError("Invalid login state"))
+		return
+	}
+	c.SetCookie(stateCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+
+	code := c.Query("code")
+	if code == "" {
+		logger.Error("OIDC callback without code")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Authorization code is required"})
+		return
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {l.cfg.RedirectURL},
+		"client_id":     {l.cfg.ClientID},
+		"client_secret": {l.cfg.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, l.verifier.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete login"})
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := l.verifier.client.Do(req)
+	if err != nil {
+		logger.Error("OIDC token exchange failed")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to reach identity provider"})
+		return
+	}
+	defer resp.Body.Close()
+
+	var tokens struct {
+		IDToken     string `json:"id_token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&tokens) != nil || tokens.IDToken == "" {
+		logger.Errorf("OIDC token exchange returned %d", resp.StatusCode)
+		abortUnauthorized(c, utils.NewUnauthorizedError("Login failed"))
+		return
+	}
+
+	principal, err := l.verifier.Verify(c.Request.Context(), tokens.IDToken)
+	if err != nil {
+		logger.Errorf("OIDC ID token rejected: %s", err.Error())
+		abortUnauthorized(c, utils.NewUnauthorizedError("Invalid ID token"))
+		return
+	}
+
+	logger.Infof("OIDC login succeeded for %s", principal.Subject)
+	c.JSON(http.StatusOK, gin.H{
+		"idToken":     tokens.IDToken,
+		"accessToken": tokens.AccessToken,
+		"expiresIn":   tokens.ExpiresIn,
+		"principal":   principal,
+	})
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
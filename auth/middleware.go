@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"strings"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey is the gin context key holding the authenticated principal
+const principalKey = "auth.principal"
+
+// Middleware requires a valid bearer token on every request and stores the
+// principal it identifies in the request context
+func Middleware(verifier Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := utils.GetLogger()
+
+		header := c.GetHeader("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || strings.TrimSpace(token) == "" {
+			logger.Error("Bearer token missing")
+			abortUnauthorized(c, utils.NewUnauthorizedError("Bearer token is required"))
+			return
+		}
+
+		principal, err := verifier.Verify(c.Request.Context(), strings.TrimSpace(token))
+		if err != nil {
+			logger.Errorf("Bearer token rejected: %s", err.Error())
+			abortUnauthorized(c, utils.NewUnauthorizedError("Invalid bearer token"))
+			return
+		}
+
+		logger.Debugf("Request authenticated as %s", principal.Subject)
+		c.Set(principalKey, principal)
+		c.Request = c.Request.WithContext(utils.WithActor(c.Request.Context(), principal.Subject))
+		c.Next()
+	}
+}
+
+// PrincipalFrom returns the principal stored by Middleware
+func PrincipalFrom(c *gin.Context) (*models.Principal, bool) {
+	v, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	principal, ok := v.(*models.Principal)
+	return principal, ok
+}
+
+func abortUnauthorized(c *gin.Context, err *utils.APIError) {
+	c.Header("WWW-Authenticate", `Bearer realm="kafka-governance"`)
+	c.AbortWithStatusJSON(err.StatusCode, gin.H{"error": err.Message})
+}
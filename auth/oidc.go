@@ -0,0 +1,288 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCConfig configures token verification against an OIDC identity provider
+type OIDCConfig struct {
+	IssuerURL string
+	Audience  string
+	Claims    ClaimMapping
+
+	// KeyCacheTTL is how long fetched signing keys are trusted before they are refetched
+	KeyCacheTTL time.Duration
+	// MinRefreshInterval limits refetches triggered by tokens with an unknown key id
+	MinRefreshInterval time.Duration
+	HTTPClient         *http.Client
+}
+
+// providerMetadata is the subset of the discovery document the service uses
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	JWKSURI               string `json:"jwks_uri"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// OIDCVerifier verifies tokens issued by an OIDC provider using the signing keys
+// published at its JWKS endpoint. Keys are cached and refetched when they expire
+// or when a token is signed with a key id that is not cached yet, which is how
+// providers roll keys.
+type OIDCVerifier struct {
+	cfg      OIDCConfig
+	client   *http.Client
+	metadata providerMetadata
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// NewOIDCVerifier runs provider discovery and fetches the initial signing keys
+func NewOIDCVerifier(ctx context.Context, cfg OIDCConfig) (*OIDCVerifier, error) {
+	logger := utils.GetLogger()
+
+	if cfg.IssuerURL == "" {
+		return nil, errors.New("OIDC issuer URL is required")
+	}
+	// Without an audience a token the provider issued to any other client would be accepted
+	if cfg.Audience == "" {
+		return nil, errors.New("OIDC audience is required, set OIDC_AUDIENCE or OIDC_CLIENT_ID")
+	}
+	if cfg.Claims.Username == "" {
+		cfg.Claims.Username = DefaultClaimMapping.Username
+	}
+	if cfg.KeyCacheTTL <= 0 {
+		cfg.KeyCacheTTL = time.Hour
+	}
+	if cfg.MinRefreshInterval <= 0 {
+		cfg.MinRefreshInterval = 30 * time.Second
+	}
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	v := &OIDCVerifier{cfg: cfg, client: client}
+
+	discoveryURL := strings.TrimRight(cfg.IssuerURL, "/") + "/.well-known/openid-configuration"
+	if err := v.getJSON(ctx, discoveryURL, &v.metadata); err != nil {
+		logger.Error("OIDC discovery failed")
+		return nil, fmt.Errorf("OIDC discovery: %w", err)
+	}
+	if strings.TrimRight(v.metadata.Issuer, "/") != strings.TrimRight(cfg.IssuerURL, "/") {
+		return nil, fmt.Errorf("OIDC discovery returned issuer %q, expected %q", v.metadata.Issuer, cfg.IssuerURL)
+	}
+	if v.metadata.JWKSURI == "" {
+		return nil, errors.New("OIDC discovery document has no jwks_uri")
+	}
+
+	if err := v.refreshKeys(ctx); err != nil {
+		logger.Error("Fetching OIDC signing keys failed")
+		return nil, err
+	}
+	logger.Infof("OIDC provider discovered: %s", v.metadata.Issuer)
+	return v, nil
+}
+
+func (v *OIDCVerifier) Verify(ctx context.Context, token string) (*models.Principal, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(v.metadata.Issuer),
+		jwt.WithAudience(v.cfg.Audience),
+		jwt.WithExpirationRequired(),
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, kid)
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return principalFromClaims(claims, v.cfg.Claims)
+}
+
+// key returns the cached key for kid, refetching the key set when it is stale or
+// does not contain kid yet
+func (v *OIDCVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.RLock()
+	key, ok := v.lookup(kid)
+	stale := time.Since(v.fetchedAt) > v.cfg.KeyCacheTTL
+	canRefresh := time.Since(v.lastAttempt) > v.cfg.MinRefreshInterval
+	v.mu.RUnlock()
+
+	if ok && !stale {
+		return key, nil
+	}
+	if !stale && !canRefresh {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	if err := v.refreshKeys(ctx); err != nil {
+		if ok {
+			// Keep serving the cached key while the provider is unreachable
+			utils.GetLogger().Warn("OIDC key refresh failed, using cached key")
+			return key, nil
+		}
+		return nil, err
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if key, ok := v.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookup finds a key by id; tokens without a kid are accepted when the set has a single key
+func (v *OIDCVerifier) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+func (v *OIDCVerifier) refreshKeys(ctx context.Context) error {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching OIDC signing keys")
+
+	v.mu.Lock()
+	v.lastAttempt = time.Now()
+	v.mu.Unlock()
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, v.metadata.JWKSURI, &set); err != nil {
+		return fmt.Errorf("fetching JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			logger.Warnf("Skipping OIDC signing key %s: %s", jwk.Kid, err.Error())
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return errors.New("JWKS contains no usable signing keys")
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+	logger.Infof("OIDC signing keys refreshed, count: %d", len(keys))
+	return nil
+}
+
+func (v *OIDCVerifier) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeIssuer is a local OIDC provider serving discovery and a JWKS whose keys
+// the test can rotate
+type fakeIssuer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	keys        map[string]crypto.Signer
+	jwksFetches int
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+	issuer := &fakeIssuer{keys: map[string]crypto.Signer{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(providerMetadata{Issuer: issuer.URL, JWKSURI: issuer.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", issuer.serveJWKS)
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+func (f *fakeIssuer) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.jwksFetches++
+
+	set := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	for kid, key := range f.keys {
+		jwk := jsonWebKey{Kid: kid, Use: "sig"}
+		switch pub := key.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty, jwk.N, jwk.E = "RSA", encodeBigInt(pub.N), encodeBigInt(big.NewInt(int64(pub.E)))
+		case *ecdsa.PublicKey:
+			jwk.Kty, jwk.Crv, jwk.X, jwk.Y = "EC", "P-256", encodeBigInt(pub.X), encodeBigInt(pub.Y)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	json.NewEncoder(w).Encode(set)
+}
+
+func (f *fakeIssuer) addKey(t *testing.T, kid string, key crypto.Signer) {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys[kid] = key
+}
+
+func (f *fakeIssuer) fetches() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.jwksFetches
+}
+
+// sign issues a token with the given key; claims override the defaults of a
+// valid token for the "governance" audience
+func (f *fakeIssuer) sign(t *testing.T, kid string, key crypto.Signer, claims jwt.MapClaims) string {
+	t.Helper()
+	all := jwt.MapClaims{
+		"iss":    f.URL,
+		"aud":    "governance",
+		"sub":    "alice",
+		"groups": []string{"payments"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range claims {
+		if v == nil {
+			delete(all, k)
+		} else {
+			all[k] = v
+		}
+	}
+
+	method := jwt.SigningMethod(jwt.SigningMethodRS256)
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		method = jwt.SigningMethodES256
+	}
+	token := jwt.NewWithClaims(method, all)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return signed
+}
+
+func encodeBigInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestNewOIDCVerifierRequiresAudience(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	issuer.addKey(t, "k1", newECKey(t))
+
+	if _, err := NewOIDCVerifier(context.Background(), OIDCConfig{IssuerURL: issuer.URL}); err == nil {
+		t.Fatal("verifier created without an audience")
+	}
+	if _, err := NewOIDCVerifier(context.Background(), OIDCConfig{IssuerURL: issuer.URL, Audience: "governance"}); err != nil {
+		t.Fatalf("NewOIDCVerifier: %v", err)
+	}
+}
+
+func TestOIDCVerify(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	key := newRSAKey(t)
+	issuer.addKey(t, "k1", key)
+	other := newRSAKey(t)
+
+	verifier, err := NewOIDCVerifier(context.Background(), OIDCConfig{
+		IssuerURL: issuer.URL,
+		Audience:  "governance",
+		Claims:    DefaultClaimMapping,
+		// Unknown key ids may refetch the key set right away
+		MinRefreshInterval: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCVerifier: %v", err)
+	}
+
+	hs256, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": issuer.URL, "aud": "governance", "sub": "mallory", "exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("guessed"))
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "valid", token: issuer.sign(t, "k1", key, nil)},
+		{name: "audience among several", token: issuer.sign(t, "k1", key, jwt.MapClaims{"aud": []string{"other-app", "governance"}})},
+		{name: "other audience", token: issuer.sign(t, "k1", key, jwt.MapClaims{"aud": "other-app"}), wantErr: true},
+		{name: "no audience", token: issuer.sign(t, "k1", key, jwt.MapClaims{"aud": nil}), wantErr: true},
+		{name: "other issuer", token: issuer.sign(t, "k1", key, jwt.MapClaims{"iss": "https://evil.example.com"}), wantErr: true},
+		{name: "expired", token: issuer.sign(t, "k1", key, jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}), wantErr: true},
+		{name: "no expiry", token: issuer.sign(t, "k1", key, jwt.MapClaims{"exp": nil}), wantErr: true},
+		{name: "signed by an unpublished key", token: issuer.sign(t, "k1", other, nil), wantErr: true},
+		{name: "unknown key id", token: issuer.sign(t, "k9", other, nil), wantErr: true},
+		{name: "HMAC token", token: hs256, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := verifier.Verify(context.Background(), tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify err = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (principal.Subject != "alice" || !slices.Equal(principal.Groups, []string{"payments"})) {
+				t.Errorf("principal = %+v", principal)
+			}
+		})
+	}
+}
+
+func TestOIDCVerifyFollowsKeyRotation(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	issuer.addKey(t, "k1", newRSAKey(t))
+
+	verifier, err := NewOIDCVerifier(context.Background(), OIDCConfig{
+		IssuerURL:          issuer.URL,
+		Audience:           "governance",
+		MinRefreshInterval: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCVerifier: %v", err)
+	}
+	fetches := issuer.fetches()
+
+	// The provider rolls a new key; the first token signed with it triggers a refetch
+	rotated := newECKey(t)
+	issuer.addKey(t, "k2", rotated)
+	token := issuer.sign(t, "k2", rotated, nil)
+	if _, err := verifier.Verify(context.Background(), token); err != nil {
+		t.Fatalf("Verify with the rotated key: %v", err)
+	}
+	if got := issuer.fetches(); got != fetches+1 {
+		t.Errorf("JWKS fetches = %d, want %d", got, fetches+1)
+	}
+
+	// Known keys are served from the cache
+	if _, err := verifier.Verify(context.Background(), token); err != nil {
+		t.Fatalf("Verify again: %v", err)
+	}
+	if got := issuer.fetches(); got != fetches+1 {
+		t.Errorf("JWKS fetches = %d after a cached key, want %d", got, fetches+1)
+	}
+}
@@ -0,0 +1,76 @@
+package cedar
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the agent while the breaker is open
+var ErrCircuitOpen = errors.New("cedar-agent circuit breaker is open")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker stops calls to the agent after a run of consecutive failures and
+// lets a single trial call through once the cooldown has elapsed
+type circuitBreaker struct {
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	threshold int
+	cooldown  time.Duration
+	openedAt  time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a call may be made right now
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = breakerHalfOpen
+		return nil
+	case breakerHalfOpen:
+		// Only the trial call is allowed until it reports back
+		return ErrCircuitOpen
+	default:
+		return nil
+	}
+}
+
+// success closes the breaker and resets the failure count. It reports whether
+// the breaker was open, i.e. the agent is reachable again.
+func (b *circuitBreaker) success() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	recovered := b.state != breakerClosed
+	b.state = breakerClosed
+	b.failures = 0
+	return recovered
+}
+
+// failure records a failed call and opens the breaker once the threshold is reached
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,357 @@
+package cedar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+// ClientConfig holds the connection settings for the cedar-agent sidecar
+type ClientConfig struct {
+	BaseURL          string
+	Timeout          time.Duration
+	MaxRetries       int
+	RetryBackoff     time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	HTTPClient       *http.Client // optional, overrides Timeout
+}
+
+// Client talks to the permitio/cedar-agent data and authorization APIs
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+	breaker    *circuitBreaker
+	// reconnected is signalled when a call succeeds after the breaker opened
+	reconnected chan struct{}
+}
+
+// agentEntityUID is the Cedar JSON form of an entity reference
+type agentEntityUID struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type agentEntity struct {
+	UID     agentEntityUID         `json:"uid"`
+	Attrs   map[string]interface{} `json:"attrs"`
+	Parents []agentEntityUID       `json:"parents"`
+}
+
+type agentPolicy struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+}
+
+type agentAuthzRequest struct {
+	Principal          string            `json:"principal"`
+	Action             string            `json:"action"`
+	Resource           string            `json:"resource"`
+	Context            map[string]string `json:"context"`
+	AdditionalEntities []agentEntity     `json:"additional_entities,omitempty"`
+}
+
+type agentAuthzResponse struct {
+	Decision    string `json:"decision"`
+	Diagnostics struct {
+		Reason []string `json:"reason"`
+		Errors []string `json:"errors"`
+	} `json:"diagnostics"`
+}
+
+// errInvalidResponse marks a 2xx response whose body could not be decoded
+var errInvalidResponse = errors.New("invalid cedar-agent response")
+
+// statusError is returned for non-2xx responses from the agent
+type statusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("cedar-agent returned %d: %s", e.StatusCode, e.Body)
+}
+
+// NewClient creates a cedar-agent client, filling unset settings with defaults
+func NewClient(cfg ClientConfig) *Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 100 * time.Millisecond
+	}
+	if cfg.BreakerThreshold <= 0 {
+		cfg.BreakerThreshold = 5
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = 30 * time.Second
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		httpClient: httpClient,
+		maxRetries: cfg.MaxRetries,
+		backoff:    cfg.RetryBackoff,
+		breaker:    newCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+
+		reconnected: make(chan struct{}, 1),
+	}
+}
+
+// PutPolicies replaces the agent's policy set with the given policies
+func (c *Client) PutPolicies(ctx context.Context, policies []models.Policy) error {
+	logger := utils.GetLogger()
+	logger.Debugf("Pushing %d policies to cedar-agent", len(policies))
+
+	body := make([]agentPolicy, 0, len(policies))
+	for _, p := range policies {
+		content, err := RenderPolicy(p)
+		if err != nil {
+			logger.Errorf("Failed to render policy %s", p.ID)
+			return err
+		}
+		body = append(body, agentPolicy{ID: p.ID, Content: content})
+	}
+
+	if err := c.do(ctx, http.MethodPut, "/v1/policies", body, nil); err != nil {
+		logger.Error("Failed to push policies to cedar-agent")
+		return err
+	}
+	logger.Info("Policies pushed to cedar-agent")
+	return nil
+}
+
+// GetPolicies returns the policy set the agent currently holds
+func (c *Client) GetPolicies(ctx context.Context) (map[string]string, error) {
+	var policies []agentPolicy
+	if err := c.do(ctx, http.MethodGet, "/v1/policies", nil, &policies); err != nil {
+		utils.GetLogger().Error("Failed to read policies from cedar-agent")
+		return nil, err
+	}
+	content := make(map[string]string, len(policies))
+	for _, p := range policies {
+		content[p.ID] = p.Content
+	}
+	return content, nil
+}
+
+// PoliciesMatch reports whether the agent holds exactly the given policies. An
+// agent that restarted comes back without them.
+func (c *Client) PoliciesMatch(ctx context.Context, policies []models.Policy) (bool, error) {
+	held, err := c.GetPolicies(ctx)
+	if err != nil {
+		return false, err
+	}
+	if len(held) != len(policies) {
+		return false, nil
+	}
+	for _, p := range policies {
+		content, err := RenderPolicy(p)
+		if err != nil {
+			return false, err
+		}
+		if held[p.ID] != content {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Reconnected is signalled when the agent answers again after the circuit
+// breaker opened, so callers can push state the agent may have lost
+func (c *Client) Reconnected() <-chan struct{} {
+	return c.reconnected
+}
+
+// IsAuthorized asks the agent for a decision. The principal and resource are sent
+// as additional entities so their parents take part in `in` checks.
+func (c *Client) IsAuthorized(ctx context.Context, req models.AuthzRequest) (*models.AuthzDecision, error) {
+	logger := utils.GetLogger()
+
+	entities, err := toAgentEntities([]models.Entity{req.Principal, req.Resource})
+	if err != nil {
+		return nil, err
+	}
+
+	reqContext := req.Context
+	if reqContext == nil {
+		reqContext = map[string]string{}
+	}
+
+	body := agentAuthzRequest{
+		Principal:          req.Principal.UID,
+		Action:             req.Action,
+		Resource:           req.Resource.UID,
+		Context:            reqContext,
+		AdditionalEntities: entities,
+	}
+
+	var resp agentAuthzResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/is_authorized", body, &resp); err != nil {
+		logger.Error("cedar-agent authorization request failed")
+		return nil, err
+	}
+	if len(resp.Diagnostics.Errors) > 0 {
+		logger.Warnf("cedar-agent reported policy errors: %s", strings.Join(resp.Diagnostics.Errors, "; "))
+	}
+
+	reasons := resp.Diagnostics.Reason
+	if reasons == nil {
+		reasons = []string{}
+	}
+	return &models.AuthzDecision{Allowed: resp.Decision == "Allow", Policies: reasons}, nil
+}
+
+// Authorize lets the client be used as the service authorizer
+func (c *Client) Authorize(ctx context.Context, req models.AuthzRequest) (*models.AuthzDecision, error) {
+	return c.IsAuthorized(ctx, req)
+}
+
+// SyncPolicies lets the service push policy changes to the agent
+func (c *Client) SyncPolicies(ctx context.Context, policies []models.Policy) error {
+	return c.PutPolicies(ctx, policies)
+}
+
+// do sends a JSON request through the circuit breaker, retrying transport errors,
+// 429 and 5xx responses with exponential backoff
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	logger := utils.GetLogger()
+
+	var payload []byte
+	var err error
+	if in != nil {
+		if payload, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	if err := c.breaker.allow(); err != nil {
+		logger.Warn("cedar-agent circuit breaker is open, skipping call")
+		return err
+	}
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		err = c.send(ctx, method, path, payload, out)
+		if err == nil {
+			c.succeeded()
+			return nil
+		}
+		if !retryable(err) || attempt >= c.maxRetries || ctx.Err() != nil {
+			break
+		}
+
+		logger.Warnf("cedar-agent call %s %s failed (attempt %d), retrying: %v", method, path, attempt+1, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			c.breaker.failure()
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+
+	if retryable(err) {
+		c.breaker.failure()
+	} else {
+		// The agent answered, it just rejected the request
+		c.succeeded()
+	}
+	return err
+}
+
+// succeeded closes the breaker and signals Reconnected when it was open
+func (c *Client) succeeded() {
+	if !c.breaker.success() {
+		return
+	}
+	utils.GetLogger().Info("cedar-agent is reachable again")
+	select {
+	case c.reconnected <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, payload []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &statusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(msg))}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidResponse, err)
+	}
+	return nil
+}
+
+// retryable reports whether a failed call should be retried and counted by the breaker
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.StatusCode == http.StatusTooManyRequests || se.StatusCode >= 500
+	}
+	return !errors.Is(err, errInvalidResponse) && !errors.Is(err, context.Canceled)
+}
+
+func toAgentEntities(entities []models.Entity) ([]agentEntity, error) {
+	out := make([]agentEntity, 0, len(entities))
+	for _, e := range entities {
+		uid, err := toAgentUID(e.UID)
+		if err != nil {
+			return nil, err
+		}
+		parents := make([]agentEntityUID, 0, len(e.Parents))
+		for _, p := range e.Parents {
+			parent, err := toAgentUID(p)
+			if err != nil {
+				return nil, err
+			}
+			parents = append(parents, parent)
+		}
+		out = append(out, agentEntity{UID: uid, Attrs: map[string]interface{}{}, Parents: parents})
+	}
+	return out, nil
+}
+
+func toAgentUID(uid string) (agentEntityUID, error) {
+	entityType, id, err := utils.ParseEntityUID(uid)
+	if err != nil {
+		return agentEntityUID{}, err
+	}
+	return agentEntityUID{Type: entityType, ID: id}, nil
+}
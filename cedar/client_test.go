@@ -0,0 +1,343 @@
+package cedar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"kafka-governance/models"
+)
+
+// fakeAgent stands in for cedar-agent: it answers each call with the next status
+// of its script, repeating the last one, and records the request bodies
+type fakeAgent struct {
+	mu       sync.Mutex
+	statuses []int
+	decision string
+	calls    int
+	bodies   [][]byte
+	policies []agentPolicy
+}
+
+func (a *fakeAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var body json.RawMessage
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	a.bodies = append(a.bodies, body)
+
+	status := http.StatusOK
+	if len(a.statuses) > 0 {
+		status = a.statuses[min(a.calls, len(a.statuses)-1)]
+	}
+	a.calls++
+	if status != http.StatusOK {
+		http.Error(w, "scripted failure", status)
+		return
+	}
+	if r.URL.Path == "/v1/policies" && r.Method == http.MethodGet {
+		json.NewEncoder(w).Encode(a.policies)
+		return
+	}
+	if r.URL.Path == "/v1/is_authorized" {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"decision":    a.decision,
+			"diagnostics": map[string]interface{}{"reason": []string{"p1"}, "errors": []string{}},
+		})
+	}
+}
+
+func (a *fakeAgent) callCount() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.calls
+}
+
+func newTestClient(t *testing.T, agent *fakeAgent, cfg ClientConfig) *Client {
+	t.Helper()
+	srv := httptest.NewServer(agent)
+	t.Cleanup(srv.Close)
+
+	cfg.BaseURL = srv.URL
+	if cfg.RetryBackoff == 0 {
+		cfg.RetryBackoff = time.Millisecond
+	}
+	return NewClient(cfg)
+}
+
+var testRequest = models.AuthzRequest{
+	Principal: models.Entity{UID: `User::"alice"`, Parents: []string{`Group::"admins"`}},
+	Action:    `Action::"CreateTopic"`,
+	Resource:  models.Entity{UID: `Topic::"orders"`, Parents: []string{`Cluster::"dev"`}},
+	Context:   map[string]string{"cluster": "dev"},
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		maxRetries int
+		wantCalls  int
+		wantErr    bool
+	}{
+		{name: "success first time", statuses: []int{200}, maxRetries: 2, wantCalls: 1},
+		{name: "recovers after 5xx", statuses: []int{503, 500, 200}, maxRetries: 2, wantCalls: 3},
+		{name: "retries 429", statuses: []int{429, 200}, maxRetries: 1, wantCalls: 2},
+		{name: "gives up after max retries", statuses: []int{503}, maxRetries: 2, wantCalls: 3, wantErr: true},
+		{name: "no retries configured", statuses: []int{503}, maxRetries: 0, wantCalls: 1, wantErr: true},
+		{name: "client error is not retried", statuses: []int{400}, maxRetries: 2, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := &fakeAgent{statuses: tt.statuses, decision: "Allow"}
+			client := newTestClient(t, agent, ClientConfig{MaxRetries: tt.maxRetries, BreakerThreshold: 100})
+
+			decision, err := client.IsAuthorized(context.Background(), testRequest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if got := agent.callCount(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			if !tt.wantErr && !decision.Allowed {
+				t.Errorf("decision = %+v, want allowed", decision)
+			}
+		})
+	}
+}
+
+func TestClientDecision(t *testing.T) {
+	tests := []struct {
+		decision string
+		want     bool
+	}{
+		{decision: "Allow", want: true},
+		{decision: "Deny", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.decision, func(t *testing.T) {
+			agent := &fakeAgent{decision: tt.decision}
+			client := newTestClient(t, agent, ClientConfig{})
+
+			decision, err := client.IsAuthorized(context.Background(), testRequest)
+			if err != nil {
+				t.Fatalf("IsAuthorized: %v", err)
+			}
+			if decision.Allowed != tt.want {
+				t.Errorf("allowed = %v, want %v", decision.Allowed, tt.want)
+			}
+
+			var sent agentAuthzRequest
+			if err := json.Unmarshal(agent.bodies[0], &sent); err != nil {
+				t.Fatalf("decoding request: %v", err)
+			}
+			if sent.Principal != testRequest.Principal.UID || sent.Context["cluster"] != "dev" {
+				t.Errorf("request = %+v", sent)
+			}
+			// The principal and resource travel with their parents
+			if len(sent.AdditionalEntities) != 2 || sent.AdditionalEntities[0].Parents[0] != (agentEntityUID{Type: "Group", ID: "admins"}) {
+				t.Errorf("additional entities = %+v", sent.AdditionalEntities)
+			}
+		})
+	}
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name string
+		// trial is the status the agent returns to the half-open trial call
+		trial     int
+		wantErr   bool
+		wantAfter error
+	}{
+		{name: "trial success closes the breaker", trial: 200, wantAfter: nil},
+		{name: "trial failure reopens the breaker", trial: 503, wantErr: true, wantAfter: ErrCircuitOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := &fakeAgent{statuses: []int{503}, decision: "Allow"}
+			client := newTestClient(t, agent, ClientConfig{BreakerThreshold: 2, BreakerCooldown: 50 * time.Millisecond})
+			ctx := context.Background()
+
+			for i := 0; i < 2; i++ {
+				if _, err := client.IsAuthorized(ctx, testRequest); err == nil {
+					t.Fatalf("call %d succeeded against a failing agent", i)
+				}
+			}
+			if _, err := client.IsAuthorized(ctx, testRequest); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("err = %v, want the breaker open", err)
+			}
+			if got := agent.callCount(); got != 2 {
+				t.Fatalf("calls = %d, the open breaker must not reach the agent", got)
+			}
+
+			// After the cooldown a single trial call goes through
+			time.Sleep(60 * time.Millisecond)
+			agent.mu.Lock()
+			agent.statuses = []int{tt.trial}
+			agent.calls = 0
+			agent.mu.Unlock()
+
+			_, err := client.IsAuthorized(ctx, testRequest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("trial err = %v, want error %v", err, tt.wantErr)
+			}
+			if _, err := client.IsAuthorized(ctx, testRequest); !errors.Is(err, tt.wantAfter) {
+				t.Errorf("after trial err = %v, want %v", err, tt.wantAfter)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpenAllowsOneTrial(t *testing.T) {
+	b := newCircuitBreaker(1, 10*time.Millisecond)
+	b.failure()
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("open breaker allowed a call")
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	if err := b.allow(); err != nil {
+		t.Fatalf("half-open breaker refused the trial call: %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("half-open breaker allowed a second call before the trial reported back")
+	}
+	b.success()
+	if err := b.allow(); err != nil {
+		t.Errorf("breaker stayed open after a successful trial: %v", err)
+	}
+}
+
+func TestPutPoliciesRendersCedar(t *testing.T) {
+	policies := []models.Policy{
+		{ID: "p1", Principal: `Group::"admins"`, Action: "*", Resource: "*", Effect: "permit"},
+		{ID: "p2", Principal: "*", Action: `Action::"CreateTopic"`, Resource: `Environment::"prod"`, Effect: "forbid"},
+		{ID: "p3", Principal: `User::"*"`, Action: `Action::"UpdateTopic"`, Resource: `Topic::"*"`, Effect: "permit",
+			Conditions: map[string]string{"owner": "true", "cluster": "dev"}},
+	}
+	want := []agentPolicy{
+		{ID: "p1", Content: `permit (principal in Group::"admins", action, resource);`},
+		{ID: "p2", Content: `forbid (principal, action in Action::"CreateTopic", resource in Environment::"prod");`},
+		{ID: "p3", Content: `permit (principal is User, action in Action::"UpdateTopic", resource is Topic) when { context["cluster"] == "dev" && context["owner"] == "true" };`},
+	}
+
+	agent := &fakeAgent{}
+	client := newTestClient(t, agent, ClientConfig{})
+	if err := client.PutPolicies(context.Background(), policies); err != nil {
+		t.Fatalf("PutPolicies: %v", err)
+	}
+
+	var sent []agentPolicy
+	if err := json.Unmarshal(agent.bodies[0], &sent); err != nil {
+		t.Fatalf("decoding policies: %v", err)
+	}
+	if len(sent) != len(want) {
+		t.Fatalf("sent %d policies, want %d", len(sent), len(want))
+	}
+	for i := range want {
+		if sent[i] != want[i] {
+			t.Errorf("policy %d:\n got %s\nwant %s", i, sent[i].Content, want[i].Content)
+		}
+	}
+}
+
+func TestPutPoliciesRejectsUnrenderablePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy models.Policy
+	}{
+		{name: "bad effect", policy: models.Policy{ID: "p", Principal: "*", Action: "*", Resource: "*", Effect: "allow"}},
+		{name: "bad principal", policy: models.Policy{ID: "p", Principal: "alice", Action: "*", Resource: "*", Effect: "permit"}},
+		{name: "unquoted id", policy: models.Policy{ID: "p", Principal: "*", Action: "Action::CreateTopic", Resource: "*", Effect: "permit"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := &fakeAgent{}
+			client := newTestClient(t, agent, ClientConfig{})
+			if err := client.PutPolicies(context.Background(), []models.Policy{tt.policy}); err == nil {
+				t.Fatal("PutPolicies accepted a policy that cannot be rendered")
+			}
+			if agent.callCount() != 0 {
+				t.Error("an unrenderable policy set reached the agent")
+			}
+		})
+	}
+}
+
+func TestPoliciesMatch(t *testing.T) {
+	policies := []models.Policy{
+		{ID: "p1", Principal: `Group::"admins"`, Action: "*", Resource: "*", Effect: "permit"},
+		{ID: "p2", Principal: "*", Action: `Action::"DeleteTopic"`, Resource: `Environment::"prod"`, Effect: "forbid"},
+	}
+	held := []agentPolicy{
+		{ID: "p1", Content: `permit (principal in Group::"admins", action, resource);`},
+		{ID: "p2", Content: `forbid (principal, action in Action::"DeleteTopic", resource in Environment::"prod");`},
+	}
+	tests := []struct {
+		name string
+		held []agentPolicy
+		want bool
+	}{
+		{name: "same policies", held: held, want: true},
+		{name: "restarted agent", held: []agentPolicy{}},
+		{name: "missing policy", held: held[:1]},
+		{name: "changed policy", held: []agentPolicy{held[0], {ID: "p2", Content: `permit (principal, action, resource);`}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, &fakeAgent{policies: tt.held}, ClientConfig{})
+			match, err := client.PoliciesMatch(context.Background(), policies)
+			if err != nil {
+				t.Fatalf("PoliciesMatch: %v", err)
+			}
+			if match != tt.want {
+				t.Errorf("PoliciesMatch = %v, want %v", match, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientSignalsReconnect(t *testing.T) {
+	agent := &fakeAgent{statuses: []int{503}, decision: "Allow"}
+	client := newTestClient(t, agent, ClientConfig{BreakerThreshold: 1, BreakerCooldown: 10 * time.Millisecond})
+	ctx := context.Background()
+
+	if _, err := client.IsAuthorized(ctx, testRequest); err == nil {
+		t.Fatal("call succeeded against a failing agent")
+	}
+	select {
+	case <-client.Reconnected():
+		t.Fatal("reconnect signalled while the agent is down")
+	default:
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	agent.mu.Lock()
+	agent.statuses = nil
+	agent.mu.Unlock()
+	if _, err := client.IsAuthorized(ctx, testRequest); err != nil {
+		t.Fatalf("IsAuthorized: %v", err)
+	}
+	select {
+	case <-client.Reconnected():
+	default:
+		t.Fatal("no reconnect signalled after the breaker closed")
+	}
+
+	// Calls on a closed breaker do not signal again
+	if _, err := client.IsAuthorized(ctx, testRequest); err != nil {
+		t.Fatalf("IsAuthorized: %v", err)
+	}
+	select {
+	case <-client.Reconnected():
+		t.Error("reconnect signalled without an outage")
+	default:
+	}
+}
@@ -0,0 +1,69 @@
+package cedar
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+// RenderPolicy converts a stored policy into Cedar policy text, e.g.
+//
+//	permit (principal in User::"u_123", action in Action::"CreateTopic", resource in Cluster::"dev")
+//	when { context.cluster == "dev" };
+func RenderPolicy(p models.Policy) (string, error) {
+	if p.Effect != utils.EffectPermit && p.Effect != utils.EffectForbid {
+		return "", fmt.Errorf("policy %s has invalid effect %q", p.ID, p.Effect)
+	}
+
+	principal, err := renderScope("principal", p.Principal)
+	if err != nil {
+		return "", err
+	}
+	action, err := renderScope("action", p.Action)
+	if err != nil {
+		return "", err
+	}
+	resource, err := renderScope("resource", p.Resource)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s, %s, %s)", p.Effect, principal, action, resource)
+
+	if len(p.Conditions) > 0 {
+		keys := make([]string, 0, len(p.Conditions))
+		for k := range p.Conditions {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		clauses := make([]string, 0, len(keys))
+		for _, k := range keys {
+			clauses = append(clauses, fmt.Sprintf("context[%s] == %s", strconv.Quote(k), strconv.Quote(p.Conditions[k])))
+		}
+		fmt.Fprintf(&b, " when { %s }", strings.Join(clauses, " && "))
+	}
+	b.WriteString(";")
+	return b.String(), nil
+}
+
+// renderScope mirrors the scope rules of utils.EvaluatePolicy
+func renderScope(variable, scope string) (string, error) {
+	if scope == "" || scope == "*" {
+		return variable, nil
+	}
+
+	entityType, id, err := utils.ParseEntityUID(scope)
+	if err != nil {
+		return "", err
+	}
+	if id == "*" {
+		return variable + " is " + entityType, nil
+	}
+	return variable + " in " + utils.EntityUID(entityType, id), nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"kafka-governance/config"
+	"kafka-governance/models"
+	"kafka-governance/service"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const commandUsage = `Usage: kafka-governance [command]
+
+Without a command the HTTP server is started.
+
+Commands:
+  import -cluster <name> [-actor <id>]   Import existing topics of a registered cluster
+  audit-verify                           Walk the audit chain and report the first break
+  audit-verify-export -file <export.json> -public-key <pub.pem>
+                                         Verify an audit export offline against its signed checkpoints
+  token -sub <id> [-groups a,b] [-roles r] [-ttl 1h]
+                                         Mint an HS256 token signed with JWT_SECRET; needs DEV_MODE=true
+`
+
+// runCommand runs a one-off CLI command and returns the process exit code
+func runCommand(svc *service.Service, args []string) int {
+	switch args[0] {
+	case "import":
+		return runImport(svc, args[1:])
+	case "audit-verify":
+		return runAuditVerify(svc, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", args[0], commandUsage)
+		return 2
+	}
+}
+
+func runImport(svc *service.Service, args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	cluster := fs.String("cluster", "", "registered cluster to import topics from")
+	actor := fs.String("actor", "system:import", "actor recorded on the imported topics")
+	timeout := fs.Duration("timeout", 5*time.Minute, "maximum duration of the import")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *cluster == "" {
+		fmt.Fprint(os.Stderr, "-cluster is required\n\n", commandUsage)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	result, err := svc.ImportTopics(ctx, *cluster, *actor)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import failed: %v\n", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		return 1
+	}
+	return 0
+}
+
+func runAuditVerify(svc *service.Service, args []string) int {
+	fs := flag.NewFlagSet("audit-verify", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 10*time.Minute, "maximum duration of the verification")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	result, err := svc.VerifyAuditChain(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "audit verification failed: %v\n", err)
+		return 1
+	}
+	return printVerification(*result)
+}
+
+func runAuditVerifyExport(args []string) int {
+	fs := flag.NewFlagSet("audit-verify-export", flag.ContinueOnError)
+	file := fs.String("file", "", "audit export from GET /api/v1/audit/export")
+	publicKey := fs.String("public-key", "", "PEM Ed25519 public key of the checkpoint signer")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *file == "" || *publicKey == "" {
+		fmt.Fprint(os.Stderr, "-file and -public-key are required\n\n", commandUsage)
+		return 2
+	}
+
+	keyData, err := os.ReadFile(*publicKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reading public key failed: %v\n", err)
+		return 1
+	}
+	pub, err := service.ParseAuditPublicKey(keyData)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid public key: %v\n", err)
+		return 1
+	}
+
+	exportData, err := os.ReadFile(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reading export failed: %v\n", err)
+		return 1
+	}
+	var export models.AuditExport
+	if err := json.Unmarshal(exportData, &export); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid export: %v\n", err)
+		return 1
+	}
+
+	return printVerification(service.VerifyAuditExport(export, pub))
+}
+
+// printVerification writes the result as JSON; the exit code is 1 when the chain is broken
+func printVerification(result models.AuditVerification) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		return 1
+	}
+	if !result.Valid {
+		return 1
+	}
+	return 0
+}
+
+func runToken(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("token", flag.ContinueOnError)
+	sub := fs.String("sub", "", "subject (user id) of the token")
+	groups := fs.String("groups", "", "comma-separated groups")
+	roles := fs.String("roles", "", "comma-separated roles")
+	ttl := fs.Duration("ttl", time.Hour, "token lifetime")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *sub == "" {
+		fmt.Fprint(os.Stderr, "-sub is required\n\n", commandUsage)
+		return 2
+	}
+
+	// Anyone who can run the binary could otherwise mint tokens for any subject
+	// with the production secret
+	if !cfg.DevMode {
+		fmt.Fprintln(os.Stderr, "token is only available with DEV_MODE=true")
+		return 1
+	}
+
+	if cfg.JWTSecret == "" {
+		fmt.Fprintln(os.Stderr, "JWT_SECRET is not set, tokens cannot be signed")
+		return 1
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":    *sub,
+		"iat":    now.Unix(),
+		"exp":    now.Add(*ttl).Unix(),
+		"groups": splitList(*groups),
+		"roles":  splitList(*roles),
+	}
+	if cfg.JWTIssuer != "" {
+		claims["iss"] = cfg.JWTIssuer
+	}
+	if cfg.JWTAudience != "" {
+		claims["aud"] = cfg.JWTAudience
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.JWTSecret))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "signing token failed: %v\n", err)
+		return 1
+	}
+	fmt.Println(token)
+	return 0
+}
+
+func splitList(s string) []string {
+	out := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -1,40 +1,196 @@
 package config
 
 import (
+	"errors"
 	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
-	AppPort          string
-	MongoURI         string
-	DBName           string
-	CedarURL         string
-	JWTSecret        string
-	UserCollection   string
-	TopicCollection  string
-	PolicyCollection string
+	AppPort              string
+	Storage              StorageConfig
+	MigrationTimeout     time.Duration
+	CedarURL             string
+	AuthzEngine          string // "local" or "agent"
+	BootstrapAdmin       string
+	CedarTimeout         time.Duration
+	CedarRetries         int
+	DevMode              bool
+	JWTSecret            string
+	JWTPublicKeyFile     string
+	JWTIssuer            string
+	JWTAudience          string
+	OIDCIssuerURL        string
+	OIDCAudience         string
+	OIDCClientID         string
+	OIDCClientSecret     string
+	OIDCRedirectURL      string
+	OIDCUserClaim        string
+	OIDCGroupsClaim      string
+	OIDCRolesClaim       string
+	OIDCEmailClaim       string
+	OIDCKeyCacheTTL      time.Duration
+	KafkaAdmin           string // "kafka", "memory" or "none"
+	KafkaTimeout         time.Duration
+	KafkaCADir           string // cluster CA files must be inside this directory
+	ProvisionEvery       time.Duration
+	ProvisionRetries     int
+	DeletionGrace        time.Duration
+	DriftEvery           time.Duration
+	AuditSigningKeyFile  string
+	AuditCheckpointEvery time.Duration
+	PolicySyncEvery      time.Duration
+}
+
+// StorageConfig selects the store and names the MongoDB database and collections.
+// Every environment sharing a Mongo cluster needs its own database or collection names.
+type StorageConfig struct {
+	Backend     string // "mongo" or "memory"
+	MongoURI    string
+	Database    string
+	Collections Collections
+}
+
+// Collections names the MongoDB collection behind each repository
+type Collections struct {
+	Topics           string
+	Policies         string
+	Clusters         string
+	Drift            string
+	Users            string
+	Groups           string
+	Workflows        string
+	Audit            string
+	AuditCheckpoints string
+	Revisions        string
+	Migrations       string
 }
 
 func Load() *Config {
 	cfg := &Config{
-		AppPort:          getEnv("APP_PORT", "8080"),
-		MongoURI:         getEnv("MONGO_URI", "mongodb://localhost:27017"),
-		DBName:           getEnv("MONGO_DB", "kafkaGovernance"),
-		UserCollection:   getEnv("USER_COLLECTION", "users"),
-		TopicCollection:  getEnv("TOPIC_COLLECTION", "topics"),
-		PolicyCollection: getEnv("POLICY_COLLECTION", "policies"),
-		CedarURL:         getEnv("CEDAR_URL", "http://localhost:8180"),
-		JWTSecret:        getEnv("JWT_SECRET", "dev-secret"),
+		AppPort: getEnv("APP_PORT", "8080"),
+		Storage: StorageConfig{
+			Backend:  getEnv("STORE", "mongo"),
+			MongoURI: getEnv("MONGO_URI", "mongodb://localhost:27017"),
+			Database: getEnv("MONGO_DB", "kafka_governance"),
+			Collections: Collections{
+				Topics:           getEnv("TOPIC_COLLECTION", "topics"),
+				Policies:         getEnv("POLICY_COLLECTION", "policies"),
+				Clusters:         getEnv("CLUSTER_COLLECTION", "clusters"),
+				Drift:            getEnv("DRIFT_COLLECTION", "drift"),
+				Users:            getEnv("USER_COLLECTION", "users"),
+				Groups:           getEnv("GROUP_COLLECTION", "groups"),
+				Workflows:        getEnv("WORKFLOW_COLLECTION", "workflows"),
+				Audit:            getEnv("AUDIT_COLLECTION", "audit"),
+				AuditCheckpoints: getEnv("AUDIT_CHECKPOINT_COLLECTION", "audit_checkpoints"),
+				Revisions:        getEnv("REVISION_COLLECTION", "topic_revisions"),
+				Migrations:       getEnv("MIGRATION_COLLECTION", "migrations"),
+			},
+		},
+		MigrationTimeout:     getEnvDuration("MIGRATION_TIMEOUT", 10*time.Minute),
+		CedarURL:             getEnv("CEDAR_URL", "http://localhost:8180"),
+		AuthzEngine:          getEnv("AUTHZ_ENGINE", "local"),
+		BootstrapAdmin:       getEnv("BOOTSTRAP_ADMIN", ""),
+		CedarTimeout:         getEnvDuration("CEDAR_TIMEOUT", 2*time.Second),
+		CedarRetries:         getEnvInt("CEDAR_RETRIES", 2),
+		DevMode:              getEnvBool("DEV_MODE", false),
+		JWTSecret:            getEnv("JWT_SECRET", ""),
+		JWTPublicKeyFile:     getEnv("JWT_PUBLIC_KEY_FILE", ""),
+		JWTIssuer:            getEnv("JWT_ISSUER", ""),
+		JWTAudience:          getEnv("JWT_AUDIENCE", ""),
+		OIDCIssuerURL:        getEnv("OIDC_ISSUER_URL", ""),
+		OIDCClientID:         getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:     getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:      getEnv("OIDC_REDIRECT_URL", ""),
+		OIDCUserClaim:        getEnv("OIDC_USERNAME_CLAIM", "sub"),
+		OIDCGroupsClaim:      getEnv("OIDC_GROUPS_CLAIM", "groups"),
+		OIDCRolesClaim:       getEnv("OIDC_ROLES_CLAIM", "roles"),
+		OIDCEmailClaim:       getEnv("OIDC_EMAIL_CLAIM", "email"),
+		OIDCKeyCacheTTL:      getEnvDuration("OIDC_KEY_CACHE_TTL", time.Hour),
+		KafkaAdmin:           getEnv("KAFKA_ADMIN", "kafka"),
+		KafkaTimeout:         getEnvDuration("KAFKA_TIMEOUT", 10*time.Second),
+		KafkaCADir:           getEnv("KAFKA_CA_DIR", ""),
+		ProvisionEvery:       getEnvDuration("PROVISION_INTERVAL", 30*time.Second),
+		ProvisionRetries:     getEnvInt("PROVISION_MAX_ATTEMPTS", 10),
+		DeletionGrace:        getEnvDuration("TOPIC_DELETION_GRACE", 7*24*time.Hour),
+		DriftEvery:           getEnvDuration("DRIFT_INTERVAL", 5*time.Minute),
+		AuditSigningKeyFile:  getEnv("AUDIT_SIGNING_KEY_FILE", ""),
+		AuditCheckpointEvery: getEnvDuration("AUDIT_CHECKPOINT_INTERVAL", time.Hour),
+		PolicySyncEvery:      getEnvDuration("POLICY_SYNC_INTERVAL", 30*time.Second),
 	}
+	// ID tokens carry the client id as their audience
+	cfg.OIDCAudience = getEnv("OIDC_AUDIENCE", cfg.OIDCClientID)
 
 	log.Println("Config loaded")
 	return cfg
 }
 
+// devJWTSecret is the well-known HS256 secret of local development setups
+const devJWTSecret = "dev-secret"
+
+// CheckAuth refuses token settings that would let anyone mint valid tokens: an
+// unset JWT_SECRET or the development secret, unless DEV_MODE is set. Dev mode
+// is for local runs only and cannot be combined with OIDC. In dev mode an unset
+// JWT_SECRET falls back to the development secret.
+func (c *Config) CheckAuth() error {
+	if c.OIDCIssuerURL != "" {
+		if c.DevMode {
+			return errors.New("DEV_MODE cannot be used together with OIDC_ISSUER_URL")
+		}
+		return nil
+	}
+
+	if c.DevMode {
+		if c.JWTSecret == "" {
+			c.JWTSecret = devJWTSecret
+		}
+		log.Println("DEV_MODE is set, tokens may be signed with a development secret")
+		return nil
+	}
+	if c.JWTSecret == devJWTSecret {
+		return errors.New("JWT_SECRET is the development secret, set a real one or DEV_MODE=true for local development")
+	}
+	if c.JWTSecret == "" && c.JWTPublicKeyFile == "" {
+		return errors.New("JWT_SECRET or JWT_PUBLIC_KEY_FILE is required, or DEV_MODE=true for local development")
+	}
+	return nil
+}
+
 func getEnv(key, fallback string) string {
 	if val, ok := os.LookupEnv(key); ok {
 		return val
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	if val, ok := os.LookupEnv(key); ok {
+		if n, err := strconv.Atoi(val); err == nil {
+			return n
+		}
+		log.Printf("Invalid %s '%s', using %d", key, val, fallback)
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if val, ok := os.LookupEnv(key); ok {
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+		log.Printf("Invalid %s '%s', using %t", key, val, fallback)
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if val, ok := os.LookupEnv(key); ok {
+		if d, err := time.ParseDuration(val); err == nil {
+			return d
+		}
+		log.Printf("Invalid %s '%s', using %s", key, val, fallback)
+	}
+	return fallback
+}
@@ -0,0 +1,33 @@
+package config
+
+import "testing"
+
+func TestCheckAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        Config
+		wantErr    bool
+		wantSecret string
+	}{
+		{name: "unset secret", cfg: Config{}, wantErr: true},
+		{name: "development secret", cfg: Config{JWTSecret: "dev-secret"}, wantErr: true},
+		{name: "real secret", cfg: Config{JWTSecret: "s3cr3t-from-vault"}, wantSecret: "s3cr3t-from-vault"},
+		{name: "public key only", cfg: Config{JWTPublicKeyFile: "/etc/keys/jwt.pem"}},
+		{name: "dev mode defaults the secret", cfg: Config{DevMode: true}, wantSecret: "dev-secret"},
+		{name: "dev mode keeps a set secret", cfg: Config{DevMode: true, JWTSecret: "local"}, wantSecret: "local"},
+		{name: "OIDC ignores the secret", cfg: Config{OIDCIssuerURL: "https://idp.example.com"}},
+		{name: "dev mode with OIDC", cfg: Config{DevMode: true, OIDCIssuerURL: "https://idp.example.com"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			err := cfg.CheckAuth()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckAuth() = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg.JWTSecret != tt.wantSecret {
+				t.Errorf("JWTSecret = %q, want %q", cfg.JWTSecret, tt.wantSecret)
+			}
+		})
+	}
+}
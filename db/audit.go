@@ -0,0 +1,211 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"kafka-governance/config"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoAuditRepository stores the audit chain and its checkpoints in MongoDB. The
+// event collection is append-only: the repository has no update or delete methods.
+type MongoAuditRepository struct {
+	events      *mongo.Collection
+	checkpoints *mongo.Collection
+}
+
+// ErrAuditSeqTaken is returned when another writer appended an event with the same seq
+var ErrAuditSeqTaken = errors.New("audit sequence number already taken")
+
+// NewMongoAuditRepository relies on the unique seq index created by the migrations
+func NewMongoAuditRepository(db *mongo.Database, names config.Collections) *MongoAuditRepository {
+	// Decode nested documents as maps so change values hash the same as when written
+	opts := options.Collection().SetBSONOptions(&options.BSONOptions{DefaultDocumentM: true})
+	return &MongoAuditRepository{
+		events:      db.Collection(names.Audit, opts),
+		checkpoints: db.Collection(names.AuditCheckpoints),
+	}
+}
+
+// Insert appends a sealed event, returning ErrAuditSeqTaken when its seq is in use
+func (r *MongoAuditRepository) Insert(ctx context.Context, event *models.AuditEvent) error {
+	logger := utils.GetLogger()
+	logger.Debug("Inserting audit event into database")
+
+	_, err := r.events.InsertOne(ctx, event)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrAuditSeqTaken
+	}
+	if err != nil {
+		logger.Error("Failed to insert audit event into database")
+		return err
+	}
+	return nil
+}
+
+// Last returns the event with the highest seq, or nil when the chain is empty
+func (r *MongoAuditRepository) Last(ctx context.Context) (*models.AuditEvent, error) {
+	var event models.AuditEvent
+	err := r.events.FindOne(ctx,
+		bson.M{"seq": bson.M{"$gt": 0}},
+		options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}}),
+	).Decode(&event)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Walk calls fn for each chained event from fromSeq up to toSeq (0 for
+// the head) in seq order, stopping early when fn returns false
+func (r *MongoAuditRepository) Walk(ctx context.Context, fromSeq, toSeq int64, fn func(models.AuditEvent) bool) error {
+	logger := utils.GetLogger()
+	logger.Debug("Walking audit chain in database")
+
+	seq := bson.M{"$gte": max(fromSeq, 1)}
+	if toSeq > 0 {
+		seq["$lte"] = toSeq
+	}
+	cursor, err := r.events.Find(ctx, bson.M{"seq": seq},
+		options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}),
+	)
+	if err != nil {
+		logger.Error("Failed to query audit chain from database")
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var event models.AuditEvent
+		if err := cursor.Decode(&event); err != nil {
+			return err
+		}
+		if !fn(event) {
+			return nil
+		}
+	}
+	return cursor.Err()
+}
+
+func (r *MongoAuditRepository) InsertCheckpoint(ctx context.Context, checkpoint *models.AuditCheckpoint) error {
+	logger := utils.GetLogger()
+	logger.Debug("Inserting audit checkpoint into database")
+
+	if _, err := r.checkpoints.InsertOne(ctx, checkpoint); err != nil {
+		logger.Error("Failed to insert audit checkpoint into database")
+		return err
+	}
+	return nil
+}
+
+// ListCheckpoints returns the checkpoints between fromSeq and toSeq (0 for no
+// upper bound) in seq order
+func (r *MongoAuditRepository) ListCheckpoints(ctx context.Context, fromSeq, toSeq int64) ([]models.AuditCheckpoint, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching audit checkpoints from database")
+
+	seq := bson.M{"$gte": fromSeq}
+	if toSeq > 0 {
+		seq["$lte"] = toSeq
+	}
+	cursor, err := r.checkpoints.Find(ctx, bson.M{"_id": seq},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		logger.Error("Failed to query audit checkpoints from database")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var checkpoints []models.AuditCheckpoint
+	if err := cursor.All(ctx, &checkpoints); err != nil {
+		logger.Error("Failed to decode audit checkpoints from cursor")
+		return nil, err
+	}
+	return checkpoints, nil
+}
+
+// LastCheckpoint returns the newest checkpoint, or nil when there is none
+func (r *MongoAuditRepository) LastCheckpoint(ctx context.Context) (*models.AuditCheckpoint, error) {
+	var checkpoint models.AuditCheckpoint
+	err := r.checkpoints.FindOne(ctx, bson.M{},
+		options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}}),
+	).Decode(&checkpoint)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// List returns up to limit events matching filter, newest first. When
+// afterAt is set only events older than the (afterAt, afterID) position are returned.
+func (r *MongoAuditRepository) List(ctx context.Context, filter models.AuditFilter, afterAt *time.Time, afterID string, limit int) ([]models.AuditEvent, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching audit events from database")
+
+	query := bson.M{}
+	if filter.Actor != "" {
+		query["actor"] = filter.Actor
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.ResourceType != "" {
+		query["resourceType"] = filter.ResourceType
+	}
+	if filter.Resource != "" {
+		query["resource"] = filter.Resource
+	}
+	if filter.RequestID != "" {
+		query["requestId"] = filter.RequestID
+	}
+
+	at := bson.M{}
+	if filter.Since != nil {
+		at["$gte"] = *filter.Since
+	}
+	if filter.Until != nil {
+		at["$lt"] = *filter.Until
+	}
+	if len(at) > 0 {
+		query["at"] = at
+	}
+
+	if afterAt != nil {
+		query["$or"] = bson.A{
+			bson.M{"at": bson.M{"$lt": *afterAt}},
+			bson.M{"at": *afterAt, "_id": bson.M{"$lt": afterID}},
+		}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.events.Find(ctx, query, opts)
+	if err != nil {
+		logger.Error("Failed to query audit events from database")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []models.AuditEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		logger.Error("Failed to decode audit events from cursor")
+		return nil, err
+	}
+	logger.Infof("Successfully fetched audit events from database, count: %d", len(events))
+	return events, nil
+}
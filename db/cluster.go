@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"kafka-governance/config"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoClusterRepository stores registered clusters in MongoDB
+type MongoClusterRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoClusterRepository(db *mongo.Database, names config.Collections) *MongoClusterRepository {
+	return &MongoClusterRepository{collection: db.Collection(names.Clusters)}
+}
+
+func (r *MongoClusterRepository) Insert(ctx context.Context, cluster *models.Cluster) (*models.Cluster, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Inserting cluster into database")
+
+	cluster.ID = uuid.New().String()
+	cluster.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, cluster)
+	if mongo.IsDuplicateKeyError(err) {
+		logger.Error("Cluster with same name already exists")
+		return nil, utils.NewAlreadyExistsError("cluster with same name already exists")
+	}
+	if err != nil {
+		logger.Error("Failed to insert cluster into database")
+		return nil, err
+	}
+	logger.Info("Cluster inserted successfully")
+	return cluster, nil
+}
+
+func (r *MongoClusterRepository) List(ctx context.Context) ([]models.Cluster, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching clusters from database")
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		logger.Error("Failed to query clusters from database")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var clusters []models.Cluster
+	if err := cursor.All(ctx, &clusters); err != nil {
+		logger.Error("Failed to decode clusters from cursor")
+		return nil, err
+	}
+	logger.Infof("Successfully fetched clusters from database, count: %d", len(clusters))
+	return clusters, nil
+}
+
+func (r *MongoClusterRepository) GetByName(ctx context.Context, name string) (*models.Cluster, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching cluster by name from database")
+
+	var cluster models.Cluster
+	err := r.collection.FindOne(ctx, bson.M{"name": name}).Decode(&cluster)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Error("Cluster not found in database")
+		return nil, utils.NewNotFoundError("cluster not found")
+	}
+	if err != nil {
+		logger.Error("Failed to fetch cluster from database")
+		return nil, err
+	}
+	logger.Info("Cluster found in database")
+	return &cluster, nil
+}
+
+// Update replaces the settings of a cluster, keeping its name, id and creation time
+func (r *MongoClusterRepository) Update(ctx context.Context, name string, cluster *models.Cluster) (*models.Cluster, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Updating cluster in database")
+
+	var updated models.Cluster
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"name": name},
+		bson.M{
+			"$set": bson.M{
+				"bootstrapServers":  cluster.BootstrapServers,
+				"security":          cluster.Security,
+				"environment":       cluster.Environment,
+				"brokerCount":       cluster.BrokerCount,
+				"defaultPartitions": cluster.DefaultPartitions,
+				"maxPartitions":     cluster.MaxPartitions,
+				"defaultReplicas":   cluster.DefaultReplicas,
+				"maxReplicas":       cluster.MaxReplicas,
+				"configLimits":      cluster.ConfigLimits,
+				"updatedAt":         time.Now(),
+			},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Error("Cluster not found in database")
+		return nil, utils.NewNotFoundError("cluster not found")
+	}
+	if err != nil {
+		logger.Error("Failed to update cluster in database")
+		return nil, err
+	}
+	logger.Info("Cluster updated in database successfully")
+	return &updated, nil
+}
+
+func (r *MongoClusterRepository) Delete(ctx context.Context, name string) error {
+	logger := utils.GetLogger()
+	logger.Debug("Deleting cluster from database")
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		logger.Error("Failed to delete cluster from database")
+		return err
+	}
+	if result.DeletedCount == 0 {
+		logger.Error("Cluster not found in database")
+		return utils.NewNotFoundError("cluster not found")
+	}
+	logger.Info("Cluster deleted from database successfully")
+	return nil
+}
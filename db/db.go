@@ -3,8 +3,11 @@ package db
 import (
 	"context"
 	"errors"
+	"fmt"
+	"kafka-governance/config"
 	"kafka-governance/models"
 	"kafka-governance/utils"
+	"regexp"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,17 +17,20 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var Client *mongo.Client
-var topicCollection *mongo.Collection
+// MongoTopicRepository stores topics in MongoDB
+type MongoTopicRepository struct {
+	collection *mongo.Collection
+}
 
-func Connect(uri string) (*mongo.Client, *mongo.Database, error) {
+// Connect opens the MongoDB client and returns the configured database
+func Connect(cfg config.StorageConfig) (*mongo.Client, *mongo.Database, error) {
 	logger := utils.GetLogger()
 	logger.Info("Attempting to connect to MongoDB")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
 	if err != nil {
 		logger.Error("MongoDB connection failed")
 		return nil, nil, err
@@ -38,67 +44,84 @@ func Connect(uri string) (*mongo.Client, *mongo.Database, error) {
 	}
 	logger.Info("MongoDB connection successful")
 
-	db := client.Database("kafka_governance")
+	db := client.Database(cfg.Database)
+	logger.Infof("Using MongoDB database %s", cfg.Database)
 
 	return client, db, nil
 }
 
-func PolicyCollection(dbName string) *mongo.Collection {
-	return Client.Database(dbName).Collection("policies")
+func NewMongoTopicRepository(db *mongo.Database, names config.Collections) *MongoTopicRepository {
+	return &MongoTopicRepository{collection: db.Collection(names.Topics)}
 }
 
-func InsertPolicy(
-	ctx context.Context,
-	policy models.Policy,
-) error {
+func (r *MongoTopicRepository) Create(ctx context.Context, topic *models.Topic) (*models.Topic, error) {
 	logger := utils.GetLogger()
-	logger.Debug("Inserting policy into database")
+	logger.Debug("Creating topic in database")
 
-	collection := PolicyCollection("kafka_governance")
-	_, err := collection.InsertOne(ctx, policy)
+	topic.ID = uuid.New().String()
+	topic.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, topic)
+	if mongo.IsDuplicateKeyError(err) {
+		// The name is free again once its topic is deleted; the audit log keeps its history
+		result, delErr := r.collection.DeleteOne(ctx, bson.M{"cluster": topic.Cluster, "name": topic.Name, "status": models.TopicDeleted})
+		if delErr != nil {
+			logger.Error("Failed to remove deleted topic with same name")
+			return nil, delErr
+		}
+		if result.DeletedCount > 0 {
+			logger.Info("Replacing deleted topic with the same name")
+			_, err = r.collection.InsertOne(ctx, topic)
+		}
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		logger.Error("Topic with same name already exists on cluster")
+		return nil, utils.NewAlreadyExistsError("topic with same name already exists on cluster")
+	}
 	if err != nil {
-		logger.Error("Failed to insert policy into database")
-		return err
+		logger.Error("Failed to create topic in database")
+		return nil, err
 	}
-	logger.Info("Policy inserted successfully")
-	return nil
-}
-
-func InitTopicRepo(db *mongo.Database) {
-	logger := utils.GetLogger()
-	logger.Debug("Initializing topic repository")
-	topicCollection = db.Collection("topics")
-	logger.Info("Topic repository initialized")
+	logger.Info("Topic created in database successfully")
+	return topic, nil
 }
 
-func CreateTopic(ctx context.Context, topic *models.Topic) (*models.Topic, error) {
+// Import inserts a topic found on a cluster unless the cluster already has a
+// governed topic with the same name, so running an import twice never duplicates records. It
+// returns the existing record, or nil when the topic was inserted.
+func (r *MongoTopicRepository) Import(ctx context.Context, topic *models.Topic) (*models.Topic, error) {
 	logger := utils.GetLogger()
-	logger.Debug("Creating topic in database")
-
-	var existingTopic models.Topic
-	err := topicCollection.FindOne(ctx, bson.M{"name": topic.Name}).Decode(&existingTopic)
-	if err == nil {
-		logger.Error("Topic with same name already exists")
-		return nil, errors.New("topic with same name already exists")
-	}
+	logger.Debug("Importing topic into database")
 
 	topic.ID = uuid.New().String()
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"cluster": topic.Cluster, "name": topic.Name},
+		bson.M{"$setOnInsert": topic},
+		options.Update().SetUpsert(true),
+	)
+	// A concurrent import of the same topic loses the upsert with a duplicate key
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		logger.Error("Failed to import topic into database")
+		return nil, err
+	}
+	if err == nil && result.UpsertedCount > 0 {
+		logger.Info("Topic imported into database")
+		return nil, nil
+	}
 
-	topic.CreatedAt = time.Now()
-	_, err = topicCollection.InsertOne(ctx, topic)
+	existing, err := r.Get(ctx, topic.Cluster, topic.Name)
 	if err != nil {
-		logger.Error("Failed to create topic in database")
 		return nil, err
 	}
-	logger.Info("Topic created in database successfully")
-	return topic, nil
+	logger.Debug("Topic already governed, import skipped")
+	return existing, nil
 }
 
-func ListTopics(ctx context.Context) ([]models.Topic, error) {
+func (r *MongoTopicRepository) List(ctx context.Context) ([]models.Topic, error) {
 	logger := utils.GetLogger()
 	logger.Debug("Fetching topics from database")
 
-	cursor, err := topicCollection.Find(ctx, bson.M{})
+	cursor, err := r.collection.Find(ctx, bson.M{})
 	if err != nil {
 		logger.Error("Failed to query topics from database")
 		return nil, err
@@ -115,40 +138,718 @@ func ListTopics(ctx context.Context) ([]models.Topic, error) {
 	return topics, nil
 }
 
-func GetTopicByName(ctx context.Context, name string) (*models.Topic, error) {
+// topicQuery translates a topic filter into a MongoDB query
+func topicQuery(filter models.TopicFilter) bson.M {
+	query := bson.M{}
+	if filter.Cluster != "" {
+		query["cluster"] = filter.Cluster
+	}
+	if len(filter.Statuses) > 0 {
+		query["status"] = bson.M{"$in": filter.Statuses}
+	}
+	if filter.RequestedBy != "" {
+		query["requestedBy"] = filter.RequestedBy
+	}
+	if filter.OwnerTeam != "" {
+		query["ownerTeam"] = filter.OwnerTeam
+	}
+	if len(filter.ExcludeOwnerTeams) > 0 {
+		team := bson.M{"$nin": filter.ExcludeOwnerTeams}
+		if filter.OwnerTeam != "" {
+			team["$eq"] = filter.OwnerTeam
+		}
+		query["ownerTeam"] = team
+	}
+	for key, value := range filter.Labels {
+		if value == "" {
+			query["labels."+key] = bson.M{"$exists": true}
+		} else {
+			query["labels."+key] = value
+		}
+	}
+
+	// Both name conditions must hold, so a regex does not replace the prefix
+	var names bson.A
+	if filter.NamePrefix != "" {
+		names = append(names, bson.M{"name": bson.M{"$regex": "^" + regexp.QuoteMeta(filter.NamePrefix)}})
+	}
+	if filter.NameRegex != "" {
+		names = append(names, bson.M{"name": bson.M{"$regex": filter.NameRegex}})
+	}
+	if len(names) > 0 {
+		query["$and"] = names
+	}
+
+	createdAt := bson.M{}
+	if filter.CreatedSince != nil {
+		createdAt["$gte"] = *filter.CreatedSince
+	}
+	if filter.CreatedUntil != nil {
+		createdAt["$lt"] = *filter.CreatedUntil
+	}
+	if len(createdAt) > 0 {
+		query["createdAt"] = createdAt
+	}
+	return query
+}
+
+// topicQueryMaxTime bounds the server time of a filtered topic query, so a
+// costly name regex cannot tie up the database
+const topicQueryMaxTime = 5 * time.Second
+
+// topicQueryError reports a query stopped by topicQueryMaxTime as the caller's
+// to fix
+func topicQueryError(err error) error {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.IsMaxTimeMSExpiredError() {
+		return utils.NewInvalidInputError("Topic query took too long, narrow the filters")
+	}
+	return err
+}
+
+// topicSortValue is the value of the sort field of a topic
+func topicSortValue(topic *models.Topic, field string) interface{} {
+	switch field {
+	case models.TopicSortCluster:
+		return topic.Cluster
+	case models.TopicSortStatus:
+		return topic.Status
+	case models.TopicSortCreatedAt:
+		return topic.CreatedAt
+	default:
+		return topic.Name
+	}
+}
+
+func (r *MongoTopicRepository) Page(ctx context.Context, filter models.TopicFilter, sort models.TopicSort, after *models.Topic, limit int) ([]models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching topic page from database")
+
+	order, past := 1, "$gt"
+	if sort.Desc {
+		order, past = -1, "$lt"
+	}
+
+	query := topicQuery(filter)
+	if after != nil {
+		value := topicSortValue(after, sort.Field)
+		query["$or"] = bson.A{
+			bson.M{sort.Field: bson.M{past: value}},
+			bson.M{sort.Field: value, "_id": bson.M{past: after.ID}},
+		}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: sort.Field, Value: order}, {Key: "_id", Value: order}}).
+		SetProjection(bson.M{"transitions": 0}).
+		SetLimit(int64(limit)).
+		SetMaxTime(topicQueryMaxTime)
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		logger.Error("Failed to query topics from database")
+		return nil, topicQueryError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var topics []models.Topic
+	if err := cursor.All(ctx, &topics); err != nil {
+		logger.Error("Failed to decode topics from cursor")
+		return nil, topicQueryError(err)
+	}
+	logger.Infof("Successfully fetched topic page from database, count: %d", len(topics))
+	return topics, nil
+}
+
+func (r *MongoTopicRepository) ListMatching(ctx context.Context, filter models.TopicFilter) ([]models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching matching topics from database")
+
+	opts := options.Find().SetProjection(bson.M{"transitions": 0}).SetMaxTime(topicQueryMaxTime)
+	cursor, err := r.collection.Find(ctx, topicQuery(filter), opts)
+	if err != nil {
+		logger.Error("Failed to query topics from database")
+		return nil, topicQueryError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var topics []models.Topic
+	if err := cursor.All(ctx, &topics); err != nil {
+		logger.Error("Failed to decode topics from cursor")
+		return nil, topicQueryError(err)
+	}
+	logger.Debugf("Fetched matching topics from database, count: %d", len(topics))
+	return topics, nil
+}
+
+func (r *MongoTopicRepository) Count(ctx context.Context, filter models.TopicFilter, limit int64) (int64, error) {
+	opts := options.Count().SetMaxTime(topicQueryMaxTime)
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	count, err := r.collection.CountDocuments(ctx, topicQuery(filter), opts)
+	if err != nil {
+		utils.GetLogger().Error("Failed to count topics in database")
+		return 0, topicQueryError(err)
+	}
+	return count, nil
+}
+
+func (r *MongoTopicRepository) Get(ctx context.Context, cluster, name string) (*models.Topic, error) {
 	logger := utils.GetLogger()
 	logger.Debug("Fetching topic by name from database")
 
 	var topic models.Topic
-	err := topicCollection.FindOne(ctx, bson.M{"name": name}).Decode(&topic)
-	if err != nil {
+	err := r.collection.FindOne(ctx, bson.M{"cluster": cluster, "name": name}).Decode(&topic)
+	if errors.Is(err, mongo.ErrNoDocuments) {
 		logger.Error("Topic not found in database")
+		return nil, utils.NewNotFoundError("topic not found")
+	}
+	if err != nil {
+		logger.Error("Failed to fetch topic from database")
 		return nil, err
 	}
 	logger.Info("Topic found in database")
 	return &topic, nil
 }
 
-func ApproveTopic(ctx context.Context, name, approvedBy string) error {
+// FindByName returns the topics with the given name on every cluster
+func (r *MongoTopicRepository) FindByName(ctx context.Context, name string) ([]models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching topics by name from database")
+
+	opts := options.Find().SetSort(bson.D{{Key: "cluster", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"name": name}, opts)
+	if err != nil {
+		logger.Error("Failed to query topics by name from database")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var topics []models.Topic
+	if err := cursor.All(ctx, &topics); err != nil {
+		logger.Error("Failed to decode topics from cursor")
+		return nil, err
+	}
+	return topics, nil
+}
+
+// Approve moves a pending topic to APPROVED and records the approver
+func (r *MongoTopicRepository) Approve(ctx context.Context, cluster, name string, transition models.TopicTransition) (*models.Topic, error) {
 	logger := utils.GetLogger()
 	logger.Debug("Updating topic approval status in database")
 
-	now := time.Now()
-	_, err := topicCollection.UpdateOne(
+	return r.transition(ctx, cluster, name, transition, bson.M{
+		"approvedBy": transition.Actor,
+		"approvedAt": transition.At,
+	})
+}
+
+// StartApproval attaches workflow state to a pending topic that does not have any yet
+func (r *MongoTopicRepository) StartApproval(ctx context.Context, cluster, name string, approval *models.TopicApproval) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Starting topic approval workflow in database")
+
+	return r.updateMatching(ctx,
+		bson.M{"cluster": cluster, "name": name, "status": models.TopicPending, "approval": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"approval": approval}},
+	)
+}
+
+// RecordApprovalVote appends a vote while the topic is pending, still in the vote's
+// stage and has no earlier vote by the same approver. It returns nil when the
+// topic no longer matches those conditions.
+func (r *MongoTopicRepository) RecordApprovalVote(ctx context.Context, cluster, name string, vote models.ApprovalVote) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Recording topic approval vote in database")
+
+	return r.updateMatching(ctx,
+		bson.M{
+			"cluster":                 cluster,
+			"name":                    name,
+			"status":                  models.TopicPending,
+			"approval.stage":          vote.Stage,
+			"approval.votes.approver": bson.M{"$ne": vote.Approver},
+		},
+		bson.M{
+			"$push": bson.M{"approval.votes": vote},
+			"$set":  bson.M{"updatedAt": vote.At},
+		},
+	)
+}
+
+// AdvanceApprovalStage moves a pending topic from stage to the next one. It
+// returns nil when another request already advanced it.
+func (r *MongoTopicRepository) AdvanceApprovalStage(ctx context.Context, cluster, name string, stage int) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Advancing topic approval stage in database")
+
+	return r.updateMatching(ctx,
+		bson.M{"cluster": cluster, "name": name, "status": models.TopicPending, "approval.stage": stage},
+		bson.M{"$inc": bson.M{"approval.stage": 1}},
+	)
+}
+
+// updateMatching applies update to the topic matching filter as its next
+// revision, returning nil without an error when nothing matched
+func (r *MongoTopicRepository) updateMatching(ctx context.Context, filter, update bson.M) (*models.Topic, error) {
+	logger := utils.GetLogger()
+
+	inc, _ := update["$inc"].(bson.M)
+	if inc == nil {
+		inc = bson.M{}
+	}
+	inc["revision"] = 1
+	update["$inc"] = inc
+
+	var updated models.Topic
+	err := r.collection.FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Error("Failed to update topic in database")
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// RequestChange opens a change request on an ACTIVE topic that has none
+func (r *MongoTopicRepository) RequestChange(ctx context.Context, cluster, name string, change *models.TopicChange) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Opening topic change request in database")
+
+	return r.updateMatching(ctx,
+		bson.M{"cluster": cluster, "name": name, "status": models.TopicActive, "change": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"change": change, "updatedAt": change.RequestedAt}},
+	)
+}
+
+// changeFilter matches the topic while its change request is changeID in status
+func changeFilter(cluster, name, changeID string, status models.TopicChangeStatus) bson.M {
+	return bson.M{"cluster": cluster, "name": name, "change.id": changeID, "change.status": status}
+}
+
+// RecordChangeVote appends a vote while the change is pending, still in the
+// vote's stage and has no earlier vote by the same approver
+func (r *MongoTopicRepository) RecordChangeVote(ctx context.Context, cluster, name, changeID string, vote models.ApprovalVote) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Recording topic change vote in database")
+
+	filter := changeFilter(cluster, name, changeID, models.ChangePending)
+	filter["change.approval.stage"] = vote.Stage
+	filter["change.approval.votes.approver"] = bson.M{"$ne": vote.Approver}
+	return r.updateMatching(ctx, filter, bson.M{
+		"$push": bson.M{"change.approval.votes": vote},
+		"$set":  bson.M{"updatedAt": vote.At},
+	})
+}
+
+// AdvanceChangeStage moves a pending change from stage to the next one
+func (r *MongoTopicRepository) AdvanceChangeStage(ctx context.Context, cluster, name, changeID string, stage int) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Advancing topic change stage in database")
+
+	filter := changeFilter(cluster, name, changeID, models.ChangePending)
+	filter["change.approval.stage"] = stage
+	return r.updateMatching(ctx, filter, bson.M{"$inc": bson.M{"change.approval.stage": 1}})
+}
+
+// ApproveChange marks a pending change as approved and ready to apply
+func (r *MongoTopicRepository) ApproveChange(ctx context.Context, cluster, name, changeID, approver string, at time.Time) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Approving topic change in database")
+
+	return r.updateMatching(ctx,
+		changeFilter(cluster, name, changeID, models.ChangePending),
+		bson.M{"$set": bson.M{
+			"change.status":     models.ChangeApproved,
+			"change.approvedBy": approver,
+			"change.approvedAt": at,
+			"updatedAt":         at,
+		}},
+	)
+}
+
+// RecordChangeFailure stores the broker error of a failed attempt to apply a change
+func (r *MongoTopicRepository) RecordChangeFailure(ctx context.Context, cluster, name, changeID, brokerErr string, at time.Time) error {
+	logger := utils.GetLogger()
+	logger.Debug("Recording topic change failure in database")
+
+	_, err := r.collection.UpdateOne(ctx,
+		changeFilter(cluster, name, changeID, models.ChangeApproved),
+		bson.M{
+			"$set": bson.M{
+				"change.applyError":         brokerErr,
+				"change.lastApplyAttemptAt": at,
+			},
+			"$inc": bson.M{"change.applyAttempts": 1},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to record topic change failure")
+		return err
+	}
+	return nil
+}
+
+// FailChange moves an approved change the provisioner gave up on to FAILED
+func (r *MongoTopicRepository) FailChange(ctx context.Context, cluster, name, changeID string, at time.Time) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Marking topic change as failed in database")
+
+	return r.updateMatching(ctx,
+		changeFilter(cluster, name, changeID, models.ChangeApproved),
+		bson.M{"$set": bson.M{"change.status": models.ChangeFailed, "updatedAt": at}},
+	)
+}
+
+// RetryChange moves a failed change back to APPROVED with its attempts cleared
+func (r *MongoTopicRepository) RetryChange(ctx context.Context, cluster, name, changeID string, at time.Time) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Resetting topic change attempts in database")
+
+	return r.updateMatching(ctx,
+		changeFilter(cluster, name, changeID, models.ChangeFailed),
+		bson.M{
+			"$set":   bson.M{"change.status": models.ChangeApproved, "updatedAt": at},
+			"$unset": bson.M{"change.applyAttempts": "", "change.lastApplyAttemptAt": ""},
+		},
+	)
+}
+
+// ApplyChange stores the applied partitions and configs and closes the change
+func (r *MongoTopicRepository) ApplyChange(ctx context.Context, cluster, name, changeID string, partitions int, configs models.TopicConfigs, at time.Time) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Applying topic change in database")
+
+	return r.updateMatching(ctx,
+		changeFilter(cluster, name, changeID, models.ChangeApproved),
+		bson.M{
+			"$set":   bson.M{"partitions": partitions, "configs": configs, "updatedAt": at},
+			"$unset": bson.M{"change": ""},
+		},
+	)
+}
+
+// CloseChange removes a pending or failed change request
+func (r *MongoTopicRepository) CloseChange(ctx context.Context, cluster, name, changeID string, status models.TopicChangeStatus) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Closing topic change request in database")
+
+	return r.updateMatching(ctx,
+		changeFilter(cluster, name, changeID, status),
+		bson.M{"$unset": bson.M{"change": ""}, "$set": bson.M{"updatedAt": time.Now()}},
+	)
+}
+
+// ListApprovedChanges returns the topics whose change request awaits applying
+func (r *MongoTopicRepository) ListApprovedChanges(ctx context.Context) ([]models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching topics with approved changes from database")
+
+	cursor, err := r.collection.Find(ctx, bson.M{"change.status": models.ChangeApproved})
+	if err != nil {
+		logger.Error("Failed to query topics with approved changes from database")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var topics []models.Topic
+	if err := cursor.All(ctx, &topics); err != nil {
+		logger.Error("Failed to decode topics from cursor")
+		return nil, err
+	}
+	return topics, nil
+}
+
+// ScheduleDeletion approves a pending deletion and deprecates the ACTIVE topic
+// until removeAfter
+func (r *MongoTopicRepository) ScheduleDeletion(ctx context.Context, cluster, name, changeID string, transition models.TopicTransition, removeAfter time.Time) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Scheduling topic deletion in database")
+
+	return r.changeTransition(ctx, cluster, name, changeID, models.ChangePending, transition,
+		bson.M{
+			"change.status":      models.ChangeApproved,
+			"change.approvedBy":  transition.Actor,
+			"change.approvedAt":  transition.At,
+			"change.removeAfter": removeAfter,
+		}, nil,
+	)
+}
+
+// CancelDeletion closes an approved or failed deletion and returns the topic to ACTIVE
+func (r *MongoTopicRepository) CancelDeletion(ctx context.Context, cluster, name, changeID string, status models.TopicChangeStatus, transition models.TopicTransition) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Cancelling topic deletion in database")
+
+	return r.changeTransition(ctx, cluster, name, changeID, status, transition, bson.M{}, bson.M{"change": ""})
+}
+
+// MarkDeleted closes an approved deletion once the topic is removed from its cluster
+func (r *MongoTopicRepository) MarkDeleted(ctx context.Context, cluster, name, changeID string, transition models.TopicTransition) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Marking topic as deleted in database")
+
+	return r.changeTransition(ctx, cluster, name, changeID, models.ChangeApproved, transition, bson.M{}, bson.M{"change": ""})
+}
+
+// changeTransition moves the topic from transition.From to transition.To while
+// its change request is changeID in status, setting and unsetting the given fields
+func (r *MongoTopicRepository) changeTransition(ctx context.Context, cluster, name, changeID string, status models.TopicChangeStatus, transition models.TopicTransition, set, unset bson.M) (*models.Topic, error) {
+	filter := changeFilter(cluster, name, changeID, status)
+	filter["status"] = transition.From
+
+	set["status"] = transition.To
+	set["updatedAt"] = transition.At
+	update := bson.M{
+		"$set":  set,
+		"$push": bson.M{"transitions": transition},
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return r.updateMatching(ctx, filter, update)
+}
+
+// AddClient registers an application in a role it does not hold yet
+func (r *MongoTopicRepository) AddClient(ctx context.Context, cluster, name string, client models.TopicClient) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Registering topic client in database")
+
+	return r.updateMatching(ctx,
+		bson.M{
+			"cluster": cluster,
+			"name":    name,
+			"clients": bson.M{"$not": bson.M{"$elemMatch": bson.M{"application": client.Application, "role": client.Role}}},
+		},
+		bson.M{"$push": bson.M{"clients": client}, "$set": bson.M{"updatedAt": client.RegisteredAt}},
+	)
+}
+
+// RemoveClient unregisters an application from a role
+func (r *MongoTopicRepository) RemoveClient(ctx context.Context, cluster, name, application string, role models.ClientRole) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Unregistering topic client in database")
+
+	registration := bson.M{"application": application, "role": role}
+	return r.updateMatching(ctx,
+		bson.M{"cluster": cluster, "name": name, "clients": bson.M{"$elemMatch": registration}},
+		bson.M{"$pull": bson.M{"clients": registration}, "$set": bson.M{"updatedAt": time.Now()}},
+	)
+}
+
+// FindProducedBy returns the topics not yet deleted that any of the applications produces to
+func (r *MongoTopicRepository) FindProducedBy(ctx context.Context, applications []string) ([]models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching topics by producer from database")
+
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"clients": bson.M{"$elemMatch": bson.M{
+			"application": bson.M{"$in": applications},
+			"role":        models.ClientProducer,
+		}},
+		"status": bson.M{"$ne": models.TopicDeleted},
+	}, options.Find().SetSort(bson.D{{Key: "cluster", Value: 1}, {Key: "name", Value: 1}}))
+	if err != nil {
+		logger.Error("Failed to query topics by producer from database")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var topics []models.Topic
+	if err := cursor.All(ctx, &topics); err != nil {
+		logger.Error("Failed to decode topics from cursor")
+		return nil, err
+	}
+	return topics, nil
+}
+
+// UpdateOwnership replaces the owners and on-call contact of a topic
+func (r *MongoTopicRepository) UpdateOwnership(ctx context.Context, cluster, name string, owners []string, onCall string, at time.Time) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Updating topic ownership in database")
+
+	return r.updateMatching(ctx,
+		bson.M{"cluster": cluster, "name": name},
+		bson.M{"$set": bson.M{"owners": owners, "onCall": onCall, "updatedAt": at}},
+	)
+}
+
+// RequestTransfer opens an ownership transfer on a topic without one, as long
+// as it is still owned by the transfer's FromTeam
+func (r *MongoTopicRepository) RequestTransfer(ctx context.Context, cluster, name string, transfer *models.OwnershipTransfer) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Opening topic ownership transfer in database")
+
+	filter := bson.M{"cluster": cluster, "name": name, "transfer": bson.M{"$exists": false}}
+	if transfer.FromTeam == "" {
+		filter["ownerTeam"] = bson.M{"$in": bson.A{nil, ""}}
+	} else {
+		filter["ownerTeam"] = transfer.FromTeam
+	}
+	return r.updateMatching(ctx, filter,
+		bson.M{"$set": bson.M{"transfer": transfer, "updatedAt": transfer.RequestedAt}},
+	)
+}
+
+// AcceptTransfer hands the topic to team with its new owners and closes the transfer
+func (r *MongoTopicRepository) AcceptTransfer(ctx context.Context, cluster, name, transferID, team string, owners []string, onCall string, at time.Time) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Accepting topic ownership transfer in database")
+
+	return r.updateMatching(ctx,
+		bson.M{"cluster": cluster, "name": name, "transfer.id": transferID},
+		bson.M{
+			"$set":   bson.M{"ownerTeam": team, "owners": owners, "onCall": onCall, "updatedAt": at},
+			"$unset": bson.M{"transfer": ""},
+		},
+	)
+}
+
+// CloseTransfer drops an ownership transfer without applying it
+func (r *MongoTopicRepository) CloseTransfer(ctx context.Context, cluster, name, transferID string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Closing topic ownership transfer in database")
+
+	return r.updateMatching(ctx,
+		bson.M{"cluster": cluster, "name": name, "transfer.id": transferID},
+		bson.M{"$unset": bson.M{"transfer": ""}, "$set": bson.M{"updatedAt": time.Now()}},
+	)
+}
+
+// CountOnCluster counts the topics on a cluster that have not been deleted
+func (r *MongoTopicRepository) CountOnCluster(ctx context.Context, cluster string) (int64, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Counting topics on cluster in database")
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{
+		"cluster": cluster,
+		"status":  bson.M{"$ne": models.TopicDeleted},
+	})
+	if err != nil {
+		logger.Error("Failed to count topics on cluster")
+		return 0, err
+	}
+	return count, nil
+}
+
+// ListByStatus returns the topics currently in any of the given statuses
+func (r *MongoTopicRepository) ListByStatus(ctx context.Context, statuses ...models.TopicStatus) ([]models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching topics by status from database")
+
+	cursor, err := r.collection.Find(ctx, bson.M{"status": bson.M{"$in": statuses}})
+	if err != nil {
+		logger.Error("Failed to query topics by status from database")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var topics []models.Topic
+	if err := cursor.All(ctx, &topics); err != nil {
+		logger.Error("Failed to decode topics from cursor")
+		return nil, err
+	}
+	logger.Debugf("Fetched topics by status from database, count: %d", len(topics))
+	return topics, nil
+}
+
+// Activate moves a provisioning topic to ACTIVE and clears any earlier provisioning error
+func (r *MongoTopicRepository) Activate(ctx context.Context, cluster, name string, transition models.TopicTransition) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Marking topic as provisioned in database")
+
+	return r.transition(ctx, cluster, name, transition, bson.M{
+		"provisionedAt":  transition.At,
+		"provisionError": "",
+	})
+}
+
+// RecordProvisionFailure stores the broker error of a failed provisioning attempt
+func (r *MongoTopicRepository) RecordProvisionFailure(ctx context.Context, cluster, name, brokerErr string, at time.Time) error {
+	logger := utils.GetLogger()
+	logger.Debug("Recording topic provisioning failure in database")
+
+	_, err := r.collection.UpdateOne(
 		ctx,
-		bson.M{"name": name},
+		bson.M{"cluster": cluster, "name": name, "status": models.TopicProvisioning},
 		bson.M{
 			"$set": bson.M{
-				"status":     models.TopicApproved,
-				"approvedBy": approvedBy,
-				"approvedAt": now,
+				"provisionError":         brokerErr,
+				"lastProvisionAttemptAt": at,
 			},
+			"$inc": bson.M{"provisionAttempts": 1},
 		},
 	)
 	if err != nil {
-		logger.Error("Failed to update topic approval status")
+		logger.Error("Failed to record provisioning failure")
 		return err
 	}
-	logger.Info("Topic approval status updated successfully")
+	logger.Info("Topic provisioning failure recorded")
 	return nil
 }
+
+// ResetProvisioning moves a failed topic back to PROVISIONING with no attempts recorded
+func (r *MongoTopicRepository) ResetProvisioning(ctx context.Context, cluster, name string, transition models.TopicTransition) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Resetting topic provisioning attempts in database")
+
+	return r.transition(ctx, cluster, name, transition, bson.M{
+		"provisionAttempts":      0,
+		"lastProvisionAttemptAt": nil,
+	})
+}
+
+// Transition atomically moves a topic from transition.From to transition.To
+func (r *MongoTopicRepository) Transition(ctx context.Context, cluster, name string, transition models.TopicTransition) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Debugf("Moving topic from %s to %s in database", transition.From, transition.To)
+
+	return r.transition(ctx, cluster, name, transition, nil)
+}
+
+// transition only updates the topic while it is still in transition.From, so
+// two concurrent transitions out of the same status cannot both succeed. The
+// transition is appended to the topic history and fields are set alongside it.
+func (r *MongoTopicRepository) transition(ctx context.Context, cluster, name string, transition models.TopicTransition, fields bson.M) (*models.Topic, error) {
+	logger := utils.GetLogger()
+
+	set := bson.M{
+		"status":    transition.To,
+		"updatedAt": transition.At,
+	}
+	for k, v := range fields {
+		set[k] = v
+	}
+
+	var updated models.Topic
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"cluster": cluster, "name": name, "status": transition.From},
+		bson.M{
+			"$set":  set,
+			"$push": bson.M{"transitions": transition},
+			"$inc":  bson.M{"revision": 1},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == nil {
+		logger.Infof("Topic status updated to %s", transition.To)
+		return &updated, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Error("Failed to update topic status")
+		return nil, err
+	}
+
+	// Nothing matched: either the topic is gone or its status changed underneath us
+	current, err := r.Get(ctx, cluster, name)
+	if err != nil {
+		return nil, err
+	}
+	logger.Errorf("Topic status is %s, expected %s", current.Status, transition.From)
+	return nil, utils.NewConflictError(fmt.Sprintf("topic is %s, cannot move from %s to %s", current.Status, transition.From, transition.To))
+}
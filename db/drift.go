@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"kafka-governance/config"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDriftRepository keeps the latest drift report of each cluster in MongoDB
+type MongoDriftRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoDriftRepository(db *mongo.Database, names config.Collections) *MongoDriftRepository {
+	return &MongoDriftRepository{collection: db.Collection(names.Drift)}
+}
+
+// Save replaces the stored report of the cluster with the latest one
+func (r *MongoDriftRepository) Save(ctx context.Context, report *models.DriftReport) error {
+	logger := utils.GetLogger()
+	logger.Debug("Saving drift report in database")
+
+	_, err := r.collection.ReplaceOne(
+		ctx,
+		bson.M{"_id": report.Cluster},
+		report,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		logger.Error("Failed to save drift report")
+		return err
+	}
+	logger.Info("Drift report saved successfully")
+	return nil
+}
+
+func (r *MongoDriftRepository) Get(ctx context.Context, cluster string) (*models.DriftReport, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching drift report from database")
+
+	var report models.DriftReport
+	err := r.collection.FindOne(ctx, bson.M{"_id": cluster}).Decode(&report)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Error("Drift report not found in database")
+		return nil, utils.NewNotFoundError("no drift report for cluster yet")
+	}
+	if err != nil {
+		logger.Error("Failed to fetch drift report from database")
+		return nil, err
+	}
+	logger.Info("Drift report found in database")
+	return &report, nil
+}
@@ -0,0 +1,1290 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+)
+
+// NewMemoryStore creates an empty in-process store for development and tests. Its
+// repositories keep the same uniqueness, not-found and conflict rules as the
+// MongoDB ones, and nothing survives a restart.
+func NewMemoryStore() *Store {
+	store := &Store{
+		Topics:    &MemoryTopicRepository{topics: map[topicKey]models.Topic{}},
+		Policies:  &MemoryPolicyRepository{policies: map[string]models.Policy{}},
+		Clusters:  &MemoryClusterRepository{clusters: map[string]models.Cluster{}},
+		Drift:     &MemoryDriftRepository{reports: map[string]models.DriftReport{}},
+		Users:     &MemoryUserRepository{users: map[string]models.User{}},
+		Groups:    &MemoryGroupRepository{groups: map[string]models.Group{}},
+		Workflows: &MemoryWorkflowRepository{workflows: map[string]models.ApprovalWorkflow{}},
+		Audit:     &MemoryAuditRepository{checkpoints: map[int64]models.AuditCheckpoint{}},
+		Revisions: &MemoryRevisionRepository{revisions: map[string][]models.TopicRevision{}},
+	}
+	store.transact = memoryTransaction(store)
+	return store
+}
+
+// clone copies a document through BSON, the way it would be stored and read back
+// from MongoDB, so callers never share memory with the store
+func clone[T any](doc T) T {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		panic(fmt.Sprintf("memory store: cannot encode %T: %s", doc, err))
+	}
+	dec, err := bson.NewDecoder(bsonrw.NewBSONDocumentReader(data))
+	if err != nil {
+		panic(fmt.Sprintf("memory store: cannot decode %T: %s", doc, err))
+	}
+	dec.DefaultDocumentM()
+
+	var copied T
+	if err := dec.Decode(&copied); err != nil {
+		panic(fmt.Sprintf("memory store: cannot decode %T: %s", doc, err))
+	}
+	return copied
+}
+
+// MemoryTopicRepository keeps topics in memory, keyed by cluster and name
+type MemoryTopicRepository struct {
+	mu     sync.Mutex
+	topics map[topicKey]models.Topic
+}
+
+type topicKey struct {
+	cluster string
+	name    string
+}
+
+func keyOf(topic *models.Topic) topicKey {
+	return topicKey{cluster: topic.Cluster, name: topic.Name}
+}
+
+func (r *MemoryTopicRepository) Create(ctx context.Context, topic *models.Topic) (*models.Topic, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.topics[keyOf(topic)]; ok && existing.Status != models.TopicDeleted {
+		return nil, utils.NewAlreadyExistsError("topic with same name already exists on cluster")
+	}
+	topic.ID = uuid.New().String()
+	topic.CreatedAt = time.Now()
+	r.topics[keyOf(topic)] = clone(*topic)
+	return topic, nil
+}
+
+func (r *MemoryTopicRepository) Import(ctx context.Context, topic *models.Topic) (*models.Topic, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.topics[keyOf(topic)]; ok {
+		existing = clone(existing)
+		return &existing, nil
+	}
+	topic.ID = uuid.New().String()
+	r.topics[keyOf(topic)] = clone(*topic)
+	return nil, nil
+}
+
+func (r *MemoryTopicRepository) List(ctx context.Context) ([]models.Topic, error) {
+	return r.list(func(models.Topic) bool { return true }), nil
+}
+
+func (r *MemoryTopicRepository) Page(ctx context.Context, filter models.TopicFilter, order models.TopicSort, after *models.Topic, limit int) ([]models.Topic, error) {
+	match, err := topicMatcher(filter)
+	if err != nil {
+		return nil, err
+	}
+	// compare orders a before b in the requested direction
+	compare := func(a, b *models.Topic) int {
+		c := compareTopicField(a, b, order.Field)
+		if c == 0 {
+			c = strings.Compare(a.ID, b.ID)
+		}
+		if order.Desc {
+			return -c
+		}
+		return c
+	}
+
+	topics := r.list(func(t models.Topic) bool {
+		return match(t) && (after == nil || compare(&t, after) > 0)
+	})
+	sort.Slice(topics, func(i, j int) bool { return compare(&topics[i], &topics[j]) < 0 })
+	if limit > 0 && len(topics) > limit {
+		topics = topics[:limit]
+	}
+	for i := range topics {
+		topics[i].Transitions = nil
+	}
+	return topics, nil
+}
+
+func (r *MemoryTopicRepository) Count(ctx context.Context, filter models.TopicFilter, limit int64) (int64, error) {
+	match, err := topicMatcher(filter)
+	if err != nil {
+		return 0, err
+	}
+	count := int64(len(r.list(match)))
+	if limit > 0 && count > limit {
+		count = limit
+	}
+	return count, nil
+}
+
+// topicMatcher applies a topic filter the way topicQuery does in MongoDB
+func topicMatcher(filter models.TopicFilter) (func(models.Topic) bool, error) {
+	var pattern *regexp.Regexp
+	if filter.NameRegex != "" {
+		var err error
+		if pattern, err = regexp.Compile(filter.NameRegex); err != nil {
+			return nil, utils.NewInvalidInputError("Invalid name regex")
+		}
+	}
+	return func(t models.Topic) bool {
+		if (filter.Cluster != "" && t.Cluster != filter.Cluster) ||
+			(len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, t.Status)) ||
+			(filter.RequestedBy != "" && t.RequestedBy != filter.RequestedBy) ||
+			(filter.OwnerTeam != "" && t.OwnerTeam != filter.OwnerTeam) ||
+			slices.Contains(filter.ExcludeOwnerTeams, t.OwnerTeam) ||
+			!strings.HasPrefix(t.Name, filter.NamePrefix) ||
+			(pattern != nil && !pattern.MatchString(t.Name)) ||
+			(filter.CreatedSince != nil && t.CreatedAt.Before(*filter.CreatedSince)) ||
+			(filter.CreatedUntil != nil && !t.CreatedAt.Before(*filter.CreatedUntil)) {
+			return false
+		}
+		for key, value := range filter.Labels {
+			label, ok := t.Labels[key]
+			if !ok || (value != "" && label != value) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func compareTopicField(a, b *models.Topic, field string) int {
+	switch field {
+	case models.TopicSortCluster:
+		return strings.Compare(a.Cluster, b.Cluster)
+	case models.TopicSortStatus:
+		return strings.Compare(string(a.Status), string(b.Status))
+	case models.TopicSortCreatedAt:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	default:
+		return strings.Compare(a.Name, b.Name)
+	}
+}
+
+func (r *MemoryTopicRepository) Get(ctx context.Context, cluster, name string) (*models.Topic, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	topic, ok := r.topics[topicKey{cluster: cluster, name: name}]
+	if !ok {
+		return nil, utils.NewNotFoundError("topic not found")
+	}
+	topic = clone(topic)
+	return &topic, nil
+}
+
+func (r *MemoryTopicRepository) FindByName(ctx context.Context, name string) ([]models.Topic, error) {
+	topics := r.list(func(t models.Topic) bool { return t.Name == name })
+	sort.Slice(topics, func(i, j int) bool { return topics[i].Cluster < topics[j].Cluster })
+	return topics, nil
+}
+
+func (r *MemoryTopicRepository) CountOnCluster(ctx context.Context, cluster string) (int64, error) {
+	topics := r.list(func(t models.Topic) bool {
+		return t.Cluster == cluster && t.Status != models.TopicDeleted
+	})
+	return int64(len(topics)), nil
+}
+
+func (r *MemoryTopicRepository) ListMatching(ctx context.Context, filter models.TopicFilter) ([]models.Topic, error) {
+	match, err := topicMatcher(filter)
+	if err != nil {
+		return nil, err
+	}
+	topics := r.list(match)
+	for i := range topics {
+		topics[i].Transitions = nil
+	}
+	return topics, nil
+}
+
+func (r *MemoryTopicRepository) ListByStatus(ctx context.Context, statuses ...models.TopicStatus) ([]models.Topic, error) {
+	return r.list(func(t models.Topic) bool { return slices.Contains(statuses, t.Status) }), nil
+}
+
+// list returns copies of the matching topics in creation order
+func (r *MemoryTopicRepository) list(match func(models.Topic) bool) []models.Topic {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var topics []models.Topic
+	for _, topic := range r.topics {
+		if match(topic) {
+			topics = append(topics, clone(topic))
+		}
+	}
+	sort.Slice(topics, func(i, j int) bool {
+		if !topics[i].CreatedAt.Equal(topics[j].CreatedAt) {
+			return topics[i].CreatedAt.Before(topics[j].CreatedAt)
+		}
+		return topics[i].ID < topics[j].ID
+	})
+	return topics
+}
+
+func (r *MemoryTopicRepository) Approve(ctx context.Context, cluster, name string, transition models.TopicTransition) (*models.Topic, error) {
+	return r.transition(topicKey{cluster: cluster, name: name}, transition, func(t *models.Topic) {
+		at := transition.At
+		t.ApprovedBy = transition.Actor
+		t.ApprovedAt = &at
+	})
+}
+
+func (r *MemoryTopicRepository) Activate(ctx context.Context, cluster, name string, transition models.TopicTransition) (*models.Topic, error) {
+	return r.transition(topicKey{cluster: cluster, name: name}, transition, func(t *models.Topic) {
+		at := transition.At
+		t.ProvisionedAt = &at
+		t.ProvisionError = ""
+	})
+}
+
+func (r *MemoryTopicRepository) ResetProvisioning(ctx context.Context, cluster, name string, transition models.TopicTransition) (*models.Topic, error) {
+	return r.transition(topicKey{cluster: cluster, name: name}, transition, func(t *models.Topic) {
+		t.ProvisionAttempts = 0
+		t.LastProvisionAttemptAt = nil
+	})
+}
+
+func (r *MemoryTopicRepository) Transition(ctx context.Context, cluster, name string, transition models.TopicTransition) (*models.Topic, error) {
+	return r.transition(topicKey{cluster: cluster, name: name}, transition, func(*models.Topic) {})
+}
+
+// transition applies the same from-status guard as the MongoDB repository
+func (r *MemoryTopicRepository) transition(key topicKey, transition models.TopicTransition, apply func(*models.Topic)) (*models.Topic, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	topic, ok := r.topics[key]
+	if !ok {
+		return nil, utils.NewNotFoundError("topic not found")
+	}
+	if topic.Status != transition.From {
+		return nil, utils.NewConflictError(fmt.Sprintf("topic is %s, cannot move from %s to %s", topic.Status, transition.From, transition.To))
+	}
+
+	at := transition.At
+	topic.Status = transition.To
+	topic.UpdatedAt = &at
+	topic.Transitions = append(topic.Transitions, transition)
+	topic.Revision++
+	apply(&topic)
+	r.topics[key] = clone(topic)
+
+	topic = clone(topic)
+	return &topic, nil
+}
+
+func (r *MemoryTopicRepository) RecordProvisionFailure(ctx context.Context, cluster, name, brokerErr string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := topicKey{cluster: cluster, name: name}
+	topic, ok := r.topics[key]
+	if !ok || topic.Status != models.TopicProvisioning {
+		return nil
+	}
+	topic.ProvisionError = brokerErr
+	topic.LastProvisionAttemptAt = &at
+	topic.ProvisionAttempts++
+	r.topics[key] = clone(topic)
+	return nil
+}
+
+func (r *MemoryTopicRepository) StartApproval(ctx context.Context, cluster, name string, approval *models.TopicApproval) (*models.Topic, error) {
+	return r.updatePending(topicKey{cluster: cluster, name: name},
+		func(t *models.Topic) bool { return t.Approval == nil },
+		func(t *models.Topic) { t.Approval = approval },
+	)
+}
+
+func (r *MemoryTopicRepository) RecordApprovalVote(ctx context.Context, cluster, name string, vote models.ApprovalVote) (*models.Topic, error) {
+	return r.updatePending(topicKey{cluster: cluster, name: name},
+		func(t *models.Topic) bool {
+			if t.Approval == nil || t.Approval.Stage != vote.Stage {
+				return false
+			}
+			for _, v := range t.Approval.Votes {
+				if v.Approver == vote.Approver {
+					return false
+				}
+			}
+			return true
+		},
+		func(t *models.Topic) {
+			at := vote.At
+			t.Approval.Votes = append(t.Approval.Votes, vote)
+			t.UpdatedAt = &at
+		},
+	)
+}
+
+func (r *MemoryTopicRepository) AdvanceApprovalStage(ctx context.Context, cluster, name string, stage int) (*models.Topic, error) {
+	return r.updatePending(topicKey{cluster: cluster, name: name},
+		func(t *models.Topic) bool { return t.Approval != nil && t.Approval.Stage == stage },
+		func(t *models.Topic) { t.Approval.Stage++ },
+	)
+}
+
+// updatePending applies update to a pending topic accepted by match, returning nil
+// without an error when the topic does not match
+func (r *MemoryTopicRepository) updatePending(key topicKey, match func(*models.Topic) bool, update func(*models.Topic)) (*models.Topic, error) {
+	return r.update(key, func(t *models.Topic) bool { return t.Status == models.TopicPending && match(t) }, update)
+}
+
+// update applies update to the topic as its next revision when match accepts
+// it, returning nil without an error when the topic does not match
+func (r *MemoryTopicRepository) update(key topicKey, match func(*models.Topic) bool, update func(*models.Topic)) (*models.Topic, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	topic, ok := r.topics[key]
+	if !ok || !match(&topic) {
+		return nil, nil
+	}
+	update(&topic)
+	topic.Revision++
+	r.topics[key] = clone(topic)
+
+	topic = clone(topic)
+	return &topic, nil
+}
+
+func (r *MemoryTopicRepository) RequestChange(ctx context.Context, cluster, name string, change *models.TopicChange) (*models.Topic, error) {
+	return r.update(topicKey{cluster: cluster, name: name},
+		func(t *models.Topic) bool { return t.Status == models.TopicActive && t.Change == nil },
+		func(t *models.Topic) {
+			at := change.RequestedAt
+			t.Change = change
+			t.UpdatedAt = &at
+		},
+	)
+}
+
+func (r *MemoryTopicRepository) RecordChangeVote(ctx context.Context, cluster, name, changeID string, vote models.ApprovalVote) (*models.Topic, error) {
+	return r.updateChange(topicKey{cluster: cluster, name: name}, changeID, models.ChangePending,
+		func(c *models.TopicChange) bool {
+			if c.Approval == nil || c.Approval.Stage != vote.Stage {
+				return false
+			}
+			for _, v := range c.Approval.Votes {
+				if v.Approver == vote.Approver {
+					return false
+				}
+			}
+			return true
+		},
+		func(t *models.Topic) {
+			at := vote.At
+			t.Change.Approval.Votes = append(t.Change.Approval.Votes, vote)
+			t.UpdatedAt = &at
+		},
+	)
+}
+
+func (r *MemoryTopicRepository) AdvanceChangeStage(ctx context.Context, cluster, name, changeID string, stage int) (*models.Topic, error) {
+	return r.updateChange(topicKey{cluster: cluster, name: name}, changeID, models.ChangePending,
+		func(c *models.TopicChange) bool { return c.Approval != nil && c.Approval.Stage == stage },
+		func(t *models.Topic) { t.Change.Approval.Stage++ },
+	)
+}
+
+func (r *MemoryTopicRepository) ApproveChange(ctx context.Context, cluster, name, changeID, approver string, at time.Time) (*models.Topic, error) {
+	return r.updateChange(topicKey{cluster: cluster, name: name}, changeID, models.ChangePending, nil,
+		func(t *models.Topic) {
+			t.Change.Status = models.ChangeApproved
+			t.Change.ApprovedBy = approver
+			t.Change.ApprovedAt = &at
+			t.UpdatedAt = &at
+		},
+	)
+}
+
+func (r *MemoryTopicRepository) RecordChangeFailure(ctx context.Context, cluster, name, changeID, brokerErr string, at time.Time) error {
+	_, err := r.updateChange(topicKey{cluster: cluster, name: name}, changeID, models.ChangeApproved, nil,
+		func(t *models.Topic) {
+			t.Change.ApplyError = brokerErr
+			t.Change.LastApplyAttemptAt = &at
+			t.Change.ApplyAttempts++
+		},
+	)
+	return err
+}
+
+func (r *MemoryTopicRepository) FailChange(ctx context.Context, cluster, name, changeID string, at time.Time) (*models.Topic, error) {
+	return r.updateChange(topicKey{cluster: cluster, name: name}, changeID, models.ChangeApproved, nil,
+		func(t *models.Topic) {
+			t.Change.Status = models.ChangeFailed
+			t.UpdatedAt = &at
+		},
+	)
+}
+
+func (r *MemoryTopicRepository) RetryChange(ctx context.Context, cluster, name, changeID string, at time.Time) (*models.Topic, error) {
+	return r.updateChange(topicKey{cluster: cluster, name: name}, changeID, models.ChangeFailed, nil,
+		func(t *models.Topic) {
+			t.Change.Status = models.ChangeApproved
+			t.Change.ApplyAttempts = 0
+			t.Change.LastApplyAttemptAt = nil
+			t.UpdatedAt = &at
+		},
+	)
+}
+
+func (r *MemoryTopicRepository) ApplyChange(ctx context.Context, cluster, name, changeID string, partitions int, configs models.TopicConfigs, at time.Time) (*models.Topic, error) {
+	return r.updateChange(topicKey{cluster: cluster, name: name}, changeID, models.ChangeApproved, nil,
+		func(t *models.Topic) {
+			t.Partitions = partitions
+			t.Configs = configs
+			t.Change = nil
+			t.UpdatedAt = &at
+		},
+	)
+}
+
+func (r *MemoryTopicRepository) CloseChange(ctx context.Context, cluster, name, changeID string, status models.TopicChangeStatus) (*models.Topic, error) {
+	return r.updateChange(topicKey{cluster: cluster, name: name}, changeID, status, nil,
+		func(t *models.Topic) {
+			now := time.Now()
+			t.Change = nil
+			t.UpdatedAt = &now
+		},
+	)
+}
+
+func (r *MemoryTopicRepository) ListApprovedChanges(ctx context.Context) ([]models.Topic, error) {
+	return r.list(func(t models.Topic) bool {
+		return t.Change != nil && t.Change.Status == models.ChangeApproved
+	}), nil
+}
+
+func (r *MemoryTopicRepository) ScheduleDeletion(ctx context.Context, cluster, name, changeID string, transition models.TopicTransition, removeAfter time.Time) (*models.Topic, error) {
+	return r.changeTransition(topicKey{cluster: cluster, name: name}, changeID, models.ChangePending, transition,
+		func(t *models.Topic) {
+			at := transition.At
+			t.Change.Status = models.ChangeApproved
+			t.Change.ApprovedBy = transition.Actor
+			t.Change.ApprovedAt = &at
+			t.Change.RemoveAfter = &removeAfter
+		},
+	)
+}
+
+func (r *MemoryTopicRepository) CancelDeletion(ctx context.Context, cluster, name, changeID string, status models.TopicChangeStatus, transition models.TopicTransition) (*models.Topic, error) {
+	return r.changeTransition(topicKey{cluster: cluster, name: name}, changeID, status, transition,
+		func(t *models.Topic) { t.Change = nil },
+	)
+}
+
+func (r *MemoryTopicRepository) MarkDeleted(ctx context.Context, cluster, name, changeID string, transition models.TopicTransition) (*models.Topic, error) {
+	return r.changeTransition(topicKey{cluster: cluster, name: name}, changeID, models.ChangeApproved, transition,
+		func(t *models.Topic) { t.Change = nil },
+	)
+}
+
+// changeTransition applies a status transition while the topic's change request
+// is changeID in status, returning nil without an error when it does not match
+func (r *MemoryTopicRepository) changeTransition(key topicKey, changeID string, status models.TopicChangeStatus, transition models.TopicTransition, apply func(*models.Topic)) (*models.Topic, error) {
+	return r.update(key,
+		func(t *models.Topic) bool {
+			return t.Status == transition.From && t.Change != nil && t.Change.ID == changeID && t.Change.Status == status
+		},
+		func(t *models.Topic) {
+			at := transition.At
+			t.Status = transition.To
+			t.UpdatedAt = &at
+			t.Transitions = append(t.Transitions, transition)
+			apply(t)
+		},
+	)
+}
+
+func (r *MemoryTopicRepository) AddClient(ctx context.Context, cluster, name string, client models.TopicClient) (*models.Topic, error) {
+	return r.update(topicKey{cluster: cluster, name: name},
+		func(t *models.Topic) bool { return clientIndex(t, client.Application, client.Role) < 0 },
+		func(t *models.Topic) {
+			at := client.RegisteredAt
+			t.Clients = append(t.Clients, client)
+			t.UpdatedAt = &at
+		},
+	)
+}
+
+func (r *MemoryTopicRepository) RemoveClient(ctx context.Context, cluster, name, application string, role models.ClientRole) (*models.Topic, error) {
+	return r.update(topicKey{cluster: cluster, name: name},
+		func(t *models.Topic) bool { return clientIndex(t, application, role) >= 0 },
+		func(t *models.Topic) {
+			now := time.Now()
+			i := clientIndex(t, application, role)
+			t.Clients = slices.Delete(t.Clients, i, i+1)
+			t.UpdatedAt = &now
+		},
+	)
+}
+
+func (r *MemoryTopicRepository) FindProducedBy(ctx context.Context, applications []string) ([]models.Topic, error) {
+	return r.list(func(t models.Topic) bool {
+		if t.Status == models.TopicDeleted {
+			return false
+		}
+		for _, c := range t.Clients {
+			if c.Role == models.ClientProducer && slices.Contains(applications, c.Application) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (r *MemoryTopicRepository) UpdateOwnership(ctx context.Context, cluster, name string, owners []string, onCall string, at time.Time) (*models.Topic, error) {
+	return r.update(topicKey{cluster: cluster, name: name},
+		func(t *models.Topic) bool { return true },
+		func(t *models.Topic) {
+			t.Owners = owners
+			t.OnCall = onCall
+			t.UpdatedAt = &at
+		},
+	)
+}
+
+func (r *MemoryTopicRepository) RequestTransfer(ctx context.Context, cluster, name string, transfer *models.OwnershipTransfer) (*models.Topic, error) {
+	return r.update(topicKey{cluster: cluster, name: name},
+		func(t *models.Topic) bool { return t.Transfer == nil && t.OwnerTeam == transfer.FromTeam },
+		func(t *models.Topic) {
+			at := transfer.RequestedAt
+			t.Transfer = transfer
+			t.UpdatedAt = &at
+		},
+	)
+}
+
+func (r *MemoryTopicRepository) AcceptTransfer(ctx context.Context, cluster, name, transferID, team string, owners []string, onCall string, at time.Time) (*models.Topic, error) {
+	return r.update(topicKey{cluster: cluster, name: name},
+		func(t *models.Topic) bool { return t.Transfer != nil && t.Transfer.ID == transferID },
+		func(t *models.Topic) {
+			t.OwnerTeam = team
+			t.Owners = owners
+			t.OnCall = onCall
+			t.Transfer = nil
+			t.UpdatedAt = &at
+		},
+	)
+}
+
+func (r *MemoryTopicRepository) CloseTransfer(ctx context.Context, cluster, name, transferID string) (*models.Topic, error) {
+	return r.update(topicKey{cluster: cluster, name: name},
+		func(t *models.Topic) bool { return t.Transfer != nil && t.Transfer.ID == transferID },
+		func(t *models.Topic) {
+			now := time.Now()
+			t.Transfer = nil
+			t.UpdatedAt = &now
+		},
+	)
+}
+
+// clientIndex returns the position of the application's registration in role, or -1
+func clientIndex(topic *models.Topic, application string, role models.ClientRole) int {
+	return slices.IndexFunc(topic.Clients, func(c models.TopicClient) bool {
+		return c.Application == application && c.Role == role
+	})
+}
+
+// updateChange applies update while the topic's change request is changeID in
+// status and accepted by match, which may be nil
+func (r *MemoryTopicRepository) updateChange(key topicKey, changeID string, status models.TopicChangeStatus, match func(*models.TopicChange) bool, update func(*models.Topic)) (*models.Topic, error) {
+	return r.update(key,
+		func(t *models.Topic) bool {
+			return t.Change != nil && t.Change.ID == changeID && t.Change.Status == status &&
+				(match == nil || match(t.Change))
+		},
+		update,
+	)
+}
+
+// MemoryPolicyRepository keeps policies in memory, keyed by id
+type MemoryPolicyRepository struct {
+	mu       sync.Mutex
+	policies map[string]models.Policy
+}
+
+func (r *MemoryPolicyRepository) Insert(ctx context.Context, policy *models.Policy) (*models.Policy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	policy.ID = uuid.New().String()
+	r.policies[policy.ID] = clone(*policy)
+	return policy, nil
+}
+
+func (r *MemoryPolicyRepository) List(ctx context.Context, filter models.PolicyFilter) ([]models.Policy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var policies []models.Policy
+	for _, p := range r.policies {
+		if (filter.Principal == "" || p.Principal == filter.Principal) &&
+			(filter.Action == "" || p.Action == filter.Action) &&
+			(filter.Resource == "" || p.Resource == filter.Resource) &&
+			(filter.Effect == "" || p.Effect == filter.Effect) {
+			policies = append(policies, clone(p))
+		}
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		if !policies[i].CreatedAt.Equal(policies[j].CreatedAt) {
+			return policies[i].CreatedAt.Before(policies[j].CreatedAt)
+		}
+		return policies[i].ID < policies[j].ID
+	})
+	return policies, nil
+}
+
+func (r *MemoryPolicyRepository) GetByID(ctx context.Context, id string) (*models.Policy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	policy, ok := r.policies[id]
+	if !ok {
+		return nil, utils.NewNotFoundError("policy not found")
+	}
+	policy = clone(policy)
+	return &policy, nil
+}
+
+func (r *MemoryPolicyRepository) Update(ctx context.Context, id string, policy *models.Policy) (*models.Policy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.policies[id]
+	if !ok {
+		return nil, utils.NewNotFoundError("policy not found")
+	}
+	now := time.Now()
+	stored.Principal = policy.Principal
+	stored.Action = policy.Action
+	stored.Resource = policy.Resource
+	stored.Effect = policy.Effect
+	stored.Conditions = policy.Conditions
+	stored.UpdatedAt = &now
+	r.policies[id] = clone(stored)
+
+	stored = clone(stored)
+	return &stored, nil
+}
+
+func (r *MemoryPolicyRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.policies[id]; !ok {
+		return utils.NewNotFoundError("policy not found")
+	}
+	delete(r.policies, id)
+	return nil
+}
+
+// MemoryClusterRepository keeps registered clusters in memory, keyed by name
+type MemoryClusterRepository struct {
+	mu       sync.Mutex
+	clusters map[string]models.Cluster
+}
+
+func (r *MemoryClusterRepository) Insert(ctx context.Context, cluster *models.Cluster) (*models.Cluster, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clusters[cluster.Name]; ok {
+		return nil, utils.NewAlreadyExistsError("cluster with same name already exists")
+	}
+	cluster.ID = uuid.New().String()
+	cluster.CreatedAt = time.Now()
+	r.clusters[cluster.Name] = clone(*cluster)
+	return cluster, nil
+}
+
+func (r *MemoryClusterRepository) List(ctx context.Context) ([]models.Cluster, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var clusters []models.Cluster
+	for _, c := range r.clusters {
+		clusters = append(clusters, clone(c))
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	return clusters, nil
+}
+
+func (r *MemoryClusterRepository) GetByName(ctx context.Context, name string) (*models.Cluster, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cluster, ok := r.clusters[name]
+	if !ok {
+		return nil, utils.NewNotFoundError("cluster not found")
+	}
+	cluster = clone(cluster)
+	return &cluster, nil
+}
+
+func (r *MemoryClusterRepository) Update(ctx context.Context, name string, cluster *models.Cluster) (*models.Cluster, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.clusters[name]
+	if !ok {
+		return nil, utils.NewNotFoundError("cluster not found")
+	}
+	now := time.Now()
+	stored.BootstrapServers = cluster.BootstrapServers
+	stored.Security = cluster.Security
+	stored.Environment = cluster.Environment
+	stored.BrokerCount = cluster.BrokerCount
+	stored.DefaultPartitions = cluster.DefaultPartitions
+	stored.MaxPartitions = cluster.MaxPartitions
+	stored.DefaultReplicas = cluster.DefaultReplicas
+	stored.MaxReplicas = cluster.MaxReplicas
+	stored.ConfigLimits = cluster.ConfigLimits
+	stored.UpdatedAt = &now
+	r.clusters[name] = clone(stored)
+
+	stored = clone(stored)
+	return &stored, nil
+}
+
+func (r *MemoryClusterRepository) Delete(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clusters[name]; !ok {
+		return utils.NewNotFoundError("cluster not found")
+	}
+	delete(r.clusters, name)
+	return nil
+}
+
+// MemoryDriftRepository keeps the latest drift report of each cluster in memory
+type MemoryDriftRepository struct {
+	mu      sync.Mutex
+	reports map[string]models.DriftReport
+}
+
+func (r *MemoryDriftRepository) Save(ctx context.Context, report *models.DriftReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reports[report.Cluster] = clone(*report)
+	return nil
+}
+
+func (r *MemoryDriftRepository) Get(ctx context.Context, cluster string) (*models.DriftReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report, ok := r.reports[cluster]
+	if !ok {
+		return nil, utils.NewNotFoundError("no drift report for cluster yet")
+	}
+	report = clone(report)
+	return &report, nil
+}
+
+// MemoryUserRepository keeps directory users in memory, keyed by username
+type MemoryUserRepository struct {
+	mu    sync.Mutex
+	users map[string]models.User
+}
+
+func (r *MemoryUserRepository) Insert(ctx context.Context, user *models.User) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.Username]; ok {
+		return nil, utils.NewAlreadyExistsError("user with same username already exists")
+	}
+	user.ID = uuid.New().String()
+	user.CreatedAt = time.Now()
+	r.users[user.Username] = clone(*user)
+	return user, nil
+}
+
+func (r *MemoryUserRepository) List(ctx context.Context, group string) ([]models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var users []models.User
+	for _, u := range r.users {
+		if group == "" || slices.Contains(u.Groups, group) {
+			users = append(users, clone(u))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users, nil
+}
+
+func (r *MemoryUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[username]
+	if !ok {
+		return nil, utils.NewNotFoundError("user not found")
+	}
+	user = clone(user)
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var users []models.User
+	for _, username := range usernames {
+		if u, ok := r.users[username]; ok {
+			users = append(users, clone(u))
+		}
+	}
+	return users, nil
+}
+
+func (r *MemoryUserRepository) Update(ctx context.Context, username string, user *models.User) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[username]
+	if !ok {
+		return nil, utils.NewNotFoundError("user not found")
+	}
+	stored.Email = user.Email
+	stored.DisplayName = user.DisplayName
+	stored.Groups = user.Groups
+	stored.Roles = user.Roles
+	stored.UpdatedAt = time.Now()
+	r.users[username] = clone(stored)
+
+	stored = clone(stored)
+	return &stored, nil
+}
+
+func (r *MemoryUserRepository) Delete(ctx context.Context, username string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[username]; !ok {
+		return utils.NewNotFoundError("user not found")
+	}
+	delete(r.users, username)
+	return nil
+}
+
+func (r *MemoryUserRepository) CountMembers(ctx context.Context, group string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for _, u := range r.users {
+		if slices.Contains(u.Groups, group) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// MemoryGroupRepository keeps directory groups in memory, keyed by name
+type MemoryGroupRepository struct {
+	mu     sync.Mutex
+	groups map[string]models.Group
+}
+
+func (r *MemoryGroupRepository) Insert(ctx context.Context, group *models.Group) (*models.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.groups[group.Name]; ok {
+		return nil, utils.NewAlreadyExistsError("group with same name already exists")
+	}
+	group.ID = uuid.New().String()
+	group.CreatedAt = time.Now()
+	r.groups[group.Name] = clone(*group)
+	return group, nil
+}
+
+func (r *MemoryGroupRepository) List(ctx context.Context) ([]models.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var groups []models.Group
+	for _, g := range r.groups {
+		groups = append(groups, clone(g))
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups, nil
+}
+
+func (r *MemoryGroupRepository) GetByName(ctx context.Context, name string) (*models.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, ok := r.groups[name]
+	if !ok {
+		return nil, utils.NewNotFoundError("group not found")
+	}
+	group = clone(group)
+	return &group, nil
+}
+
+func (r *MemoryGroupRepository) GetByNames(ctx context.Context, names []string) ([]models.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var groups []models.Group
+	for _, name := range names {
+		if g, ok := r.groups[name]; ok {
+			groups = append(groups, clone(g))
+		}
+	}
+	return groups, nil
+}
+
+func (r *MemoryGroupRepository) Update(ctx context.Context, name string, group *models.Group) (*models.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.groups[name]
+	if !ok {
+		return nil, utils.NewNotFoundError("group not found")
+	}
+	stored.Description = group.Description
+	stored.Parents = group.Parents
+	stored.Roles = group.Roles
+	stored.UpdatedAt = time.Now()
+	r.groups[name] = clone(stored)
+
+	stored = clone(stored)
+	return &stored, nil
+}
+
+func (r *MemoryGroupRepository) Delete(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.groups[name]; !ok {
+		return utils.NewNotFoundError("group not found")
+	}
+	delete(r.groups, name)
+	return nil
+}
+
+func (r *MemoryGroupRepository) CountChildren(ctx context.Context, name string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for _, g := range r.groups {
+		if slices.Contains(g.Parents, name) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// MemoryWorkflowRepository keeps approval workflows in memory, keyed by name
+type MemoryWorkflowRepository struct {
+	mu        sync.Mutex
+	workflows map[string]models.ApprovalWorkflow
+}
+
+func (r *MemoryWorkflowRepository) Insert(ctx context.Context, workflow *models.ApprovalWorkflow) (*models.ApprovalWorkflow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.workflows[workflow.Name]; ok {
+		return nil, utils.NewAlreadyExistsError("workflow with same name already exists")
+	}
+	workflow.ID = uuid.New().String()
+	workflow.Version = 1
+	workflow.CreatedAt = time.Now()
+	r.workflows[workflow.Name] = clone(*workflow)
+	return workflow, nil
+}
+
+func (r *MemoryWorkflowRepository) List(ctx context.Context) ([]models.ApprovalWorkflow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var workflows []models.ApprovalWorkflow
+	for _, w := range r.workflows {
+		workflows = append(workflows, clone(w))
+	}
+	sort.Slice(workflows, func(i, j int) bool { return workflows[i].Name < workflows[j].Name })
+	return workflows, nil
+}
+
+func (r *MemoryWorkflowRepository) GetByName(ctx context.Context, name string) (*models.ApprovalWorkflow, error) {
+	return r.find(func(w models.ApprovalWorkflow) bool { return w.Name == name })
+}
+
+func (r *MemoryWorkflowRepository) GetForCluster(ctx context.Context, cluster string) (*models.ApprovalWorkflow, error) {
+	return r.find(func(w models.ApprovalWorkflow) bool { return w.Cluster == cluster })
+}
+
+func (r *MemoryWorkflowRepository) GetForEnvironment(ctx context.Context, env models.Environment) (*models.ApprovalWorkflow, error) {
+	return r.find(func(w models.ApprovalWorkflow) bool { return w.Environment == env })
+}
+
+func (r *MemoryWorkflowRepository) find(match func(models.ApprovalWorkflow) bool) (*models.ApprovalWorkflow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, w := range r.workflows {
+		if match(w) {
+			w = clone(w)
+			return &w, nil
+		}
+	}
+	return nil, utils.NewNotFoundError("workflow not found")
+}
+
+func (r *MemoryWorkflowRepository) Update(ctx context.Context, name string, workflow *models.ApprovalWorkflow) (*models.ApprovalWorkflow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.workflows[name]
+	if !ok {
+		return nil, utils.NewNotFoundError("workflow not found")
+	}
+	stored.Cluster = workflow.Cluster
+	stored.Environment = workflow.Environment
+	stored.Stages = workflow.Stages
+	stored.Version++
+	stored.UpdatedAt = time.Now()
+	r.workflows[name] = clone(stored)
+
+	stored = clone(stored)
+	return &stored, nil
+}
+
+func (r *MemoryWorkflowRepository) Delete(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.workflows[name]; !ok {
+		return utils.NewNotFoundError("workflow not found")
+	}
+	delete(r.workflows, name)
+	return nil
+}
+
+// MemoryAuditRepository keeps the audit chain in memory in insertion order
+type MemoryAuditRepository struct {
+	mu          sync.Mutex
+	events      []models.AuditEvent
+	checkpoints map[int64]models.AuditCheckpoint
+}
+
+func (r *MemoryAuditRepository) Insert(ctx context.Context, event *models.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.events {
+		if e.ID == event.ID || (event.Seq > 0 && e.Seq == event.Seq) {
+			return ErrAuditSeqTaken
+		}
+	}
+	r.events = append(r.events, clone(*event))
+	return nil
+}
+
+func (r *MemoryAuditRepository) Last(ctx context.Context) (*models.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var last *models.AuditEvent
+	for i := range r.events {
+		if r.events[i].Seq > 0 && (last == nil || r.events[i].Seq > last.Seq) {
+			last = &r.events[i]
+		}
+	}
+	if last == nil {
+		return nil, nil
+	}
+	event := clone(*last)
+	return &event, nil
+}
+
+func (r *MemoryAuditRepository) Walk(ctx context.Context, fromSeq, toSeq int64, fn func(models.AuditEvent) bool) error {
+	// Copy the range first so fn can call back into the repository
+	r.mu.Lock()
+	var chain []models.AuditEvent
+	for _, e := range r.events {
+		if e.Seq >= max(fromSeq, 1) && (toSeq <= 0 || e.Seq <= toSeq) {
+			chain = append(chain, clone(e))
+		}
+	}
+	r.mu.Unlock()
+
+	sort.Slice(chain, func(i, j int) bool { return chain[i].Seq < chain[j].Seq })
+	for _, event := range chain {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !fn(event) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *MemoryAuditRepository) List(ctx context.Context, filter models.AuditFilter, afterAt *time.Time, afterID string, limit int) ([]models.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []models.AuditEvent
+	for _, e := range r.events {
+		if (filter.Actor != "" && e.Actor != filter.Actor) ||
+			(filter.Action != "" && e.Action != filter.Action) ||
+			(filter.ResourceType != "" && e.ResourceType != filter.ResourceType) ||
+			(filter.Resource != "" && e.Resource != filter.Resource) ||
+			(filter.RequestID != "" && e.RequestID != filter.RequestID) ||
+			(filter.Since != nil && e.At.Before(*filter.Since)) ||
+			(filter.Until != nil && !e.At.Before(*filter.Until)) {
+			continue
+		}
+		if afterAt != nil && !(e.At.Before(*afterAt) || (e.At.Equal(*afterAt) && e.ID < afterID)) {
+			continue
+		}
+		events = append(events, e)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].At.Equal(events[j].At) {
+			return events[i].At.After(events[j].At)
+		}
+		return events[i].ID > events[j].ID
+	})
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+	for i := range events {
+		events[i] = clone(events[i])
+	}
+	return events, nil
+}
+
+func (r *MemoryAuditRepository) InsertCheckpoint(ctx context.Context, checkpoint *models.AuditCheckpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.checkpoints[checkpoint.Seq]; ok {
+		return utils.NewAlreadyExistsError("audit checkpoint already exists")
+	}
+	r.checkpoints[checkpoint.Seq] = clone(*checkpoint)
+	return nil
+}
+
+func (r *MemoryAuditRepository) ListCheckpoints(ctx context.Context, fromSeq, toSeq int64) ([]models.AuditCheckpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var checkpoints []models.AuditCheckpoint
+	for seq, c := range r.checkpoints {
+		if seq >= fromSeq && (toSeq <= 0 || seq <= toSeq) {
+			checkpoints = append(checkpoints, clone(c))
+		}
+	}
+	sort.Slice(checkpoints, func(i, j int) bool { return checkpoints[i].Seq < checkpoints[j].Seq })
+	return checkpoints, nil
+}
+
+func (r *MemoryAuditRepository) LastCheckpoint(ctx context.Context) (*models.AuditCheckpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var last *models.AuditCheckpoint
+	for seq := range r.checkpoints {
+		if last == nil || seq > last.Seq {
+			c := r.checkpoints[seq]
+			last = &c
+		}
+	}
+	if last == nil {
+		return nil, nil
+	}
+	checkpoint := clone(*last)
+	return &checkpoint, nil
+}
+
+// MemoryRevisionRepository keeps topic revisions in memory, by topic id in revision order
+type MemoryRevisionRepository struct {
+	mu        sync.Mutex
+	revisions map[string][]models.TopicRevision
+}
+
+func (r *MemoryRevisionRepository) Insert(ctx context.Context, revision *models.TopicRevision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.revisions[revision.TopicID]
+	i, found := slices.BinarySearchFunc(stored, revision.Revision, func(rev models.TopicRevision, n int) int {
+		return rev.Revision - n
+	})
+	if found {
+		return utils.NewAlreadyExistsError("topic revision already exists")
+	}
+	r.revisions[revision.TopicID] = slices.Insert(stored, i, clone(*revision))
+	return nil
+}
+
+func (r *MemoryRevisionRepository) List(ctx context.Context, topicID string, before, limit int) ([]models.TopicRevision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.revisions[topicID]
+	var revisions []models.TopicRevision
+	for i := len(stored) - 1; i >= 0 && len(revisions) < limit; i-- {
+		if before > 0 && stored[i].Revision >= before {
+			continue
+		}
+		rev := clone(stored[i])
+		rev.Topic = nil
+		revisions = append(revisions, rev)
+	}
+	return revisions, nil
+}
+
+func (r *MemoryRevisionRepository) Get(ctx context.Context, topicID string, revision int) (*models.TopicRevision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rev := range r.revisions[topicID] {
+		if rev.Revision == revision {
+			rev = clone(rev)
+			return &rev, nil
+		}
+	}
+	return nil, utils.NewNotFoundError("topic revision not found")
+}
@@ -0,0 +1,508 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"kafka-governance/config"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migration is one versioned change to the indexes or validators of the database.
+// Migrations run in version order and each version is applied once.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database, names config.Collections) error
+}
+
+// migrations must only ever be appended to; applied versions are never re-run
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "unique topic, cluster, directory, workflow and audit seq indexes",
+		Up:          migrateUniqueIndexes,
+	},
+	{
+		Version:     2,
+		Description: "topic status and requester indexes",
+		Up:          migrateTopicQueryIndexes,
+	},
+	{
+		Version:     3,
+		Description: "JSON schema validators for topics, policies and clusters",
+		Up:          migrateValidators,
+	},
+	{
+		Version:     4,
+		Description: "scope topic name uniqueness to the cluster",
+		Up:          migrateTopicIdentity,
+	},
+	{
+		Version:     5,
+		Description: "topic listing sort, owner team and label indexes",
+		Up:          migrateTopicListIndexes,
+	},
+	{
+		Version:     6,
+		Description: "topic revisions and the approved change index",
+		Up:          migrateTopicChanges,
+	},
+	{
+		Version:     7,
+		Description: "topic client application index",
+		Up:          migrateTopicClients,
+	},
+	{
+		Version:     8,
+		Description: "topic revision index and a baseline revision of existing topics",
+		Up:          migrateTopicRevisions,
+	},
+	{
+		Version:     9,
+		Description: "allow the FAILED topic status in the topic validator",
+		Up:          migrateValidators,
+	},
+	{
+		Version:     10,
+		Description: "start existing approval workflows at version 1",
+		Up:          migrateWorkflowVersions,
+	},
+	{
+		Version:     11,
+		Description: "scope policies on a topic name to that name on every cluster",
+		Up:          migrateTopicPolicyScopes,
+	},
+}
+
+// indexNotFoundCode is the server error for dropping an index that does not exist
+const indexNotFoundCode = 27
+
+const (
+	migrationLockID   = "lock"
+	migrationLockTTL  = time.Minute
+	migrationLockWait = 2 * time.Second
+)
+
+// migrationLockRenew is how often the holder extends the lock; tests shorten it
+var migrationLockRenew = 20 * time.Second
+
+// errMigrationLockLost stops migrations when another instance took over the lock
+var errMigrationLockLost = errors.New("migration lock was taken over by another instance")
+
+// migrationRecord marks a migration version as applied
+type migrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+// Migrate applies the pending migrations. Instances starting at the same time
+// take turns through a lock document in the migrations collection, so every
+// migration runs exactly once. The holder renews the lock while migrations run,
+// however long they take; a lock left behind by a crashed instance expires after
+// migrationLockTTL.
+func Migrate(ctx context.Context, database *mongo.Database, names config.Collections) error {
+	logger := utils.GetLogger()
+	logger.Info("Checking database migrations")
+
+	coll := database.Collection(names.Migrations)
+	owner := lockOwner()
+	if err := acquireMigrationLock(ctx, coll, owner); err != nil {
+		logger.Error("Failed to acquire migration lock")
+		return err
+	}
+	defer releaseMigrationLock(coll, owner)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go renewMigrationLock(ctx, coll, owner, cancel)
+
+	applied, err := appliedMigrations(ctx, coll)
+	if err != nil {
+		logger.Error("Failed to read applied migrations")
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+		logger.Infof("Applying migration %d: %s", m.Version, m.Description)
+		if err := m.Up(ctx, database, names); err != nil {
+			if cause := context.Cause(ctx); errors.Is(cause, errMigrationLockLost) {
+				err = cause
+			}
+			logger.Errorf("Migration %d failed: %s", m.Version, err.Error())
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, err)
+		}
+		record := migrationRecord{Version: m.Version, Description: m.Description, AppliedAt: time.Now()}
+		if _, err := coll.InsertOne(ctx, record); err != nil {
+			logger.Error("Failed to record applied migration")
+			return err
+		}
+	}
+	logger.Info("Database migrations are up to date")
+	return nil
+}
+
+func lockOwner() string {
+	host, _ := os.Hostname()
+	return host + "/" + uuid.New().String()
+}
+
+// acquireMigrationLock waits until the lock document is free or expired. The
+// upsert inserts the lock when it is missing and fails with a duplicate key
+// while another instance holds it.
+func acquireMigrationLock(ctx context.Context, coll *mongo.Collection, owner string) error {
+	logger := utils.GetLogger()
+
+	for {
+		now := time.Now()
+		_, err := coll.UpdateOne(ctx,
+			bson.M{"_id": migrationLockID, "lockedUntil": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"owner": owner, "lockedUntil": now.Add(migrationLockTTL)}},
+			options.Update().SetUpsert(true),
+		)
+		if err == nil {
+			logger.Debug("Migration lock acquired")
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+
+		logger.Info("Waiting for another instance to finish migrations")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(migrationLockWait):
+		}
+	}
+}
+
+// renewMigrationLock extends the lock every migrationLockRenew until ctx is done.
+// Finding the lock held by another instance cancels ctx, so two instances never
+// keep migrating at the same time.
+func renewMigrationLock(ctx context.Context, coll *mongo.Collection, owner string, cancel context.CancelCauseFunc) {
+	logger := utils.GetLogger()
+
+	ticker := time.NewTicker(migrationLockRenew)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		result, err := coll.UpdateOne(ctx,
+			bson.M{"_id": migrationLockID, "owner": owner},
+			bson.M{"$set": bson.M{"lockedUntil": time.Now().Add(migrationLockTTL)}},
+		)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Warnf("Failed to renew migration lock: %s", err.Error())
+			}
+			continue
+		}
+		if result.MatchedCount == 0 {
+			logger.Error("Migration lock was taken over by another instance")
+			cancel(errMigrationLockLost)
+			return
+		}
+		logger.Debug("Migration lock renewed")
+	}
+}
+
+func releaseMigrationLock(coll *mongo.Collection, owner string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := coll.DeleteOne(ctx, bson.M{"_id": migrationLockID, "owner": owner}); err != nil {
+		utils.GetLogger().Errorf("Failed to release migration lock: %s", err.Error())
+	}
+}
+
+func appliedMigrations(ctx context.Context, coll *mongo.Collection) (map[int]bool, error) {
+	cursor, err := coll.Find(ctx, bson.M{"_id": bson.M{"$ne": migrationLockID}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []migrationRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	applied := map[int]bool{}
+	for _, r := range records {
+		applied[r.Version] = true
+	}
+	return applied, nil
+}
+
+func migrateUniqueIndexes(ctx context.Context, db *mongo.Database, names config.Collections) error {
+	unique := func(name string) *options.IndexOptions {
+		return options.Index().SetName(name).SetUnique(true)
+	}
+
+	indexes := map[string][]mongo.IndexModel{
+		names.Topics: {
+			// Topic names were global at first; migration 4 drops name_unique
+			{Keys: bson.D{{Key: "name", Value: 1}}, Options: unique("name_unique")},
+			{Keys: bson.D{{Key: "cluster", Value: 1}, {Key: "name", Value: 1}}, Options: unique("cluster_name_unique")},
+		},
+		names.Clusters:  {{Keys: bson.D{{Key: "name", Value: 1}}, Options: unique("name_unique")}},
+		names.Users:     {{Keys: bson.D{{Key: "username", Value: 1}}, Options: unique("username_unique")}},
+		names.Groups:    {{Keys: bson.D{{Key: "name", Value: 1}}, Options: unique("name_unique")}},
+		names.Workflows: {{Keys: bson.D{{Key: "name", Value: 1}}, Options: unique("name_unique")}},
+		// The unique seq keeps concurrent writers from forking the hash chain
+		names.Audit: {{
+			Keys: bson.D{{Key: "seq", Value: 1}},
+			Options: unique("seq_unique").
+				SetPartialFilterExpression(bson.M{"seq": bson.M{"$gt": 0}}),
+		}},
+	}
+	for coll, idx := range indexes {
+		if _, err := db.Collection(coll).Indexes().CreateMany(ctx, idx); err != nil {
+			return fmt.Errorf("%s indexes: %w", coll, err)
+		}
+	}
+	return nil
+}
+
+func migrateTopicQueryIndexes(ctx context.Context, db *mongo.Database, names config.Collections) error {
+	_, err := db.Collection(names.Topics).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}},
+			Options: options.Index().SetName("status"),
+		},
+		{
+			Keys:    bson.D{{Key: "requestedBy", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("requestedBy_createdAt"),
+		},
+	})
+	return err
+}
+
+func migrateValidators(ctx context.Context, db *mongo.Database, names config.Collections) error {
+	statuses := bson.A{}
+	for _, s := range models.TopicStatuses {
+		statuses = append(statuses, s)
+	}
+	count := bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 1}
+	name := bson.M{"bsonType": "string", "minLength": 1}
+
+	validators := map[string]bson.M{
+		names.Topics: {
+			"bsonType": "object",
+			"required": bson.A{"name", "cluster", "status", "partitions", "replicas", "createdAt"},
+			"properties": bson.M{
+				"name":       name,
+				"cluster":    name,
+				"status":     bson.M{"enum": statuses},
+				"partitions": count,
+				"replicas":   count,
+				"createdAt":  bson.M{"bsonType": "date"},
+			},
+		},
+		names.Policies: {
+			"bsonType": "object",
+			"required": bson.A{"principal", "action", "resource", "effect"},
+			"properties": bson.M{
+				"principal": name,
+				"action":    name,
+				"resource":  name,
+				"effect":    bson.M{"enum": bson.A{utils.EffectPermit, utils.EffectForbid}},
+			},
+		},
+		names.Clusters: {
+			"bsonType": "object",
+			"required": bson.A{"name", "bootstrapServers"},
+			"properties": bson.M{
+				"name":             name,
+				"bootstrapServers": bson.M{"bsonType": "array", "minItems": 1},
+			},
+		},
+	}
+	for coll, schema := range validators {
+		if err := setValidator(ctx, db, coll, schema); err != nil {
+			return fmt.Errorf("%s validator: %w", coll, err)
+		}
+	}
+	return nil
+}
+
+// setValidator attaches a $jsonSchema validator to a collection, creating the
+// collection first when needed. The moderate level leaves existing documents
+// that do not match alone until they are next updated.
+func setValidator(ctx context.Context, db *mongo.Database, coll string, schema bson.M) error {
+	validator := bson.M{"$jsonSchema": schema}
+
+	existing, err := db.ListCollectionNames(ctx, bson.M{"name": coll})
+	if err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		return db.CreateCollection(ctx, coll, options.CreateCollection().
+			SetValidator(validator).
+			SetValidationLevel("moderate"))
+	}
+	return db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: coll},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "moderate"},
+	}).Err()
+}
+
+// migrateTopicIdentity drops the global topic name index so the same name can
+// exist on several clusters; cluster_name_unique keeps (cluster, name) unique
+func migrateTopicIdentity(ctx context.Context, db *mongo.Database, names config.Collections) error {
+	_, err := db.Collection(names.Topics).Indexes().DropOne(ctx, "name_unique")
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == indexNotFoundCode {
+		return nil
+	}
+	return err
+}
+
+// migrateTopicListIndexes backs the sort orders and filters of the topic listing.
+// Sorting by cluster and status uses cluster_name_unique and status.
+func migrateTopicListIndexes(ctx context.Context, db *mongo.Database, names config.Collections) error {
+	_, err := db.Collection(names.Topics).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetName("name_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetName("createdAt_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "ownerTeam", Value: 1}, {Key: "name", Value: 1}},
+			Options: options.Index().SetName("ownerTeam_name"),
+		},
+		{
+			Keys:    bson.D{{Key: "labels.$**", Value: 1}},
+			Options: options.Index().SetName("labels_wildcard"),
+		},
+	})
+	return err
+}
+
+// migrateTopicChanges starts existing topics at revision 1 and indexes the change
+// requests the provisioner applies
+func migrateTopicChanges(ctx context.Context, db *mongo.Database, names config.Collections) error {
+	topics := db.Collection(names.Topics)
+	if _, err := topics.UpdateMany(ctx,
+		bson.M{"revision": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revision": 1}},
+	); err != nil {
+		return err
+	}
+	_, err := topics.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "change.status", Value: 1}},
+		Options: options.Index().SetName("change_status").
+			SetPartialFilterExpression(bson.M{"change": bson.M{"$exists": true}}),
+	})
+	return err
+}
+
+// migrateTopicClients indexes registered clients for the dependents of a
+// deletion's impact analysis
+func migrateTopicClients(ctx context.Context, db *mongo.Database, names config.Collections) error {
+	_, err := db.Collection(names.Topics).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "clients.application", Value: 1}, {Key: "clients.role", Value: 1}},
+		Options: options.Index().SetName("clients_application_role"),
+	})
+	return err
+}
+
+// migrateTopicRevisions makes revisions unique per topic and stores the current
+// version of every existing topic, so its history starts at the revision it has
+func migrateTopicRevisions(ctx context.Context, db *mongo.Database, names config.Collections) error {
+	revisions := db.Collection(names.Revisions)
+	if _, err := revisions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "topicId", Value: 1}, {Key: "revision", Value: 1}},
+		Options: options.Index().SetName("topicId_revision_unique").SetUnique(true),
+	}); err != nil {
+		return err
+	}
+
+	cursor, err := db.Collection(names.Topics).Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	for cursor.Next(ctx) {
+		var topic models.Topic
+		if err := cursor.Decode(&topic); err != nil {
+			return err
+		}
+		topic.Transitions = nil
+		_, err := revisions.InsertOne(ctx, models.TopicRevision{
+			TopicID:  topic.ID,
+			Revision: topic.Revision,
+			Action:   "topic.baseline",
+			Actor:    "system",
+			At:       now,
+			Topic:    &topic,
+		})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// migrateWorkflowVersions gives workflows created before they were versioned
+// their first version
+func migrateWorkflowVersions(ctx context.Context, db *mongo.Database, names config.Collections) error {
+	_, err := db.Collection(names.Workflows).UpdateMany(ctx,
+		bson.M{"version": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"version": 1}},
+	)
+	return err
+}
+
+// migrateTopicPolicyScopes rewrites policies on Topic::"name", which topics no
+// longer match now that their entity is Topic::"cluster/name", into policies on
+// any topic with that name, so they keep covering the same topics
+func migrateTopicPolicyScopes(ctx context.Context, db *mongo.Database, names config.Collections) error {
+	policies := db.Collection(names.Policies)
+	cursor, err := policies.Find(ctx, bson.M{"resource": bson.M{"$regex": `^Topic::`}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var policy models.Policy
+		if err := cursor.Decode(&policy); err != nil {
+			return err
+		}
+		_, name, err := utils.ParseEntityUID(policy.Resource)
+		if err != nil || name == "*" || strings.Contains(name, "/") {
+			continue
+		}
+		if _, err := policies.UpdateOne(ctx,
+			bson.M{"_id": policy.ID},
+			bson.M{"$set": bson.M{"resource": utils.EntityUID("Topic", "*"), "conditions.topic": name}},
+		); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
@@ -0,0 +1,276 @@
+//go:build mongo
+
+package db
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"kafka-governance/config"
+	"kafka-governance/models"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testDatabase connects to MONGO_TEST_URI and returns a fresh database that is
+// dropped when the test ends
+func testDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		uri = "mongodb://localhost:27017"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to %s: %v", uri, err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("pinging %s: %v", uri, err)
+	}
+
+	database := client.Database("kg_test_" + strings.ReplaceAll(uuid.New().String(), "-", "")[:12])
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		database.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	return database
+}
+
+type lockDocument struct {
+	Owner       string    `bson:"owner"`
+	LockedUntil time.Time `bson:"lockedUntil"`
+}
+
+func readLock(t *testing.T, coll *mongo.Collection) *lockDocument {
+	t.Helper()
+	var lock lockDocument
+	err := coll.FindOne(context.Background(), bson.M{"_id": migrationLockID}).Decode(&lock)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("reading lock: %v", err)
+	}
+	return &lock
+}
+
+func TestAcquireMigrationLock(t *testing.T) {
+	coll := testDatabase(t).Collection("migrations")
+	ctx := context.Background()
+
+	if err := acquireMigrationLock(ctx, coll, "a"); err != nil {
+		t.Fatalf("acquireMigrationLock: %v", err)
+	}
+	lock := readLock(t, coll)
+	if lock == nil || lock.Owner != "a" || time.Until(lock.LockedUntil) < migrationLockTTL-10*time.Second {
+		t.Fatalf("lock = %+v, want it held by a for the TTL", lock)
+	}
+
+	// A second instance waits while the lock is held
+	waitCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	if err := acquireMigrationLock(waitCtx, coll, "b"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquireMigrationLock while held = %v, want it to wait until the deadline", err)
+	}
+	if lock := readLock(t, coll); lock.Owner != "a" {
+		t.Errorf("lock owner = %s, want a", lock.Owner)
+	}
+
+	// and gets the lock once it is released
+	releaseMigrationLock(coll, "a")
+	if lock := readLock(t, coll); lock != nil {
+		t.Fatalf("lock = %+v after release, want none", lock)
+	}
+	if err := acquireMigrationLock(ctx, coll, "b"); err != nil {
+		t.Fatalf("acquireMigrationLock after release: %v", err)
+	}
+	if lock := readLock(t, coll); lock.Owner != "b" {
+		t.Errorf("lock owner = %s, want b", lock.Owner)
+	}
+}
+
+func TestAcquireMigrationLockTakesOverExpiredLock(t *testing.T) {
+	coll := testDatabase(t).Collection("migrations")
+	ctx := context.Background()
+
+	// Left behind by an instance that crashed while migrating
+	if _, err := coll.InsertOne(ctx, bson.M{"_id": migrationLockID, "owner": "crashed", "lockedUntil": time.Now().Add(-time.Second)}); err != nil {
+		t.Fatalf("inserting expired lock: %v", err)
+	}
+
+	acquireCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := acquireMigrationLock(acquireCtx, coll, "b"); err != nil {
+		t.Fatalf("acquireMigrationLock: %v", err)
+	}
+	if lock := readLock(t, coll); lock.Owner != "b" || !lock.LockedUntil.After(time.Now()) {
+		t.Errorf("lock = %+v, want it taken over by b", lock)
+	}
+
+	// The crashed instance no longer releases it
+	releaseMigrationLock(coll, "crashed")
+	if lock := readLock(t, coll); lock == nil || lock.Owner != "b" {
+		t.Errorf("lock = %+v, want it still held by b", lock)
+	}
+}
+
+func TestRenewMigrationLock(t *testing.T) {
+	coll := testDatabase(t).Collection("migrations")
+	ctx := context.Background()
+	previous := migrationLockRenew
+	migrationLockRenew = 50 * time.Millisecond
+	t.Cleanup(func() { migrationLockRenew = previous })
+
+	if err := acquireMigrationLock(ctx, coll, "a"); err != nil {
+		t.Fatalf("acquireMigrationLock: %v", err)
+	}
+	// Shorten the lock so a renewal is visible
+	if _, err := coll.UpdateOne(ctx, bson.M{"_id": migrationLockID}, bson.M{"$set": bson.M{"lockedUntil": time.Now().Add(time.Second)}}); err != nil {
+		t.Fatalf("shortening lock: %v", err)
+	}
+
+	renewCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	done := make(chan struct{})
+	go func() {
+		renewMigrationLock(renewCtx, coll, "a", cancel)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Until(readLock(t, coll).LockedUntil) < migrationLockTTL-10*time.Second {
+		if time.Now().After(deadline) {
+			t.Fatal("lock was not renewed")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// Another instance took the lock over, e.g. after a pause longer than the TTL
+	if _, err := coll.UpdateOne(ctx, bson.M{"_id": migrationLockID}, bson.M{"$set": bson.M{"owner": "b"}}); err != nil {
+		t.Fatalf("taking lock over: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("renewal did not stop after the lock was taken over")
+	}
+	if cause := context.Cause(renewCtx); !errors.Is(cause, errMigrationLockLost) {
+		t.Errorf("cause = %v, want errMigrationLockLost", cause)
+	}
+}
+
+func TestMigrateStopsWhenLockIsLost(t *testing.T) {
+	database := testDatabase(t)
+	names := config.Collections{Migrations: "migrations"}
+	coll := database.Collection(names.Migrations)
+
+	previousRenew, previousMigrations := migrationLockRenew, migrations
+	migrationLockRenew = 50 * time.Millisecond
+	t.Cleanup(func() { migrationLockRenew, migrations = previousRenew, previousMigrations })
+
+	// The migration hands the lock to another instance and runs until it is stopped
+	migrations = []Migration{{
+		Version:     1,
+		Description: "long running",
+		Up: func(ctx context.Context, db *mongo.Database, names config.Collections) error {
+			if _, err := coll.UpdateOne(ctx, bson.M{"_id": migrationLockID}, bson.M{"$set": bson.M{"owner": "b"}}); err != nil {
+				return err
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := Migrate(ctx, database, names)
+	if !errors.Is(err, errMigrationLockLost) {
+		t.Fatalf("Migrate = %v, want errMigrationLockLost", err)
+	}
+
+	applied, err := appliedMigrations(context.Background(), coll)
+	if err != nil {
+		t.Fatalf("appliedMigrations: %v", err)
+	}
+	if applied[1] {
+		t.Error("migration was recorded as applied")
+	}
+	if lock := readLock(t, coll); lock == nil || lock.Owner != "b" {
+		t.Errorf("lock = %+v, want it left to b", lock)
+	}
+}
+
+func TestMigrateRecordsAppliedMigrations(t *testing.T) {
+	database := testDatabase(t)
+	names := config.Collections{Migrations: "migrations"}
+
+	previous := migrations
+	t.Cleanup(func() { migrations = previous })
+	runs := 0
+	migrations = []Migration{{
+		Version:     1,
+		Description: "counted",
+		Up: func(ctx context.Context, db *mongo.Database, names config.Collections) error {
+			runs++
+			return nil
+		},
+	}}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := Migrate(ctx, database, names); err != nil {
+			t.Fatalf("Migrate: %v", err)
+		}
+	}
+	if runs != 1 {
+		t.Errorf("migration ran %d times, want once", runs)
+	}
+	if lock := readLock(t, database.Collection(names.Migrations)); lock != nil {
+		t.Errorf("lock = %+v after Migrate, want it released", lock)
+	}
+}
+
+func TestMigrateTopicPolicyScopes(t *testing.T) {
+	database := testDatabase(t)
+	names := config.Collections{Policies: "policies"}
+	ctx := context.Background()
+
+	policies := []interface{}{
+		bson.M{"_id": "name", "resource": `Topic::"orders"`, "conditions": bson.M{"environment": "prod"}},
+		bson.M{"_id": "scoped", "resource": `Topic::"dev/orders"`},
+		bson.M{"_id": "any", "resource": `Topic::"*"`},
+		bson.M{"_id": "cluster", "resource": `Cluster::"dev"`},
+	}
+	if _, err := database.Collection(names.Policies).InsertMany(ctx, policies); err != nil {
+		t.Fatalf("inserting policies: %v", err)
+	}
+	if err := migrateTopicPolicyScopes(ctx, database, names); err != nil {
+		t.Fatalf("migrateTopicPolicyScopes: %v", err)
+	}
+
+	want := map[string]string{"name": `Topic::"*"`, "scoped": `Topic::"dev/orders"`, "any": `Topic::"*"`, "cluster": `Cluster::"dev"`}
+	for id, resource := range want {
+		var policy models.Policy
+		if err := database.Collection(names.Policies).FindOne(ctx, bson.M{"_id": id}).Decode(&policy); err != nil {
+			t.Fatalf("reading policy %s: %v", id, err)
+		}
+		if policy.Resource != resource {
+			t.Errorf("policy %s resource = %s, want %s", id, policy.Resource, resource)
+		}
+		if id == "name" && (policy.Conditions["topic"] != "orders" || policy.Conditions["environment"] != "prod") {
+			t.Errorf("policy %s conditions = %v, want the topic name added", id, policy.Conditions)
+		}
+	}
+}
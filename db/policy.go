@@ -0,0 +1,149 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"kafka-governance/config"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoPolicyRepository stores policies in MongoDB
+type MongoPolicyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoPolicyRepository(db *mongo.Database, names config.Collections) *MongoPolicyRepository {
+	return &MongoPolicyRepository{collection: db.Collection(names.Policies)}
+}
+
+func (r *MongoPolicyRepository) Insert(
+	ctx context.Context,
+	policy *models.Policy,
+) (*models.Policy, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Inserting policy into database")
+
+	policy.ID = uuid.New().String()
+
+	_, err := r.collection.InsertOne(ctx, policy)
+	if err != nil {
+		logger.Error("Failed to insert policy into database")
+		return nil, err
+	}
+	logger.Info("Policy inserted successfully")
+	return policy, nil
+}
+
+// List returns the policies matching every non-empty field of the filter
+func (r *MongoPolicyRepository) List(ctx context.Context, filter models.PolicyFilter) ([]models.Policy, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching policies from database")
+
+	query := bson.M{}
+	if filter.Principal != "" {
+		query["principal"] = filter.Principal
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.Resource != "" {
+		query["resource"] = filter.Resource
+	}
+	if filter.Effect != "" {
+		query["effect"] = filter.Effect
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		logger.Error("Failed to query policies from database")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var policies []models.Policy
+	err = cursor.All(ctx, &policies)
+	if err != nil {
+		logger.Error("Failed to decode policies from cursor")
+		return nil, err
+	}
+	logger.Debugf("Fetched policies from database, count: %d", len(policies))
+	return policies, nil
+}
+
+func (r *MongoPolicyRepository) GetByID(ctx context.Context, id string) (*models.Policy, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching policy by id from database")
+
+	var policy models.Policy
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&policy)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Error("Policy not found in database")
+		return nil, utils.NewNotFoundError("policy not found")
+	}
+	if err != nil {
+		logger.Error("Failed to fetch policy from database")
+		return nil, err
+	}
+	logger.Info("Policy found in database")
+	return &policy, nil
+}
+
+// Update replaces the scope, effect and conditions of a policy and returns the stored document
+func (r *MongoPolicyRepository) Update(ctx context.Context, id string, policy *models.Policy) (*models.Policy, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Updating policy in database")
+
+	now := time.Now()
+	var updated models.Policy
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set": bson.M{
+				"principal":  policy.Principal,
+				"action":     policy.Action,
+				"resource":   policy.Resource,
+				"effect":     policy.Effect,
+				"conditions": policy.Conditions,
+				"updatedAt":  now,
+			},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Error("Policy not found in database")
+		return nil, utils.NewNotFoundError("policy not found")
+	}
+	if err != nil {
+		logger.Error("Failed to update policy in database")
+		return nil, err
+	}
+	logger.Info("Policy updated in database successfully")
+	return &updated, nil
+}
+
+func (r *MongoPolicyRepository) Delete(ctx context.Context, id string) error {
+	logger := utils.GetLogger()
+	logger.Debug("Deleting policy from database")
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		logger.Error("Failed to delete policy from database")
+		return err
+	}
+	if result.DeletedCount == 0 {
+		logger.Error("Policy not found in database")
+		return utils.NewNotFoundError("policy not found")
+	}
+	logger.Info("Policy deleted from database successfully")
+	return nil
+}
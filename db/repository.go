@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"kafka-governance/config"
+	"kafka-governance/models"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TopicRepository stores governed topics. A topic is identified by its cluster and
+// name; the same name may exist on several clusters. Status changes only succeed
+// while the topic is still in the transition's From status and return a conflict
+// otherwise. Every update except the provisioning and apply failure records
+// increments the topic's Revision.
+type TopicRepository interface {
+	// Create inserts a new topic, replacing a DELETED topic of the same name on the cluster
+	Create(ctx context.Context, topic *models.Topic) (*models.Topic, error)
+	// Import inserts the topic unless its cluster has one with the same name, which it returns
+	Import(ctx context.Context, topic *models.Topic) (*models.Topic, error)
+	List(ctx context.Context) ([]models.Topic, error)
+	// Page returns up to limit topics matching filter in sort order, without their
+	// transitions. When after is set only topics past its position are returned.
+	Page(ctx context.Context, filter models.TopicFilter, sort models.TopicSort, after *models.Topic, limit int) ([]models.Topic, error)
+	// ListMatching returns every topic matching filter, without transitions
+	ListMatching(ctx context.Context, filter models.TopicFilter) ([]models.Topic, error)
+	// Count counts the topics matching filter, stopping at limit when it is positive
+	Count(ctx context.Context, filter models.TopicFilter, limit int64) (int64, error)
+	Get(ctx context.Context, cluster, name string) (*models.Topic, error)
+	FindByName(ctx context.Context, name string) ([]models.Topic, error)
+	CountOnCluster(ctx context.Context, cluster string) (int64, error)
+	ListByStatus(ctx context.Context, statuses ...models.TopicStatus) ([]models.Topic, error)
+
+	Approve(ctx context.Context, cluster, name string, transition models.TopicTransition) (*models.Topic, error)
+	Activate(ctx context.Context, cluster, name string, transition models.TopicTransition) (*models.Topic, error)
+	Transition(ctx context.Context, cluster, name string, transition models.TopicTransition) (*models.Topic, error)
+	RecordProvisionFailure(ctx context.Context, cluster, name, brokerErr string, at time.Time) error
+	// ResetProvisioning applies the transition and clears the provisioning attempts
+	// so the provisioner tries the topic again without a backoff
+	ResetProvisioning(ctx context.Context, cluster, name string, transition models.TopicTransition) (*models.Topic, error)
+
+	// The approval updates return nil without an error when the topic no longer matches
+	StartApproval(ctx context.Context, cluster, name string, approval *models.TopicApproval) (*models.Topic, error)
+	RecordApprovalVote(ctx context.Context, cluster, name string, vote models.ApprovalVote) (*models.Topic, error)
+	AdvanceApprovalStage(ctx context.Context, cluster, name string, stage int) (*models.Topic, error)
+
+	// The change request updates match the change by id and status and return nil
+	// without an error when the topic no longer matches. RequestChange only opens a
+	// change on an ACTIVE topic without one.
+	RequestChange(ctx context.Context, cluster, name string, change *models.TopicChange) (*models.Topic, error)
+	RecordChangeVote(ctx context.Context, cluster, name, changeID string, vote models.ApprovalVote) (*models.Topic, error)
+	AdvanceChangeStage(ctx context.Context, cluster, name, changeID string, stage int) (*models.Topic, error)
+	ApproveChange(ctx context.Context, cluster, name, changeID, approver string, at time.Time) (*models.Topic, error)
+	RecordChangeFailure(ctx context.Context, cluster, name, changeID, brokerErr string, at time.Time) error
+	// FailChange moves an approved change to FAILED and RetryChange moves it back
+	// with its attempts cleared
+	FailChange(ctx context.Context, cluster, name, changeID string, at time.Time) (*models.Topic, error)
+	RetryChange(ctx context.Context, cluster, name, changeID string, at time.Time) (*models.Topic, error)
+	// ApplyChange stores the applied partitions and configs and closes the change
+	ApplyChange(ctx context.Context, cluster, name, changeID string, partitions int, configs models.TopicConfigs, at time.Time) (*models.Topic, error)
+	// CloseChange removes a pending or failed change without applying it
+	CloseChange(ctx context.Context, cluster, name, changeID string, status models.TopicChangeStatus) (*models.Topic, error)
+	ListApprovedChanges(ctx context.Context) ([]models.Topic, error)
+
+	// Deletion requests are DELETE changes. ScheduleDeletion approves a pending
+	// deletion and moves the topic to DEPRECATED until removeAfter; CancelDeletion
+	// closes an approved or failed deletion, moving the topic back to ACTIVE, and
+	// MarkDeleted closes an approved one, moving the topic on to DELETED. They return nil without an error when the topic no longer matches.
+	ScheduleDeletion(ctx context.Context, cluster, name, changeID string, transition models.TopicTransition, removeAfter time.Time) (*models.Topic, error)
+	CancelDeletion(ctx context.Context, cluster, name, changeID string, status models.TopicChangeStatus, transition models.TopicTransition) (*models.Topic, error)
+	MarkDeleted(ctx context.Context, cluster, name, changeID string, transition models.TopicTransition) (*models.Topic, error)
+
+	// AddClient registers an application as a producer or consumer of the topic and
+	// RemoveClient unregisters it. Both return nil without an error when the topic
+	// is missing or the registration already is as requested.
+	AddClient(ctx context.Context, cluster, name string, client models.TopicClient) (*models.Topic, error)
+	RemoveClient(ctx context.Context, cluster, name, application string, role models.ClientRole) (*models.Topic, error)
+	// FindProducedBy returns the topics not yet deleted that any of the applications produces to
+	FindProducedBy(ctx context.Context, applications []string) ([]models.Topic, error)
+
+	// UpdateOwnership replaces the owners and on-call contact of the topic.
+	// RequestTransfer opens a transfer on a topic still owned by transfer.FromTeam
+	// that has none; AcceptTransfer hands the topic to the transfer's team and
+	// CloseTransfer drops the transfer, both matching it by id. They return nil
+	// without an error when the topic no longer matches.
+	UpdateOwnership(ctx context.Context, cluster, name string, owners []string, onCall string, at time.Time) (*models.Topic, error)
+	RequestTransfer(ctx context.Context, cluster, name string, transfer *models.OwnershipTransfer) (*models.Topic, error)
+	AcceptTransfer(ctx context.Context, cluster, name, transferID, team string, owners []string, onCall string, at time.Time) (*models.Topic, error)
+	CloseTransfer(ctx context.Context, cluster, name, transferID string) (*models.Topic, error)
+}
+
+// PolicyRepository stores authorization policies
+type PolicyRepository interface {
+	Insert(ctx context.Context, policy *models.Policy) (*models.Policy, error)
+	List(ctx context.Context, filter models.PolicyFilter) ([]models.Policy, error)
+	GetByID(ctx context.Context, id string) (*models.Policy, error)
+	Update(ctx context.Context, id string, policy *models.Policy) (*models.Policy, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// ClusterRepository stores registered Kafka clusters
+type ClusterRepository interface {
+	Insert(ctx context.Context, cluster *models.Cluster) (*models.Cluster, error)
+	List(ctx context.Context) ([]models.Cluster, error)
+	GetByName(ctx context.Context, name string) (*models.Cluster, error)
+	Update(ctx context.Context, name string, cluster *models.Cluster) (*models.Cluster, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// DriftRepository keeps the latest drift report of each cluster
+type DriftRepository interface {
+	Save(ctx context.Context, report *models.DriftReport) error
+	Get(ctx context.Context, cluster string) (*models.DriftReport, error)
+}
+
+// UserRepository stores directory users
+type UserRepository interface {
+	Insert(ctx context.Context, user *models.User) (*models.User, error)
+	List(ctx context.Context, group string) ([]models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	GetByUsernames(ctx context.Context, usernames []string) ([]models.User, error)
+	Update(ctx context.Context, username string, user *models.User) (*models.User, error)
+	Delete(ctx context.Context, username string) error
+	CountMembers(ctx context.Context, group string) (int64, error)
+}
+
+// GroupRepository stores directory groups
+type GroupRepository interface {
+	Insert(ctx context.Context, group *models.Group) (*models.Group, error)
+	List(ctx context.Context) ([]models.Group, error)
+	GetByName(ctx context.Context, name string) (*models.Group, error)
+	GetByNames(ctx context.Context, names []string) ([]models.Group, error)
+	Update(ctx context.Context, name string, group *models.Group) (*models.Group, error)
+	Delete(ctx context.Context, name string) error
+	CountChildren(ctx context.Context, name string) (int64, error)
+}
+
+// WorkflowRepository stores approval workflows
+type WorkflowRepository interface {
+	Insert(ctx context.Context, workflow *models.ApprovalWorkflow) (*models.ApprovalWorkflow, error)
+	List(ctx context.Context) ([]models.ApprovalWorkflow, error)
+	GetByName(ctx context.Context, name string) (*models.ApprovalWorkflow, error)
+	GetForCluster(ctx context.Context, cluster string) (*models.ApprovalWorkflow, error)
+	GetForEnvironment(ctx context.Context, env models.Environment) (*models.ApprovalWorkflow, error)
+	Update(ctx context.Context, name string, workflow *models.ApprovalWorkflow) (*models.ApprovalWorkflow, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// RevisionRepository keeps every stored version of each topic
+type RevisionRepository interface {
+	// Insert returns an already-exists error when the topic has the revision
+	Insert(ctx context.Context, revision *models.TopicRevision) error
+	// List returns up to limit revisions of the topic below before, newest first
+	// and without their snapshots. before <= 0 starts at the latest revision.
+	List(ctx context.Context, topicID string, before, limit int) ([]models.TopicRevision, error)
+	Get(ctx context.Context, topicID string, revision int) (*models.TopicRevision, error)
+}
+
+// AuditRepository is the append-only audit chain and its signed checkpoints
+type AuditRepository interface {
+	// Insert returns ErrAuditSeqTaken when the event's seq is already in use
+	Insert(ctx context.Context, event *models.AuditEvent) error
+	Last(ctx context.Context) (*models.AuditEvent, error)
+	Walk(ctx context.Context, fromSeq, toSeq int64, fn func(models.AuditEvent) bool) error
+	List(ctx context.Context, filter models.AuditFilter, afterAt *time.Time, afterID string, limit int) ([]models.AuditEvent, error)
+	InsertCheckpoint(ctx context.Context, checkpoint *models.AuditCheckpoint) error
+	ListCheckpoints(ctx context.Context, fromSeq, toSeq int64) ([]models.AuditCheckpoint, error)
+	LastCheckpoint(ctx context.Context) (*models.AuditCheckpoint, error)
+}
+
+// Store groups the repositories the service layer works against
+type Store struct {
+	Topics    TopicRepository
+	Policies  PolicyRepository
+	Clusters  ClusterRepository
+	Drift     DriftRepository
+	Users     UserRepository
+	Groups    GroupRepository
+	Workflows WorkflowRepository
+	Audit     AuditRepository
+	Revisions RevisionRepository
+
+	transact func(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// NewMongoStore backs every repository with its configured collection of database
+func NewMongoStore(database *mongo.Database, names config.Collections) *Store {
+	store := &Store{
+		Topics:    NewMongoTopicRepository(database, names),
+		Policies:  NewMongoPolicyRepository(database, names),
+		Clusters:  NewMongoClusterRepository(database, names),
+		Drift:     NewMongoDriftRepository(database, names),
+		Users:     NewMongoUserRepository(database, names),
+		Groups:    NewMongoGroupRepository(database, names),
+		Workflows: NewMongoWorkflowRepository(database, names),
+		Audit:     NewMongoAuditRepository(database, names),
+		Revisions: NewMongoRevisionRepository(database, names),
+	}
+	store.transact = mongoTransaction(database.Client())
+	return store
+}
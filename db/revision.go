@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"kafka-governance/config"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoRevisionRepository keeps topic revisions in MongoDB, unique by topic id and revision
+type MongoRevisionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoRevisionRepository(db *mongo.Database, names config.Collections) *MongoRevisionRepository {
+	return &MongoRevisionRepository{collection: db.Collection(names.Revisions)}
+}
+
+func (r *MongoRevisionRepository) Insert(ctx context.Context, revision *models.TopicRevision) error {
+	logger := utils.GetLogger()
+	logger.Debug("Storing topic revision in database")
+
+	_, err := r.collection.InsertOne(ctx, revision)
+	if mongo.IsDuplicateKeyError(err) {
+		logger.Error("Topic revision already stored")
+		return utils.NewAlreadyExistsError("topic revision already exists")
+	}
+	if err != nil {
+		logger.Error("Failed to store topic revision")
+		return err
+	}
+	return nil
+}
+
+func (r *MongoRevisionRepository) List(ctx context.Context, topicID string, before, limit int) ([]models.TopicRevision, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching topic revisions from database")
+
+	filter := bson.M{"topicId": topicID}
+	if before > 0 {
+		filter["revision"] = bson.M{"$lt": before}
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "revision", Value: -1}}).
+		SetProjection(bson.M{"topic": 0}).
+		SetLimit(int64(limit)),
+	)
+	if err != nil {
+		logger.Error("Failed to query topic revisions from database")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var revisions []models.TopicRevision
+	if err := cursor.All(ctx, &revisions); err != nil {
+		logger.Error("Failed to decode topic revisions from cursor")
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *MongoRevisionRepository) Get(ctx context.Context, topicID string, revision int) (*models.TopicRevision, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching topic revision from database")
+
+	var found models.TopicRevision
+	err := r.collection.FindOne(ctx, bson.M{"topicId": topicID, "revision": revision}).Decode(&found)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Error("Topic revision not found in database")
+		return nil, utils.NewNotFoundError("topic revision not found")
+	}
+	if err != nil {
+		logger.Error("Failed to fetch topic revision from database")
+		return nil, err
+	}
+	return &found, nil
+}
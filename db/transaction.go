@@ -0,0 +1,198 @@
+package db
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"kafka-governance/models"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WithTransaction runs fn so that every write it makes through the store with
+// the context it is given is kept together or not at all: when fn returns an
+// error nothing it wrote remains. fn may run more than once when the transaction
+// conflicts with another writer, so it should only write through the store.
+// Calls made while a transaction is already open on ctx join it.
+func (s *Store) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.transact == nil {
+		return fn(ctx)
+	}
+	return s.transact(ctx, fn)
+}
+
+// mongoTransaction runs fn in a MongoDB session transaction. Transactions need
+// a replica set; a single server can run as a one-member set.
+func mongoTransaction(client *mongo.Client) func(context.Context, func(context.Context) error) error {
+	return func(ctx context.Context, fn func(context.Context) error) error {
+		if mongo.SessionFromContext(ctx) != nil {
+			return fn(ctx)
+		}
+
+		session, err := client.StartSession()
+		if err != nil {
+			return err
+		}
+		defer session.EndSession(context.WithoutCancel(ctx))
+
+		_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+			return nil, fn(sc)
+		})
+		return err
+	}
+}
+
+type memoryTxKey struct{}
+
+// snapshotter is implemented by the memory repositories: snapshot copies their
+// contents and returns a function that puts the copy back
+type snapshotter interface {
+	snapshot() (restore func())
+}
+
+// memoryTransaction runs one transaction at a time over the memory repositories
+// and restores them when fn fails. Reads outside a transaction may see its
+// writes before it ends.
+func memoryTransaction(store *Store) func(context.Context, func(context.Context) error) error {
+	var mu sync.Mutex
+	return func(ctx context.Context, fn func(context.Context) error) error {
+		if ctx.Value(memoryTxKey{}) != nil {
+			return fn(ctx)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		var restores []func()
+		for _, repo := range []interface{}{store.Topics, store.Policies, store.Clusters, store.Drift,
+			store.Users, store.Groups, store.Workflows, store.Audit, store.Revisions} {
+			if s, ok := repo.(snapshotter); ok {
+				restores = append(restores, s.snapshot())
+			}
+		}
+
+		if err := fn(context.WithValue(ctx, memoryTxKey{}, true)); err != nil {
+			for _, restore := range restores {
+				restore()
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// cloneMap deep copies the documents of a memory repository, since updates
+// change nested fields of the stored documents in place
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	copied := make(map[K]V, len(m))
+	for k, v := range m {
+		copied[k] = clone(v)
+	}
+	return copied
+}
+
+func (r *MemoryTopicRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved := cloneMap(r.topics)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.topics = saved
+	}
+}
+
+func (r *MemoryPolicyRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved := cloneMap(r.policies)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.policies = saved
+	}
+}
+
+func (r *MemoryClusterRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved := cloneMap(r.clusters)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.clusters = saved
+	}
+}
+
+func (r *MemoryDriftRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved := cloneMap(r.reports)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.reports = saved
+	}
+}
+
+func (r *MemoryUserRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved := cloneMap(r.users)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.users = saved
+	}
+}
+
+func (r *MemoryGroupRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved := cloneMap(r.groups)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.groups = saved
+	}
+}
+
+func (r *MemoryWorkflowRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved := cloneMap(r.workflows)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.workflows = saved
+	}
+}
+
+// Audit events, checkpoints and revisions are never changed once written, so
+// their snapshots only copy the containers
+func (r *MemoryAuditRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events, checkpoints := slices.Clip(r.events), maps.Clone(r.checkpoints)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.events, r.checkpoints = events, checkpoints
+	}
+}
+
+func (r *MemoryRevisionRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved := make(map[string][]models.TopicRevision, len(r.revisions))
+	for topicID, revisions := range r.revisions {
+		saved[topicID] = slices.Clone(revisions)
+	}
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.revisions = saved
+	}
+}
@@ -0,0 +1,303 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"kafka-governance/config"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoUserRepository stores directory users in MongoDB
+type MongoUserRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoUserRepository(db *mongo.Database, names config.Collections) *MongoUserRepository {
+	return &MongoUserRepository{collection: db.Collection(names.Users)}
+}
+
+// MongoGroupRepository stores directory groups in MongoDB
+type MongoGroupRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoGroupRepository(db *mongo.Database, names config.Collections) *MongoGroupRepository {
+	return &MongoGroupRepository{collection: db.Collection(names.Groups)}
+}
+
+func (r *MongoUserRepository) Insert(ctx context.Context, user *models.User) (*models.User, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Inserting user into database")
+
+	user.ID = uuid.New().String()
+	user.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		logger.Error("User with same username already exists")
+		return nil, utils.NewAlreadyExistsError("user with same username already exists")
+	}
+	if err != nil {
+		logger.Error("Failed to insert user into database")
+		return nil, err
+	}
+	logger.Info("User inserted successfully")
+	return user, nil
+}
+
+// List returns all users, or only the direct members of group when it is set
+func (r *MongoUserRepository) List(ctx context.Context, group string) ([]models.User, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching users from database")
+
+	filter := bson.M{}
+	if group != "" {
+		filter["groups"] = group
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "username", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error("Failed to query users from database")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		logger.Error("Failed to decode users from cursor")
+		return nil, err
+	}
+	logger.Infof("Successfully fetched users from database, count: %d", len(users))
+	return users, nil
+}
+
+func (r *MongoUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching user by username from database")
+
+	var user models.User
+	err := r.collection.FindOne(ctx, bson.M{"username": username}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Debug("User not found in database")
+		return nil, utils.NewNotFoundError("user not found")
+	}
+	if err != nil {
+		logger.Error("Failed to fetch user from database")
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Update replaces the profile and memberships of a user, keeping its username,
+// id and creation time
+func (r *MongoUserRepository) Update(ctx context.Context, username string, user *models.User) (*models.User, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Updating user in database")
+
+	var updated models.User
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"username": username},
+		bson.M{
+			"$set": bson.M{
+				"email":       user.Email,
+				"displayName": user.DisplayName,
+				"groups":      user.Groups,
+				"roles":       user.Roles,
+				"updatedAt":   time.Now(),
+			},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Error("User not found in database")
+		return nil, utils.NewNotFoundError("user not found")
+	}
+	if err != nil {
+		logger.Error("Failed to update user in database")
+		return nil, err
+	}
+	logger.Info("User updated in database successfully")
+	return &updated, nil
+}
+
+func (r *MongoUserRepository) Delete(ctx context.Context, username string) error {
+	logger := utils.GetLogger()
+	logger.Debug("Deleting user from database")
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"username": username})
+	if err != nil {
+		logger.Error("Failed to delete user from database")
+		return err
+	}
+	if result.DeletedCount == 0 {
+		logger.Error("User not found in database")
+		return utils.NewNotFoundError("user not found")
+	}
+	logger.Info("User deleted from database successfully")
+	return nil
+}
+
+// CountMembers counts the users that are direct members of a group
+func (r *MongoUserRepository) CountMembers(ctx context.Context, group string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"groups": group})
+}
+
+func (r *MongoGroupRepository) Insert(ctx context.Context, group *models.Group) (*models.Group, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Inserting group into database")
+
+	group.ID = uuid.New().String()
+	group.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, group)
+	if mongo.IsDuplicateKeyError(err) {
+		logger.Error("Group with same name already exists")
+		return nil, utils.NewAlreadyExistsError("group with same name already exists")
+	}
+	if err != nil {
+		logger.Error("Failed to insert group into database")
+		return nil, err
+	}
+	logger.Info("Group inserted successfully")
+	return group, nil
+}
+
+func (r *MongoGroupRepository) List(ctx context.Context) ([]models.Group, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching groups from database")
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		logger.Error("Failed to query groups from database")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []models.Group
+	if err := cursor.All(ctx, &groups); err != nil {
+		logger.Error("Failed to decode groups from cursor")
+		return nil, err
+	}
+	logger.Infof("Successfully fetched groups from database, count: %d", len(groups))
+	return groups, nil
+}
+
+// GetByUsernames returns the users with the given usernames; unknown ones are left out
+func (r *MongoUserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]models.User, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching users by username from database")
+
+	cursor, err := r.collection.Find(ctx, bson.M{"username": bson.M{"$in": usernames}})
+	if err != nil {
+		logger.Error("Failed to query users from database")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		logger.Error("Failed to decode users from cursor")
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetByNames returns the groups with the given names; unknown names are left out
+func (r *MongoGroupRepository) GetByNames(ctx context.Context, names []string) ([]models.Group, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching groups by name from database")
+
+	cursor, err := r.collection.Find(ctx, bson.M{"name": bson.M{"$in": names}})
+	if err != nil {
+		logger.Error("Failed to query groups from database")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []models.Group
+	if err := cursor.All(ctx, &groups); err != nil {
+		logger.Error("Failed to decode groups from cursor")
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *MongoGroupRepository) GetByName(ctx context.Context, name string) (*models.Group, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching group by name from database")
+
+	var group models.Group
+	err := r.collection.FindOne(ctx, bson.M{"name": name}).Decode(&group)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Error("Group not found in database")
+		return nil, utils.NewNotFoundError("group not found")
+	}
+	if err != nil {
+		logger.Error("Failed to fetch group from database")
+		return nil, err
+	}
+	logger.Info("Group found in database")
+	return &group, nil
+}
+
+// Update replaces the description, parents and roles of a group
+func (r *MongoGroupRepository) Update(ctx context.Context, name string, group *models.Group) (*models.Group, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Updating group in database")
+
+	var updated models.Group
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"name": name},
+		bson.M{
+			"$set": bson.M{
+				"description": group.Description,
+				"parents":     group.Parents,
+				"roles":       group.Roles,
+				"updatedAt":   time.Now(),
+			},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Error("Group not found in database")
+		return nil, utils.NewNotFoundError("group not found")
+	}
+	if err != nil {
+		logger.Error("Failed to update group in database")
+		return nil, err
+	}
+	logger.Info("Group updated in database successfully")
+	return &updated, nil
+}
+
+func (r *MongoGroupRepository) Delete(ctx context.Context, name string) error {
+	logger := utils.GetLogger()
+	logger.Debug("Deleting group from database")
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		logger.Error("Failed to delete group from database")
+		return err
+	}
+	if result.DeletedCount == 0 {
+		logger.Error("Group not found in database")
+		return utils.NewNotFoundError("group not found")
+	}
+	logger.Info("Group deleted from database successfully")
+	return nil
+}
+
+// CountChildren counts the groups that have name as a direct parent
+func (r *MongoGroupRepository) CountChildren(ctx context.Context, name string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"parents": name})
+}
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"kafka-governance/config"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoWorkflowRepository stores approval workflows in MongoDB
+type MongoWorkflowRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoWorkflowRepository(db *mongo.Database, names config.Collections) *MongoWorkflowRepository {
+	return &MongoWorkflowRepository{collection: db.Collection(names.Workflows)}
+}
+
+func (r *MongoWorkflowRepository) Insert(ctx context.Context, workflow *models.ApprovalWorkflow) (*models.ApprovalWorkflow, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Inserting workflow into database")
+
+	workflow.ID = uuid.New().String()
+	workflow.Version = 1
+	workflow.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, workflow)
+	if mongo.IsDuplicateKeyError(err) {
+		logger.Error("Workflow with same name already exists")
+		return nil, utils.NewAlreadyExistsError("workflow with same name already exists")
+	}
+	if err != nil {
+		logger.Error("Failed to insert workflow into database")
+		return nil, err
+	}
+	logger.Info("Workflow inserted successfully")
+	return workflow, nil
+}
+
+func (r *MongoWorkflowRepository) List(ctx context.Context) ([]models.ApprovalWorkflow, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching workflows from database")
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		logger.Error("Failed to query workflows from database")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var workflows []models.ApprovalWorkflow
+	if err := cursor.All(ctx, &workflows); err != nil {
+		logger.Error("Failed to decode workflows from cursor")
+		return nil, err
+	}
+	logger.Infof("Successfully fetched workflows from database, count: %d", len(workflows))
+	return workflows, nil
+}
+
+func (r *MongoWorkflowRepository) GetByName(ctx context.Context, name string) (*models.ApprovalWorkflow, error) {
+	return r.find(ctx, bson.M{"name": name})
+}
+
+// GetForCluster returns the workflow attached directly to a cluster
+func (r *MongoWorkflowRepository) GetForCluster(ctx context.Context, cluster string) (*models.ApprovalWorkflow, error) {
+	return r.find(ctx, bson.M{"cluster": cluster})
+}
+
+// GetForEnvironment returns the workflow attached to an environment
+func (r *MongoWorkflowRepository) GetForEnvironment(ctx context.Context, env models.Environment) (*models.ApprovalWorkflow, error) {
+	return r.find(ctx, bson.M{"environment": env})
+}
+
+func (r *MongoWorkflowRepository) find(ctx context.Context, filter bson.M) (*models.ApprovalWorkflow, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching workflow from database")
+
+	var workflow models.ApprovalWorkflow
+	err := r.collection.FindOne(ctx, filter).Decode(&workflow)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Debug("Workflow not found in database")
+		return nil, utils.NewNotFoundError("workflow not found")
+	}
+	if err != nil {
+		logger.Error("Failed to fetch workflow from database")
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+// Update replaces the scope and stages of a workflow
+func (r *MongoWorkflowRepository) Update(ctx context.Context, name string, workflow *models.ApprovalWorkflow) (*models.ApprovalWorkflow, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Updating workflow in database")
+
+	var updated models.ApprovalWorkflow
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"name": name},
+		bson.M{
+			"$set": bson.M{
+				"cluster":     workflow.Cluster,
+				"environment": workflow.Environment,
+				"stages":      workflow.Stages,
+				"updatedAt":   time.Now(),
+			},
+			"$inc": bson.M{"version": 1},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Error("Workflow not found in database")
+		return nil, utils.NewNotFoundError("workflow not found")
+	}
+	if err != nil {
+		logger.Error("Failed to update workflow in database")
+		return nil, err
+	}
+	logger.Info("Workflow updated in database successfully")
+	return &updated, nil
+}
+
+func (r *MongoWorkflowRepository) Delete(ctx context.Context, name string) error {
+	logger := utils.GetLogger()
+	logger.Debug("Deleting workflow from database")
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		logger.Error("Failed to delete workflow from database")
+		return err
+	}
+	if result.DeletedCount == 0 {
+		logger.Error("Workflow not found in database")
+		return utils.NewNotFoundError("workflow not found")
+	}
+	logger.Info("Workflow deleted from database successfully")
+	return nil
+}
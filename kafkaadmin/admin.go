@@ -0,0 +1,56 @@
+package kafkaadmin
+
+import (
+	"context"
+	"errors"
+
+	"kafka-governance/models"
+)
+
+// ErrTopicExists is returned by CreateTopic when the topic is already on the cluster
+var ErrTopicExists = errors.New("topic already exists")
+
+// ErrTopicNotFound is returned when a topic to change is not on the cluster
+var ErrTopicNotFound = errors.New("topic does not exist on the cluster")
+
+// TopicSpec describes a topic to create on a cluster
+type TopicSpec struct {
+	Name       string
+	Partitions int
+	Replicas   int
+	Configs    map[string]string
+}
+
+// TopicMetadata is the live state of a topic on a cluster
+type TopicMetadata struct {
+	Name       string
+	Partitions int
+	Replicas   int
+	Configs    map[string]string // topic-level overrides only
+}
+
+// MetadataSource lists the topics that exist on a cluster
+type MetadataSource interface {
+	ListTopics(ctx context.Context) ([]TopicMetadata, error)
+}
+
+// Admin is the subset of the Kafka Admin API the governance service needs.
+// KafkaAdmin talks to a real cluster and MemoryAdmin is an in-process fake.
+type Admin interface {
+	MetadataSource
+	CreateTopic(ctx context.Context, spec TopicSpec) error
+	// CreatePartitions grows a topic to total partitions. It succeeds without a
+	// change when the topic already has exactly total partitions.
+	CreatePartitions(ctx context.Context, topic string, total int) error
+	// AlterConfigs sets the given topic-level configs and removes the overrides
+	// listed in remove, leaving the others as they are
+	AlterConfigs(ctx context.Context, topic string, set map[string]string, remove []string) error
+	// DeleteTopic removes a topic and its data. It succeeds when the topic is
+	// already gone.
+	DeleteTopic(ctx context.Context, topic string) error
+}
+
+// Connector hands out an Admin for a registered cluster
+type Connector interface {
+	Connect(cluster *models.Cluster) (Admin, error)
+}
@@ -0,0 +1,415 @@
+package kafkaadmin
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// KafkaAdmin implements Admin against a real cluster using kafka-go
+type KafkaAdmin struct {
+	client *kafka.Client
+}
+
+// NewKafkaAdmin creates an admin client for a registered cluster, using its
+// bootstrap servers and security settings
+func NewKafkaAdmin(cluster *models.Cluster, timeout time.Duration, caDir string) (*KafkaAdmin, error) {
+	transport := &kafka.Transport{DialTimeout: timeout}
+
+	sec := cluster.Security
+	if sec.Protocol == "SSL" || sec.Protocol == "SASL_SSL" {
+		tlsConfig, err := newTLSConfig(sec, caDir)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLS = tlsConfig
+	}
+	if sec.Protocol == "SASL_PLAINTEXT" || sec.Protocol == "SASL_SSL" {
+		mechanism, err := newSASLMechanism(sec)
+		if err != nil {
+			return nil, err
+		}
+		transport.SASL = mechanism
+	}
+
+	return &KafkaAdmin{
+		client: &kafka.Client{
+			Addr:      kafka.TCP(cluster.BootstrapServers...),
+			Timeout:   timeout,
+			Transport: transport,
+		},
+	}, nil
+}
+
+func (a *KafkaAdmin) CreateTopic(ctx context.Context, spec TopicSpec) error {
+	logger := utils.GetLogger()
+	logger.Debugf("Creating topic %s on Kafka cluster", spec.Name)
+
+	entries := make([]kafka.ConfigEntry, 0, len(spec.Configs))
+	for name, value := range spec.Configs {
+		entries = append(entries, kafka.ConfigEntry{ConfigName: name, ConfigValue: value})
+	}
+
+	resp, err := a.client.CreateTopics(ctx, &kafka.CreateTopicsRequest{
+		Topics: []kafka.TopicConfig{{
+			Topic:             spec.Name,
+			NumPartitions:     spec.Partitions,
+			ReplicationFactor: spec.Replicas,
+			ConfigEntries:     entries,
+		}},
+	})
+	if err != nil {
+		logger.Error("CreateTopics request to Kafka failed")
+		return err
+	}
+
+	if topicErr := resp.Errors[spec.Name]; topicErr != nil {
+		if errors.Is(topicErr, kafka.TopicAlreadyExists) {
+			return ErrTopicExists
+		}
+		logger.Errorf("Kafka rejected topic creation: %s", topicErr.Error())
+		return topicErr
+	}
+	logger.Info("Topic created on Kafka cluster")
+	return nil
+}
+
+func (a *KafkaAdmin) CreatePartitions(ctx context.Context, topic string, total int) error {
+	logger := utils.GetLogger()
+	logger.Debugf("Growing topic %s to %d partitions on Kafka cluster", topic, total)
+
+	// A previous attempt may have added the partitions before its result was recorded
+	meta, err := a.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		logger.Error("Metadata request to Kafka failed")
+		return err
+	}
+	if len(meta.Topics) == 0 || errors.Is(meta.Topics[0].Error, kafka.UnknownTopicOrPartition) {
+		return ErrTopicNotFound
+	}
+	if meta.Topics[0].Error != nil {
+		return meta.Topics[0].Error
+	}
+	if len(meta.Topics[0].Partitions) == total {
+		logger.Info("Topic already has the requested partitions")
+		return nil
+	}
+
+	resp, err := a.client.CreatePartitions(ctx, &kafka.CreatePartitionsRequest{
+		Topics: []kafka.TopicPartitionsConfig{{Name: topic, Count: int32(total)}},
+	})
+	if err != nil {
+		logger.Error("CreatePartitions request to Kafka failed")
+		return err
+	}
+	if topicErr := resp.Errors[topic]; topicErr != nil {
+		logger.Errorf("Kafka rejected partition increase: %s", topicErr.Error())
+		return topicErr
+	}
+	logger.Info("Topic partitions increased on Kafka cluster")
+	return nil
+}
+
+func (a *KafkaAdmin) AlterConfigs(ctx context.Context, topic string, set map[string]string, remove []string) error {
+	logger := utils.GetLogger()
+	logger.Debugf("Altering configs of topic %s on Kafka cluster", topic)
+
+	configs := make([]kafka.IncrementalAlterConfigsRequestConfig, 0, len(set)+len(remove))
+	for name, value := range set {
+		configs = append(configs, kafka.IncrementalAlterConfigsRequestConfig{
+			Name: name, Value: value, ConfigOperation: kafka.ConfigOperationSet,
+		})
+	}
+	for _, name := range remove {
+		configs = append(configs, kafka.IncrementalAlterConfigsRequestConfig{
+			Name: name, ConfigOperation: kafka.ConfigOperationDelete,
+		})
+	}
+
+	resp, err := a.client.IncrementalAlterConfigs(ctx, &kafka.IncrementalAlterConfigsRequest{
+		Resources: []kafka.IncrementalAlterConfigsRequestResource{{
+			ResourceType: kafka.ResourceTypeTopic,
+			ResourceName: topic,
+			Configs:      configs,
+		}},
+	})
+	if err != nil {
+		logger.Error("IncrementalAlterConfigs request to Kafka failed")
+		return err
+	}
+	for _, r := range resp.Resources {
+		if errors.Is(r.Error, kafka.UnknownTopicOrPartition) {
+			return ErrTopicNotFound
+		}
+		if r.Error != nil {
+			logger.Errorf("Kafka rejected config change: %s", r.Error.Error())
+			return r.Error
+		}
+	}
+	logger.Info("Topic configs altered on Kafka cluster")
+	return nil
+}
+
+func (a *KafkaAdmin) DeleteTopic(ctx context.Context, topic string) error {
+	logger := utils.GetLogger()
+	logger.Debugf("Deleting topic %s on Kafka cluster", topic)
+
+	resp, err := a.client.DeleteTopics(ctx, &kafka.DeleteTopicsRequest{Topics: []string{topic}})
+	if err != nil {
+		logger.Error("DeleteTopics request to Kafka failed")
+		return err
+	}
+	topicErr := resp.Errors[topic]
+	if errors.Is(topicErr, kafka.UnknownTopicOrPartition) {
+		logger.Info("Topic already absent from Kafka cluster")
+		return nil
+	}
+	if topicErr != nil {
+		logger.Errorf("Kafka rejected topic deletion: %s", topicErr.Error())
+		return topicErr
+	}
+	logger.Info("Topic deleted on Kafka cluster")
+	return nil
+}
+
+// ListTopics returns every non-internal topic on the cluster
+func (a *KafkaAdmin) ListTopics(ctx context.Context) ([]TopicMetadata, error) {
+	logger := utils.GetLogger()
+	logger.Debug("Fetching topic metadata from Kafka cluster")
+
+	resp, err := a.client.Metadata(ctx, &kafka.MetadataRequest{})
+	if err != nil {
+		logger.Error("Metadata request to Kafka failed")
+		return nil, err
+	}
+
+	topics := make([]TopicMetadata, 0, len(resp.Topics))
+	for _, t := range resp.Topics {
+		if t.Internal || strings.HasPrefix(t.Name, "__") {
+			continue
+		}
+		if t.Error != nil {
+			logger.Warnf("Kafka returned an error for topic %s: %s", t.Name, t.Error.Error())
+			continue
+		}
+
+		meta := TopicMetadata{Name: t.Name, Partitions: len(t.Partitions)}
+		for _, p := range t.Partitions {
+			if len(p.Replicas) > meta.Replicas {
+				meta.Replicas = len(p.Replicas)
+			}
+		}
+		topics = append(topics, meta)
+	}
+
+	if err := a.describeConfigs(ctx, topics); err != nil {
+		logger.Error("DescribeConfigs request to Kafka failed")
+		return nil, err
+	}
+	logger.Debugf("Fetched topic metadata from Kafka cluster, count: %d", len(topics))
+	return topics, nil
+}
+
+// topicConfigSource is the DYNAMIC_TOPIC_CONFIG source of a config entry
+const topicConfigSource = 1
+
+// describeConfigs fills in the topic-level config overrides of each topic
+func (a *KafkaAdmin) describeConfigs(ctx context.Context, topics []TopicMetadata) error {
+	if len(topics) == 0 {
+		return nil
+	}
+
+	resources := make([]kafka.DescribeConfigRequestResource, 0, len(topics))
+	for _, t := range topics {
+		resources = append(resources, kafka.DescribeConfigRequestResource{
+			ResourceType: kafka.ResourceTypeTopic,
+			ResourceName: t.Name,
+		})
+	}
+
+	resp, err := a.client.DescribeConfigs(ctx, &kafka.DescribeConfigsRequest{Resources: resources})
+	if err != nil {
+		return err
+	}
+
+	configs := make(map[string]map[string]string, len(resp.Resources))
+	for _, r := range resp.Resources {
+		if r.Error != nil {
+			utils.GetLogger().Warnf("Kafka returned an error describing topic %s: %s", r.ResourceName, r.Error.Error())
+			continue
+		}
+		overrides := map[string]string{}
+		for _, e := range r.ConfigEntries {
+			if e.IsSensitive {
+				continue
+			}
+			if e.ConfigSource == topicConfigSource || (e.ConfigSource == 0 && !e.IsDefault) {
+				overrides[e.ConfigName] = e.ConfigValue
+			}
+		}
+		configs[r.ResourceName] = overrides
+	}
+
+	for i := range topics {
+		topics[i].Configs = configs[topics[i].Name]
+	}
+	return nil
+}
+
+// KafkaConnector creates KafkaAdmin clients for registered clusters and reuses
+// them until the cluster settings change
+type KafkaConnector struct {
+	timeout time.Duration
+	caDir   string
+
+	mu      sync.Mutex
+	clients map[string]cachedAdmin
+}
+
+type cachedAdmin struct {
+	version time.Time
+	admin   *KafkaAdmin
+}
+
+// NewKafkaConnector creates a connector whose clusters may only use CA files
+// inside caDir
+func NewKafkaConnector(timeout time.Duration, caDir string) *KafkaConnector {
+	return &KafkaConnector{
+		timeout: timeout,
+		caDir:   caDir,
+		clients: map[string]cachedAdmin{},
+	}
+}
+
+func (k *KafkaConnector) Connect(cluster *models.Cluster) (Admin, error) {
+	version := cluster.CreatedAt
+	if cluster.UpdatedAt != nil {
+		version = *cluster.UpdatedAt
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if cached, ok := k.clients[cluster.Name]; ok && cached.version.Equal(version) {
+		return cached.admin, nil
+	}
+
+	admin, err := NewKafkaAdmin(cluster, k.timeout, k.caDir)
+	if err != nil {
+		return nil, err
+	}
+	k.clients[cluster.Name] = cachedAdmin{version: version, admin: admin}
+	return admin, nil
+}
+
+// PasswordEnvPrefix starts the name of every environment variable a cluster may
+// read its SASL password from, so a cluster registration cannot read the
+// service's other secrets
+const PasswordEnvPrefix = "KAFKA_SASL_"
+
+// CheckPasswordEnv rejects password environment variables without PasswordEnvPrefix
+func CheckPasswordEnv(name string) error {
+	if !strings.HasPrefix(name, PasswordEnvPrefix) || len(name) == len(PasswordEnvPrefix) {
+		return fmt.Errorf("password environment variable must start with %s", PasswordEnvPrefix)
+	}
+	return nil
+}
+
+// ResolveCAFile returns the path of a cluster CA file, which must be inside
+// caDir. A relative file is taken relative to caDir. Symbolic links are followed
+// once the file exists, so a link cannot point out of the directory either.
+func ResolveCAFile(caDir, file string) (string, error) {
+	if caDir == "" {
+		return "", errors.New("CA files are disabled, KAFKA_CA_DIR is not set")
+	}
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(caDir, path)
+	}
+	if !insideDir(caDir, path) {
+		return "", fmt.Errorf("CA file %s is outside %s", file, caDir)
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return path, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	dir, err := filepath.EvalSymlinks(caDir)
+	if err != nil {
+		return "", err
+	}
+	if !insideDir(dir, resolved) {
+		return "", fmt.Errorf("CA file %s is outside %s", file, caDir)
+	}
+	return resolved, nil
+}
+
+// insideDir reports whether path is below dir, comparing the cleaned paths
+func insideDir(dir, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	return err == nil && rel != "." && filepath.IsLocal(rel)
+}
+
+func newTLSConfig(sec models.ClusterSecurity, caDir string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: sec.InsecureSkipVerify,
+	}
+	if sec.CAFile == "" {
+		return cfg, nil
+	}
+
+	path, err := ResolveCAFile(caDir, sec.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", sec.CAFile)
+	}
+	cfg.RootCAs = pool
+	return cfg, nil
+}
+
+func newSASLMechanism(sec models.ClusterSecurity) (sasl.Mechanism, error) {
+	if err := CheckPasswordEnv(sec.PasswordEnv); err != nil {
+		return nil, err
+	}
+	password, ok := os.LookupEnv(sec.PasswordEnv)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s with the SASL password is not set", sec.PasswordEnv)
+	}
+
+	switch sec.SASLMechanism {
+	case "PLAIN":
+		return plain.Mechanism{Username: sec.Username, Password: password}, nil
+	case "SCRAM-SHA-256":
+		return scram.Mechanism(scram.SHA256, sec.Username, password)
+	case "SCRAM-SHA-512":
+		return scram.Mechanism(scram.SHA512, sec.Username, password)
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism %q", sec.SASLMechanism)
+	}
+}
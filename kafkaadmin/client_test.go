@@ -0,0 +1,64 @@
+package kafkaadmin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckPasswordEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "KAFKA_SASL_PROD_EU"},
+		{name: "KAFKA_SASL_", wantErr: true},
+		{name: "JWT_SECRET", wantErr: true},
+		{name: "kafka_sasl_prod", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckPasswordEnv(tt.name); (err != nil) != tt.wantErr {
+				t.Errorf("CheckPasswordEnv(%q) = %v, want error %v", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveCAFile(t *testing.T) {
+	caDir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(caDir, "prod.pem"), []byte("pem"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret"), filepath.Join(caDir, "link.pem")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		caDir   string
+		file    string
+		wantErr bool
+	}{
+		{name: "relative", caDir: caDir, file: "prod.pem"},
+		{name: "absolute inside", caDir: caDir, file: filepath.Join(caDir, "prod.pem")},
+		{name: "not created yet", caDir: caDir, file: "staging.pem"},
+		{name: "absolute outside", caDir: caDir, file: "/etc/passwd", wantErr: true},
+		{name: "dot dot", caDir: caDir, file: "../" + filepath.Base(outside) + "/secret", wantErr: true},
+		{name: "the directory itself", caDir: caDir, file: ".", wantErr: true},
+		{name: "symlink out", caDir: caDir, file: "link.pem", wantErr: true},
+		{name: "no CA directory", caDir: "", file: "prod.pem", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := ResolveCAFile(tt.caDir, tt.file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveCAFile(%q) = %q, %v, want error %v", tt.file, path, err, tt.wantErr)
+			}
+		})
+	}
+}
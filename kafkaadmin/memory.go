@@ -0,0 +1,202 @@
+package kafkaadmin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"kafka-governance/models"
+)
+
+// MemoryAdmin is an in-process fake broker for development and tests. It applies
+// the same checks a real cluster would for partitions and replication factor.
+type MemoryAdmin struct {
+	mu      sync.Mutex
+	brokers int
+	topics  map[string]TopicSpec
+
+	failNext  int
+	failError error
+}
+
+// NewMemoryAdmin creates an empty fake cluster with the given number of brokers
+func NewMemoryAdmin(brokers int) *MemoryAdmin {
+	return &MemoryAdmin{
+		brokers: brokers,
+		topics:  map[string]TopicSpec{},
+	}
+}
+
+func (m *MemoryAdmin) CreateTopic(ctx context.Context, spec TopicSpec) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.injectedFailure(); err != nil {
+		return err
+	}
+	if _, ok := m.topics[spec.Name]; ok {
+		return ErrTopicExists
+	}
+	if spec.Partitions <= 0 {
+		return fmt.Errorf("invalid partitions %d", spec.Partitions)
+	}
+	if spec.Replicas <= 0 || spec.Replicas > m.brokers {
+		return fmt.Errorf("replication factor %d larger than available brokers %d", spec.Replicas, m.brokers)
+	}
+
+	if minISR, ok := spec.Configs["min.insync.replicas"]; ok {
+		if n, err := strconv.Atoi(minISR); err != nil || n > spec.Replicas {
+			return fmt.Errorf("invalid min.insync.replicas %q for replication factor %d", minISR, spec.Replicas)
+		}
+	}
+
+	m.topics[spec.Name] = spec
+	return nil
+}
+
+func (m *MemoryAdmin) CreatePartitions(ctx context.Context, topic string, total int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.injectedFailure(); err != nil {
+		return err
+	}
+	spec, ok := m.topics[topic]
+	if !ok {
+		return ErrTopicNotFound
+	}
+	if total < spec.Partitions {
+		return fmt.Errorf("topic currently has %d partitions, which is higher than the requested %d", spec.Partitions, total)
+	}
+	spec.Partitions = total
+	m.topics[topic] = spec
+	return nil
+}
+
+func (m *MemoryAdmin) AlterConfigs(ctx context.Context, topic string, set map[string]string, remove []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.injectedFailure(); err != nil {
+		return err
+	}
+	spec, ok := m.topics[topic]
+	if !ok {
+		return ErrTopicNotFound
+	}
+	configs := make(map[string]string, len(spec.Configs)+len(set))
+	for k, v := range spec.Configs {
+		configs[k] = v
+	}
+	for k, v := range set {
+		configs[k] = v
+	}
+	for _, k := range remove {
+		delete(configs, k)
+	}
+	spec.Configs = configs
+	m.topics[topic] = spec
+	return nil
+}
+
+func (m *MemoryAdmin) DeleteTopic(ctx context.Context, topic string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.injectedFailure(); err != nil {
+		return err
+	}
+	delete(m.topics, topic)
+	return nil
+}
+
+func (m *MemoryAdmin) ListTopics(ctx context.Context) ([]TopicMetadata, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.injectedFailure(); err != nil {
+		return nil, err
+	}
+
+	topics := make([]TopicMetadata, 0, len(m.topics))
+	for _, spec := range m.topics {
+		configs := make(map[string]string, len(spec.Configs))
+		for k, v := range spec.Configs {
+			configs[k] = v
+		}
+		topics = append(topics, TopicMetadata{Name: spec.Name, Partitions: spec.Partitions, Replicas: spec.Replicas, Configs: configs})
+	}
+	sort.Slice(topics, func(i, j int) bool { return topics[i].Name < topics[j].Name })
+	return topics, nil
+}
+
+// PutTopic creates or overwrites a topic directly, bypassing governance, the way
+// an out-of-band change on a real cluster would
+func (m *MemoryAdmin) PutTopic(spec TopicSpec) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.topics[spec.Name] = spec
+}
+
+// RemoveTopic deletes a topic directly, bypassing governance
+func (m *MemoryAdmin) RemoveTopic(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.topics, name)
+}
+
+// Topic returns the stored spec of a topic created on the fake cluster
+func (m *MemoryAdmin) Topic(name string) (TopicSpec, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	spec, ok := m.topics[name]
+	return spec, ok
+}
+
+// FailNext makes the next n calls fail with err, to exercise retries
+func (m *MemoryAdmin) FailNext(n int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failNext = n
+	m.failError = err
+}
+
+func (m *MemoryAdmin) injectedFailure() error {
+	if m.failNext <= 0 {
+		return nil
+	}
+	m.failNext--
+	if m.failError != nil {
+		return m.failError
+	}
+	return fmt.Errorf("injected broker failure")
+}
+
+// MemoryConnector keeps one fake broker per cluster, sized by the cluster's broker count
+type MemoryConnector struct {
+	mu       sync.Mutex
+	clusters map[string]*MemoryAdmin
+}
+
+func NewMemoryConnector() *MemoryConnector {
+	return &MemoryConnector{clusters: map[string]*MemoryAdmin{}}
+}
+
+func (m *MemoryConnector) Connect(cluster *models.Cluster) (Admin, error) {
+	return m.Cluster(cluster.Name, cluster.BrokerCount), nil
+}
+
+// Cluster returns the fake broker of a cluster, creating it on first use
+func (m *MemoryConnector) Cluster(name string, brokers int) *MemoryAdmin {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	admin, ok := m.clusters[name]
+	if !ok {
+		admin = NewMemoryAdmin(brokers)
+		m.clusters[name] = admin
+	}
+	return admin
+}
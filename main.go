@@ -2,11 +2,18 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
 	"log"
+	"os"
 
+	"kafka-governance/api"
+	"kafka-governance/auth"
+	"kafka-governance/cedar"
 	"kafka-governance/config"
 	"kafka-governance/db"
+	"kafka-governance/kafkaadmin"
 	"kafka-governance/routes"
+	"kafka-governance/service"
 	"kafka-governance/utils"
 
 	"github.com/gin-gonic/gin"
@@ -22,32 +29,176 @@ func main() {
 	cfg := config.Load()
 	logger.Info("Configuration loaded successfully")
 
-	client, database, err := db.Connect(cfg.MongoURI)
-	if err != nil {
-		logger.Error("Failed to connect to database")
+	// Minting a development token and verifying an export do not need the database
+	if len(os.Args) > 1 && os.Args[1] == "audit-verify-export" {
+		os.Exit(runAuditVerifyExport(os.Args[2:]))
+	}
+	if err := cfg.CheckAuth(); err != nil {
+		logger.Error("Refusing to start with insecure token settings")
 		log.Fatal(err)
 	}
-	defer func() {
-		if err := client.Disconnect(context.TODO()); err != nil {
-			logger.Error("Failed to disconnect from database")
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		os.Exit(runToken(cfg, os.Args[2:]))
+	}
+
+	var store *db.Store
+	disconnect := func() {}
+	if cfg.Storage.Backend == "memory" {
+		store = db.NewMemoryStore()
+		logger.Warn("Using the in-memory store, all data is lost on restart")
+	} else {
+		client, database, err := db.Connect(cfg.Storage)
+		if err != nil {
+			logger.Error("Failed to connect to database")
+			log.Fatal(err)
+		}
+		disconnect = func() {
+			if err := client.Disconnect(context.TODO()); err != nil {
+				logger.Error("Failed to disconnect from database")
+			}
+		}
+		logger.Info("Database connection established")
+
+		migrateCtx, cancel := context.WithTimeout(context.Background(), cfg.MigrationTimeout)
+		err = db.Migrate(migrateCtx, database, cfg.Storage.Collections)
+		cancel()
+		if err != nil {
+			logger.Error("Failed to migrate database")
+			log.Fatal(err)
+		}
+
+		store = db.NewMongoStore(database, cfg.Storage.Collections)
+		logger.Info("MongoDB repositories initialized")
+	}
+	defer disconnect()
+
+	svcCfg := service.Config{
+		ProvisionRetries: cfg.ProvisionRetries,
+		ClusterCADir:     cfg.KafkaCADir,
+		DeletionGrace:    cfg.DeletionGrace,
+	}
+
+	if cfg.AuditSigningKeyFile != "" {
+		keyData, err := os.ReadFile(cfg.AuditSigningKeyFile)
+		if err != nil {
+			logger.Error("Failed to read audit signing key")
+			log.Fatal(err)
+		}
+		signer, err := service.ParseAuditSigningKey(keyData)
+		if err != nil {
+			logger.Error("Failed to parse audit signing key")
+			log.Fatal(err)
+		}
+		svcCfg.AuditSigner = signer
+		logger.Infof("Audit checkpoints signed with key %s", service.AuditKeyID(signer.Public().(ed25519.PublicKey)))
+	}
+
+	if cfg.AuthzEngine == "agent" {
+		svcCfg.Authorizer = cedar.NewClient(cedar.ClientConfig{
+			BaseURL:    cfg.CedarURL,
+			Timeout:    cfg.CedarTimeout,
+			MaxRetries: cfg.CedarRetries,
+		})
+		logger.Infof("Using cedar-agent authorizer at %s", cfg.CedarURL)
+	} else {
+		logger.Info("Using in-process policy evaluation")
+	}
+
+	switch cfg.KafkaAdmin {
+	case "kafka":
+		svcCfg.AdminConnector = kafkaadmin.NewKafkaConnector(cfg.KafkaTimeout, cfg.KafkaCADir)
+		logger.Info("Provisioning topics on registered Kafka clusters")
+	case "memory":
+		svcCfg.AdminConnector = kafkaadmin.NewMemoryConnector()
+		logger.Warn("Provisioning topics on in-memory fake brokers")
+	default:
+		logger.Warn("Kafka provisioning disabled, approved topics must be created manually")
+	}
+
+	svc := service.New(store, svcCfg)
+
+	if cfg.BootstrapAdmin != "" {
+		if err := svc.EnsureAdminPolicy(context.Background(), cfg.BootstrapAdmin); err != nil {
+			logger.Error("Failed to set up the bootstrap admin policy")
 			log.Fatal(err)
 		}
-	}()
+	}
+
+	// One-off CLI commands run instead of the server
+	if len(os.Args) > 1 {
+		code := runCommand(svc, os.Args[1:])
+		disconnect()
+		os.Exit(code)
+	}
 
-	logger.Info("Database connection established")
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go svc.RunProvisioner(workerCtx, cfg.ProvisionEvery)
+	go svc.RunReconciler(workerCtx, cfg.DriftEvery)
+	go svc.RunAuditCheckpointer(workerCtx, cfg.AuditCheckpointEvery)
+	go svc.RunPolicySync(workerCtx, cfg.PolicySyncEvery)
 
-	db.InitTopicRepo(database)
-	logger.Info("Topic repository initialized")
+	var verifier auth.Verifier
+	var login *auth.OIDCLogin
+	if cfg.OIDCIssuerURL != "" {
+		oidc, err := auth.NewOIDCVerifier(context.Background(), auth.OIDCConfig{
+			IssuerURL: cfg.OIDCIssuerURL,
+			Audience:  cfg.OIDCAudience,
+			Claims: auth.ClaimMapping{
+				Username: cfg.OIDCUserClaim,
+				Groups:   cfg.OIDCGroupsClaim,
+				Roles:    cfg.OIDCRolesClaim,
+				Email:    cfg.OIDCEmailClaim,
+			},
+			KeyCacheTTL: cfg.OIDCKeyCacheTTL,
+		})
+		if err != nil {
+			logger.Error("Failed to initialize OIDC verification")
+			log.Fatal(err)
+		}
+		verifier = oidc
+
+		if cfg.OIDCClientID != "" {
+			login, err = auth.NewOIDCLogin(oidc, auth.LoginConfig{
+				ClientID:     cfg.OIDCClientID,
+				ClientSecret: cfg.OIDCClientSecret,
+				RedirectURL:  cfg.OIDCRedirectURL,
+			})
+			if err != nil {
+				logger.Error("Failed to initialize OIDC login")
+				log.Fatal(err)
+			}
+		}
+		logger.Info("Using OIDC token verification")
+	} else {
+		static, err := auth.NewStaticKeyVerifier(auth.StaticKeyConfig{
+			Secret:        cfg.JWTSecret,
+			PublicKeyFile: cfg.JWTPublicKeyFile,
+			Issuer:        cfg.JWTIssuer,
+			Audience:      cfg.JWTAudience,
+		})
+		if err != nil {
+			logger.Error("Failed to initialize JWT verification")
+			log.Fatal(err)
+		}
+		verifier = static
+	}
 
 	r := gin.New()
 	r.Use(gin.Recovery())
 
 	// Health route
 	r.GET("/api/v1/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
+		// An authorizer missing policy changes is reported without failing the check
+		policies := svc.PolicySyncStatus()
+		status := "ok"
+		if !policies.InSync {
+			status = "degraded"
+		}
+		c.JSON(200, gin.H{"status": status, "policies": policies})
 	})
 
-	routes.Register(r)
+	routes.Register(r, api.NewHandler(svc), verifier, login)
 	logger.Info("Routes registered successfully")
 
 	addr := ":" + cfg.AppPort
@@ -4,30 +4,544 @@ import "time"
 
 type TopicStatus string
 
+// Topic lifecycle:
+// PENDING -> APPROVED | REJECTED, APPROVED -> PROVISIONING -> ACTIVE -> DEPRECATED -> DELETED,
+// PROVISIONING -> FAILED -> PROVISIONING when provisioning gives up and is retried,
+// and DEPRECATED -> ACTIVE when a deletion is cancelled
 const (
-	TopicPending  TopicStatus = "PENDING"
-	TopicApproved TopicStatus = "APPROVED"
+	TopicPending      TopicStatus = "PENDING"
+	TopicApproved     TopicStatus = "APPROVED"
+	TopicRejected     TopicStatus = "REJECTED"
+	TopicProvisioning TopicStatus = "PROVISIONING"
+	TopicFailed       TopicStatus = "FAILED"
+	TopicActive       TopicStatus = "ACTIVE"
+	TopicDeprecated   TopicStatus = "DEPRECATED"
+	TopicDeleted      TopicStatus = "DELETED"
 )
 
+// TopicStatuses lists every topic status in lifecycle order
+var TopicStatuses = []TopicStatus{
+	TopicPending, TopicApproved, TopicRejected, TopicProvisioning, TopicFailed, TopicActive, TopicDeprecated, TopicDeleted,
+}
+
+// TopicTransition records a single lifecycle status change
+type TopicTransition struct {
+	From   TopicStatus `bson:"from,omitempty" json:"from,omitempty"`
+	To     TopicStatus `bson:"to" json:"to"`
+	Actor  string      `bson:"actor" json:"actor"`
+	Reason string      `bson:"reason" json:"reason"`
+	At     time.Time   `bson:"at" json:"at"`
+}
+
+// TopicConfigs holds Kafka topic-level configs such as retention.ms, keyed by
+// config name. Values use Kafka's string form and are validated per key.
+type TopicConfigs map[string]string
+
 type Topic struct {
-	ID         string      `bson:"_id,omitempty" json:"id"`
-	Name       string      `bson:"name" json:"name"`
-	Cluster    string      `bson:"cluster" json:"cluster"`
-	Partitions int         `bson:"partitions" json:"partitions"`
-	Replicas   int         `bson:"replicas" json:"replicas"`
-	Status     TopicStatus `bson:"status" json:"status"`
-	RequestedBy string     `bson:"requestedBy" json:"requestedBy"`
-	ApprovedBy  string     `bson:"approvedBy,omitempty" json:"approvedBy,omitempty"`
-	CreatedAt  time.Time   `bson:"createdAt" json:"createdAt"`
-	ApprovedAt *time.Time  `bson:"approvedAt,omitempty" json:"approvedAt,omitempty"`
+	ID          string            `bson:"_id,omitempty" json:"id"`
+	Name        string            `bson:"name" json:"name"`
+	Cluster     string            `bson:"cluster" json:"cluster"`
+	Partitions  int               `bson:"partitions" json:"partitions"`
+	Replicas    int               `bson:"replicas" json:"replicas"`
+	Configs     TopicConfigs      `bson:"configs,omitempty" json:"configs,omitempty"`
+	OwnerTeam   string            `bson:"ownerTeam,omitempty" json:"ownerTeam,omitempty"`
+	Owners      []string          `bson:"owners,omitempty" json:"owners,omitempty"`
+	OnCall      string            `bson:"onCall,omitempty" json:"onCall,omitempty"`
+	Labels      map[string]string `bson:"labels,omitempty" json:"labels,omitempty"`
+	Status      TopicStatus       `bson:"status" json:"status"`
+	RequestedBy string            `bson:"requestedBy" json:"requestedBy"`
+	ApprovedBy  string            `bson:"approvedBy,omitempty" json:"approvedBy,omitempty"`
+	CreatedAt   time.Time         `bson:"createdAt" json:"createdAt"`
+	ApprovedAt  *time.Time        `bson:"approvedAt,omitempty" json:"approvedAt,omitempty"`
+	UpdatedAt   *time.Time        `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+	Transitions []TopicTransition `bson:"transitions,omitempty" json:"transitions,omitempty"`
+
+	// Approval tracks the multi-stage approval of a pending topic, when a workflow applies
+	Approval *TopicApproval `bson:"approval,omitempty" json:"approval,omitempty"`
+
+	// Revision numbers the stored versions of the topic, starting at 1 when it is
+	// requested and growing with every change to it. Each version is kept as a TopicRevision.
+	Revision int `bson:"revision" json:"revision"`
+
+	// Change is the open change request of the topic, if any
+	Change *TopicChange `bson:"change,omitempty" json:"change,omitempty"`
+
+	// Clients are the applications registered as producers or consumers of the topic
+	Clients []TopicClient `bson:"clients,omitempty" json:"clients,omitempty"`
+
+	// Transfer is the open request to hand the topic over to another team, if any
+	Transfer *OwnershipTransfer `bson:"transfer,omitempty" json:"transfer,omitempty"`
+
+	// Imported is set for topics that existed on the cluster before governance
+	Imported bool `bson:"imported,omitempty" json:"imported,omitempty"`
+
+	// Provisioning on the Kafka cluster
+	ProvisionedAt          *time.Time `bson:"provisionedAt,omitempty" json:"provisionedAt,omitempty"`
+	ProvisionAttempts      int        `bson:"provisionAttempts,omitempty" json:"provisionAttempts,omitempty"`
+	ProvisionError         string     `bson:"provisionError,omitempty" json:"provisionError,omitempty"`
+	LastProvisionAttemptAt *time.Time `bson:"lastProvisionAttemptAt,omitempty" json:"lastProvisionAttemptAt,omitempty"`
+}
+
+type TopicChangeStatus string
+
+// Change request lifecycle: PENDING until the last approval, then APPROVED until
+// it is applied to the cluster. An approved deletion keeps the topic DEPRECATED
+// until its grace period ends. A change that cannot be applied within the
+// provisioning attempt limit moves to FAILED until it is retried or cancelled.
+// Applied, rejected and cancelled changes are removed from the topic and kept in
+// the audit log.
+const (
+	ChangePending  TopicChangeStatus = "PENDING"
+	ChangeApproved TopicChangeStatus = "APPROVED"
+	ChangeFailed   TopicChangeStatus = "FAILED"
+)
+
+type TopicChangeKind string
+
+const (
+	ChangeUpdate TopicChangeKind = "UPDATE"
+	ChangeDelete TopicChangeKind = "DELETE"
+)
+
+// TopicChange is a requested modification of an active topic: an UPDATE of its
+// partitions or configs, or its DELETE. Configs maps each changed config to its
+// new value, or to null to remove the override.
+type TopicChange struct {
+	ID          string             `bson:"id" json:"id"`
+	Kind        TopicChangeKind    `bson:"kind" json:"kind"`
+	Status      TopicChangeStatus  `bson:"status" json:"status"`
+	Partitions  *int               `bson:"partitions,omitempty" json:"partitions,omitempty"`
+	Configs     map[string]*string `bson:"configs,omitempty" json:"configs,omitempty"`
+	Reason      string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	RequestedBy string             `bson:"requestedBy" json:"requestedBy"`
+	RequestedAt time.Time          `bson:"requestedAt" json:"requestedAt"`
+	ApprovedBy  string             `bson:"approvedBy,omitempty" json:"approvedBy,omitempty"`
+	ApprovedAt  *time.Time         `bson:"approvedAt,omitempty" json:"approvedAt,omitempty"`
+
+	// Approval tracks the multi-stage approval of the change, when a workflow applies
+	Approval *TopicApproval `bson:"approval,omitempty" json:"approval,omitempty"`
+
+	// Impact is what a deletion affects, as analysed when it was requested
+	Impact *TopicImpact `bson:"impact,omitempty" json:"impact,omitempty"`
+	// RemoveAfter is the end of an approved deletion's grace period
+	RemoveAfter *time.Time `bson:"removeAfter,omitempty" json:"removeAfter,omitempty"`
+
+	// Applying an approved change to the Kafka cluster
+	ApplyAttempts      int        `bson:"applyAttempts,omitempty" json:"applyAttempts,omitempty"`
+	ApplyError         string     `bson:"applyError,omitempty" json:"applyError,omitempty"`
+	LastApplyAttemptAt *time.Time `bson:"lastApplyAttemptAt,omitempty" json:"lastApplyAttemptAt,omitempty"`
+}
+
+type ClientRole string
+
+const (
+	ClientProducer ClientRole = "producer"
+	ClientConsumer ClientRole = "consumer"
+)
+
+// TopicClient is an application registered as a producer or consumer of a topic
+type TopicClient struct {
+	Application  string     `bson:"application" json:"application"`
+	Role         ClientRole `bson:"role" json:"role"`
+	RegisteredBy string     `bson:"registeredBy" json:"registeredBy"`
+	RegisteredAt time.Time  `bson:"registeredAt" json:"registeredAt"`
+}
+
+// OwnershipTransfer asks ToTeam to take over a topic. Owners and OnCall replace
+// the topic's when the receiving team accepts; a member of ToTeam may still set them then.
+type OwnershipTransfer struct {
+	ID          string    `bson:"id" json:"id"`
+	FromTeam    string    `bson:"fromTeam,omitempty" json:"fromTeam,omitempty"`
+	ToTeam      string    `bson:"toTeam" json:"toTeam"`
+	Owners      []string  `bson:"owners,omitempty" json:"owners,omitempty"`
+	OnCall      string    `bson:"onCall,omitempty" json:"onCall,omitempty"`
+	Reason      string    `bson:"reason,omitempty" json:"reason,omitempty"`
+	RequestedBy string    `bson:"requestedBy" json:"requestedBy"`
+	RequestedAt time.Time `bson:"requestedAt" json:"requestedAt"`
+}
+
+// Reasons a topic is reported as orphaned
+const (
+	OrphanNoOwnerTeam     = "NO_OWNER_TEAM"
+	OrphanTeamNotFound    = "TEAM_NOT_FOUND"
+	OrphanNoActiveMembers = "NO_ACTIVE_MEMBERS"
+)
+
+// OrphanedTopic is a topic nobody can answer for: it has no owner team, or its
+// team is missing from the directory or has no members left
+type OrphanedTopic struct {
+	Cluster   string      `json:"cluster"`
+	Name      string      `json:"name"`
+	Status    TopicStatus `json:"status"`
+	OwnerTeam string      `json:"ownerTeam,omitempty"`
+	Owners    []string    `json:"owners,omitempty"`
+	OnCall    string      `json:"onCall,omitempty"`
+	Reason    string      `json:"reason"`
+}
+
+// TopicImpact lists what removing a topic would affect. Dependents are the
+// topics produced by applications that consume the topic.
+type TopicImpact struct {
+	Producers  []TopicClient    `bson:"producers" json:"producers"`
+	Consumers  []TopicClient    `bson:"consumers" json:"consumers"`
+	Dependents []TopicDependent `bson:"dependents" json:"dependents"`
+}
+
+// TopicDependent is a topic fed by Application, a consumer of the analysed topic
+type TopicDependent struct {
+	Cluster     string      `bson:"cluster" json:"cluster"`
+	Name        string      `bson:"name" json:"name"`
+	Status      TopicStatus `bson:"status" json:"status"`
+	Application string      `bson:"application" json:"application"`
+}
+
+// TopicFilter selects topics; empty fields are not filtered on. A label with an
+// empty value matches any topic carrying the label.
+type TopicFilter struct {
+	Cluster     string
+	Statuses    []TopicStatus
+	RequestedBy string
+	OwnerTeam   string
+	// ExcludeOwnerTeams leaves out topics owned by any of the teams
+	ExcludeOwnerTeams []string
+	Labels            map[string]string
+	NamePrefix        string
+	NameRegex         string
+	CreatedSince      *time.Time
+	CreatedUntil      *time.Time
+}
+
+// Fields a topic listing can be sorted by
+const (
+	TopicSortName      = "name"
+	TopicSortCluster   = "cluster"
+	TopicSortStatus    = "status"
+	TopicSortCreatedAt = "createdAt"
+)
+
+// TopicSort orders a topic listing by Field, with the topic id breaking ties
+type TopicSort struct {
+	Field string
+	Desc  bool
+}
+
+// TopicRevision is a topic as it was after one of its changes. Revisions are kept
+// per topic id, so a name requested again after its deletion starts a new history.
+type TopicRevision struct {
+	TopicID  string    `bson:"topicId" json:"topicId"`
+	Revision int       `bson:"revision" json:"revision"`
+	Action   string    `bson:"action" json:"action"`
+	Actor    string    `bson:"actor" json:"actor"`
+	At       time.Time `bson:"at" json:"at"`
+	// Topic is the stored version without its transitions, which the history
+	// itself records. History listings leave it out.
+	Topic *Topic `bson:"topic,omitempty" json:"topic,omitempty"`
+}
+
+// TopicRevisionPage is one page of a topic's history, newest first
+type TopicRevisionPage struct {
+	Revisions  []TopicRevision `json:"revisions"`
+	NextCursor string          `json:"nextCursor,omitempty"`
+}
+
+// TopicDiff lists the fields that differ between two revisions of a topic
+type TopicDiff struct {
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Changes []AuditChange `json:"changes"`
+}
+
+// TopicPage is one page of a topic listing. NextCursor is empty on the last page.
+// Total counts every matching topic; when TotalExact is false counting stopped
+// early and Total is a lower bound.
+type TopicPage struct {
+	Topics     []Topic `json:"topics"`
+	NextCursor string  `json:"nextCursor,omitempty"`
+	Total      int64   `json:"total"`
+	TotalExact bool    `json:"totalExact"`
 }
 
 type Policy struct {
-	ID        string    `bson:"_id,omitempty" json:"id"`
-	Principal string    `bson:"principal" json:"principal"` // User::"u_123"
-	Action    string    `bson:"action" json:"action"`       // Action::"CreateTopic"
-	Resource  string    `bson:"resource" json:"resource"`   // Topic::"orders.created"
-	Effect    string    `bson:"effect" json:"effect"`        // permit / forbid
+	ID         string            `bson:"_id,omitempty" json:"id"`
+	Principal  string            `bson:"principal" json:"principal"`                       // User::"u_123"
+	Action     string            `bson:"action" json:"action"`                             // Action::"CreateTopic"
+	Resource   string            `bson:"resource" json:"resource"`                         // Topic::"prod-eu/orders.created"
+	Effect     string            `bson:"effect" json:"effect"`                             // permit / forbid
+	Conditions map[string]string `bson:"conditions,omitempty" json:"conditions,omitempty"` // when { context.key == "value" }
+	CreatedAt  time.Time         `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  *time.Time        `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+}
+
+// PolicyFilter narrows a policy listing; empty fields match everything
+type PolicyFilter struct {
+	Principal string
+	Action    string
+	Resource  string
+	Effect    string
+}
+
+// Entity is a Cedar entity reference together with the entities it is "in".
+type Entity struct {
+	UID     string   `json:"uid"`               // User::"u_123"
+	Parents []string `json:"parents,omitempty"` // Group::"platform-admins", Cluster::"prod"
+}
+
+// AuthzRequest is a single authorization query: can principal perform action on resource.
+type AuthzRequest struct {
+	Principal Entity            `json:"principal"`
+	Action    string            `json:"action"`
+	Resource  Entity            `json:"resource"`
+	Context   map[string]string `json:"context,omitempty"`
+}
+
+// AuthzDecision is the outcome of an authorization query and the policies that determined it.
+type AuthzDecision struct {
+	Allowed  bool     `json:"allowed"`
+	Policies []string `json:"policies"`
+}
+
+type Environment string
+
+const (
+	EnvDev     Environment = "dev"
+	EnvStaging Environment = "staging"
+	EnvProd    Environment = "prod"
+)
+
+// ClusterSecurity holds how the service authenticates to a cluster. Passwords are
+// never stored: PasswordEnv names the environment variable that holds it.
+type ClusterSecurity struct {
+	Protocol           string `bson:"protocol" json:"protocol"`                               // PLAINTEXT, SSL, SASL_PLAINTEXT, SASL_SSL
+	SASLMechanism      string `bson:"saslMechanism,omitempty" json:"saslMechanism,omitempty"` // PLAIN, SCRAM-SHA-256, SCRAM-SHA-512
+	Username           string `bson:"username,omitempty" json:"username,omitempty"`
+	PasswordEnv        string `bson:"passwordEnv,omitempty" json:"passwordEnv,omitempty"`
+	CAFile             string `bson:"caFile,omitempty" json:"caFile,omitempty"`
+	InsecureSkipVerify bool   `bson:"insecureSkipVerify,omitempty" json:"insecureSkipVerify,omitempty"`
+}
+
+// ConfigLimit narrows the values a topic config may take on a cluster, e.g. a
+// retention.ms cap on prod. Min and Max apply to numeric configs, Allowed to
+// enum and list configs.
+type ConfigLimit struct {
+	Min     *float64 `bson:"min,omitempty" json:"min,omitempty"`
+	Max     *float64 `bson:"max,omitempty" json:"max,omitempty"`
+	Allowed []string `bson:"allowed,omitempty" json:"allowed,omitempty"`
+}
+
+// Cluster is a Kafka cluster registered with governance and the limits topics on it must respect
+type Cluster struct {
+	ID                string                 `bson:"_id,omitempty" json:"id"`
+	Name              string                 `bson:"name" json:"name"`
+	BootstrapServers  []string               `bson:"bootstrapServers" json:"bootstrapServers"`
+	Security          ClusterSecurity        `bson:"security" json:"security"`
+	Environment       Environment            `bson:"environment" json:"environment"`
+	BrokerCount       int                    `bson:"brokerCount" json:"brokerCount"`
+	DefaultPartitions int                    `bson:"defaultPartitions" json:"defaultPartitions"`
+	MaxPartitions     int                    `bson:"maxPartitions" json:"maxPartitions"`
+	DefaultReplicas   int                    `bson:"defaultReplicas" json:"defaultReplicas"`
+	MaxReplicas       int                    `bson:"maxReplicas" json:"maxReplicas"`
+	ConfigLimits      map[string]ConfigLimit `bson:"configLimits,omitempty" json:"configLimits,omitempty"`
+	CreatedAt         time.Time              `bson:"createdAt" json:"createdAt"`
+	UpdatedAt         *time.Time             `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+}
+
+type DriftType string
+
+const (
+	DriftUngoverned         DriftType = "UNGOVERNED_TOPIC"    // on the cluster without an approved record
+	DriftNotProvisioned     DriftType = "NOT_PROVISIONED"     // approved but never created on the cluster
+	DriftMissing            DriftType = "MISSING_TOPIC"       // active in governance but gone from the cluster
+	DriftDeletedButPresent  DriftType = "DELETED_BUT_PRESENT" // deleted in governance but still on the cluster
+	DriftPartitionsMismatch DriftType = "PARTITION_MISMATCH"
+	DriftReplicasMismatch   DriftType = "REPLICA_MISMATCH"
+)
+
+// DriftFinding is a single disagreement between governance and the live cluster
+type DriftFinding struct {
+	Topic    string      `bson:"topic" json:"topic"`
+	Type     DriftType   `bson:"type" json:"type"`
+	Status   TopicStatus `bson:"status,omitempty" json:"status,omitempty"` // governance status, empty when ungoverned
+	Expected string      `bson:"expected,omitempty" json:"expected,omitempty"`
+	Actual   string      `bson:"actual,omitempty" json:"actual,omitempty"`
+}
+
+// DriftReport holds the findings of the latest reconciliation of a cluster
+type DriftReport struct {
+	Cluster   string         `bson:"_id" json:"cluster"`
+	CheckedAt time.Time      `bson:"checkedAt" json:"checkedAt"`
+	Findings  []DriftFinding `bson:"findings" json:"findings"`
+}
+
+// ImportSkip is a live topic an import left alone, with the reason why
+type ImportSkip struct {
+	Topic  string `json:"topic"`
+	Reason string `json:"reason"`
+}
+
+// ImportResult summarizes a brownfield import of a cluster
+type ImportResult struct {
+	Cluster  string       `json:"cluster"`
+	Imported []string     `json:"imported"`
+	Skipped  []ImportSkip `json:"skipped"`
+}
+
+// Principal is the authenticated caller of a request, taken from a verified token
+type Principal struct {
+	Subject string   `json:"subject"`
+	Email   string   `json:"email,omitempty"`
+	Groups  []string `json:"groups,omitempty"`
+	Roles   []string `json:"roles,omitempty"`
+}
+
+// User is a directory entry for a principal. Username matches the subject of the
+// user's tokens; groups and roles are added to those carried by the token.
+type User struct {
+	ID          string    `bson:"_id" json:"id"`
+	Username    string    `bson:"username" json:"username"`
+	Email       string    `bson:"email,omitempty" json:"email,omitempty"`
+	DisplayName string    `bson:"displayName,omitempty" json:"displayName,omitempty"`
+	Groups      []string  `bson:"groups" json:"groups"`
+	Roles       []string  `bson:"roles" json:"roles"`
+	CreatedAt   time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+}
+
+// Group is a named set of users. Its members inherit the group's roles, and
+// parent groups make it a member of other groups, e.g. "kafka-admins" in
+// "platform-admins".
+type Group struct {
+	ID          string    `bson:"_id" json:"id"`
+	Name        string    `bson:"name" json:"name"`
+	Description string    `bson:"description,omitempty" json:"description,omitempty"`
+	Parents     []string  `bson:"parents" json:"parents"`
+	Roles       []string  `bson:"roles" json:"roles"`
+	CreatedAt   time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+}
+
+// ApprovalStage is one step of an approval workflow: MinApprovals members of
+// Group must approve before the request moves to the next stage
+type ApprovalStage struct {
+	Name         string `bson:"name" json:"name"`
+	Group        string `bson:"group" json:"group"`
+	MinApprovals int    `bson:"minApprovals" json:"minApprovals"`
+}
+
+// ApprovalWorkflow defines the approval stages for topics on a cluster or on
+// every cluster of an environment. A cluster workflow takes precedence over the
+// environment's.
+type ApprovalWorkflow struct {
+	ID          string          `bson:"_id" json:"id"`
+	Name        string          `bson:"name" json:"name"`
+	Cluster     string          `bson:"cluster,omitempty" json:"cluster,omitempty"`
+	Environment Environment     `bson:"environment,omitempty" json:"environment,omitempty"`
+	Stages      []ApprovalStage `bson:"stages" json:"stages"`
+	// Version starts at 1 and grows with every update; requests record the version they follow
+	Version   int       `bson:"version" json:"version"`
 	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
 }
 
+// ApprovalVote is a single approval recorded against a workflow stage
+type ApprovalVote struct {
+	Stage    int       `bson:"stage" json:"stage"`
+	Approver string    `bson:"approver" json:"approver"`
+	Reason   string    `bson:"reason,omitempty" json:"reason,omitempty"`
+	At       time.Time `bson:"at" json:"at"`
+}
+
+// TopicApproval is the workflow state of a topic request. The stages are copied
+// from the workflow when the request enters it, so later workflow edits do not
+// change requests already in flight.
+type TopicApproval struct {
+	Workflow        string          `bson:"workflow" json:"workflow"`
+	WorkflowVersion int             `bson:"workflowVersion" json:"workflowVersion"`
+	Stages          []ApprovalStage `bson:"stages" json:"stages"`
+	Stage           int             `bson:"stage" json:"stage"`
+	Votes           []ApprovalVote  `bson:"votes" json:"votes"`
+}
+
+// AuditEvent records one change made through the service. Events are only ever
+// appended, never updated or deleted, and are numbered by Seq without gaps.
+type AuditEvent struct {
+	ID           string        `bson:"_id" json:"id"`
+	Seq          int64         `bson:"seq" json:"seq"`
+	At           time.Time     `bson:"at" json:"at"`
+	Actor        string        `bson:"actor" json:"actor"`
+	Action       string        `bson:"action" json:"action"`
+	ResourceType string        `bson:"resourceType" json:"resourceType"`
+	Resource     string        `bson:"resource" json:"resource"`
+	Changes      []AuditChange `bson:"changes" json:"changes"`
+	RequestID    string        `bson:"requestId,omitempty" json:"requestId,omitempty"`
+	SourceIP     string        `bson:"sourceIp,omitempty" json:"sourceIp,omitempty"`
+
+	// PrevHash and Hash chain the events: Hash covers the event content and
+	// PrevHash, which is the Hash of the event with the previous Seq
+	PrevHash string `bson:"prevHash" json:"prevHash"`
+	Hash     string `bson:"hash" json:"hash"`
+}
+
+// AuditChange is a field that differs between the resource before and after the change
+type AuditChange struct {
+	Field  string      `bson:"field" json:"field"`
+	Before interface{} `bson:"before,omitempty" json:"before,omitempty"`
+	After  interface{} `bson:"after,omitempty" json:"after,omitempty"`
+}
+
+// AuditFilter selects audit events; empty fields are not filtered on
+type AuditFilter struct {
+	Actor        string
+	Action       string
+	ResourceType string
+	Resource     string
+	RequestID    string
+	Since        *time.Time
+	Until        *time.Time
+}
+
+// AuditPage is one page of audit events, newest first. NextCursor is empty on the last page.
+type AuditPage struct {
+	Events     []AuditEvent `json:"events"`
+	NextCursor string       `json:"nextCursor,omitempty"`
+}
+
+// AuditCheckpoint is a signed statement of the audit chain head at a point in
+// time. It lets an export ending at Seq be verified without the rest of the chain.
+type AuditCheckpoint struct {
+	Seq       int64     `bson:"_id" json:"seq"`
+	Hash      string    `bson:"hash" json:"hash"`
+	At        time.Time `bson:"at" json:"at"`
+	KeyID     string    `bson:"keyId" json:"keyId"`
+	Signature string    `bson:"signature" json:"signature"`
+}
+
+// AuditBreak is the first point where the audit chain fails verification
+type AuditBreak struct {
+	Seq     int64  `json:"seq"`
+	EventID string `json:"eventId,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// AuditVerification is the result of walking the audit chain
+type AuditVerification struct {
+	Valid               bool        `json:"valid"`
+	Checked             int64       `json:"checked"`
+	FromSeq             int64       `json:"fromSeq,omitempty"`
+	HeadSeq             int64       `json:"headSeq"`
+	HeadHash            string      `json:"headHash,omitempty"`
+	CheckpointsVerified int         `json:"checkpointsVerified"`
+	Break               *AuditBreak `json:"break,omitempty"`
+}
+
+// PolicySyncStatus reports whether an external authorizer holds the stored policies
+type PolicySyncStatus struct {
+	InSync       bool       `json:"inSync"`
+	LastSyncedAt *time.Time `json:"lastSyncedAt,omitempty"`
+}
+
+// AuditExport is a contiguous range of the audit chain with the checkpoints that
+// fall inside it
+type AuditExport struct {
+	Events      []AuditEvent      `json:"events"`
+	Checkpoints []AuditCheckpoint `json:"checkpoints"`
+}
@@ -2,20 +2,105 @@ package routes
 
 import (
 	"kafka-governance/api"
+	"kafka-governance/auth"
 	"kafka-governance/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Register(r *gin.Engine) {
+// Register mounts the API routes served by h. login is nil unless an OIDC client is configured.
+func Register(r *gin.Engine, h *api.Handler, verifier auth.Verifier, login *auth.OIDCLogin) {
 	r.Use(utils.GinLoggingMiddleware())
+	r.Use(utils.RequestInfoMiddleware())
+
+	if login != nil {
+		r.GET("/api/v1/auth/login", login.Login)
+		r.GET("/api/v1/auth/callback", login.Callback)
+	}
 
 	v1 := r.Group("/api/v1")
+	v1.Use(auth.Middleware(verifier))
 	{
-		v1.POST("/topics", api.CreateTopic)
-		v1.GET("/topics", api.ListTopics)
-		v1.GET("/topics/:name", api.GetTopic)
-		v1.POST("/topics/:name/approve", api.ApproveTopic)
-		v1.POST("/policies", api.CreatePolicy)
+		v1.POST("/topics", h.CreateTopic)
+		v1.GET("/topics", h.ListTopics)
+		v1.GET("/topics/:name", h.GetTopic)
+		v1.POST("/topics/:name/approve", h.ApproveTopic)
+		v1.POST("/topics/:name/reject", h.RejectTopic)
+		v1.PATCH("/topics/:name", h.UpdateTopic)
+		v1.POST("/topics/:name/change/approve", h.ApproveTopicChange)
+		v1.POST("/topics/:name/change/reject", h.RejectTopicChange)
+		v1.POST("/topics/:name/change/retry", h.RetryTopicChange)
+		v1.POST("/topics/:name/change/cancel", h.CancelTopicChange)
+		v1.DELETE("/topics/:name", h.DeleteTopic)
+		v1.GET("/topics/:name/impact", h.GetTopicImpact)
+		v1.POST("/topics/:name/deletion/cancel", h.CancelTopicDeletion)
+		v1.POST("/topics/:name/provisioning/retry", h.RetryTopicProvisioning)
+		v1.POST("/topics/:name/clients", h.RegisterTopicClient)
+		v1.DELETE("/topics/:name/clients/:application", h.UnregisterTopicClient)
+		v1.GET("/topics/:name/history", h.GetTopicHistory)
+		v1.GET("/topics/:name/revisions/:rev", h.GetTopicRevision)
+		v1.GET("/topics/:name/diff", h.DiffTopicRevisions)
+		v1.PUT("/topics/:name/ownership", h.UpdateTopicOwnership)
+		v1.POST("/topics/:name/ownership/transfer", h.RequestTopicTransfer)
+		v1.DELETE("/topics/:name/ownership/transfer", h.WithdrawTopicTransfer)
+		v1.POST("/topics/:name/ownership/transfer/accept", h.AcceptTopicTransfer)
+		v1.POST("/topics/:name/ownership/transfer/decline", h.DeclineTopicTransfer)
+		v1.GET("/ownership/orphaned", h.ListOrphanedTopics)
+		v1.POST("/policies", h.CreatePolicy)
+		v1.GET("/policies", h.ListPolicies)
+		v1.GET("/policies/:id", h.GetPolicy)
+		v1.PUT("/policies/:id", h.UpdatePolicy)
+		v1.DELETE("/policies/:id", h.DeletePolicy)
+
+		v1.POST("/clusters", h.CreateCluster)
+		v1.GET("/clusters", h.ListClusters)
+		v1.GET("/clusters/:name", h.GetCluster)
+		v1.PUT("/clusters/:name", h.UpdateCluster)
+		v1.DELETE("/clusters/:name", h.DeleteCluster)
+		v1.GET("/clusters/:name/drift", h.GetClusterDrift)
+		v1.GET("/clusters/:name/topics/:topic", h.GetTopic)
+		v1.POST("/clusters/:name/topics/:topic/approve", h.ApproveTopic)
+		v1.POST("/clusters/:name/topics/:topic/reject", h.RejectTopic)
+		v1.PATCH("/clusters/:name/topics/:topic", h.UpdateTopic)
+		v1.POST("/clusters/:name/topics/:topic/change/approve", h.ApproveTopicChange)
+		v1.POST("/clusters/:name/topics/:topic/change/reject", h.RejectTopicChange)
+		v1.POST("/clusters/:name/topics/:topic/change/retry", h.RetryTopicChange)
+		v1.POST("/clusters/:name/topics/:topic/change/cancel", h.CancelTopicChange)
+		v1.DELETE("/clusters/:name/topics/:topic", h.DeleteTopic)
+		v1.GET("/clusters/:name/topics/:topic/impact", h.GetTopicImpact)
+		v1.POST("/clusters/:name/topics/:topic/deletion/cancel", h.CancelTopicDeletion)
+		v1.POST("/clusters/:name/topics/:topic/provisioning/retry", h.RetryTopicProvisioning)
+		v1.POST("/clusters/:name/topics/:topic/clients", h.RegisterTopicClient)
+		v1.DELETE("/clusters/:name/topics/:topic/clients/:application", h.UnregisterTopicClient)
+		v1.GET("/clusters/:name/topics/:topic/history", h.GetTopicHistory)
+		v1.GET("/clusters/:name/topics/:topic/revisions/:rev", h.GetTopicRevision)
+		v1.GET("/clusters/:name/topics/:topic/diff", h.DiffTopicRevisions)
+		v1.PUT("/clusters/:name/topics/:topic/ownership", h.UpdateTopicOwnership)
+		v1.POST("/clusters/:name/topics/:topic/ownership/transfer", h.RequestTopicTransfer)
+		v1.DELETE("/clusters/:name/topics/:topic/ownership/transfer", h.WithdrawTopicTransfer)
+		v1.POST("/clusters/:name/topics/:topic/ownership/transfer/accept", h.AcceptTopicTransfer)
+		v1.POST("/clusters/:name/topics/:topic/ownership/transfer/decline", h.DeclineTopicTransfer)
+		v1.POST("/clusters/:name/import", h.ImportClusterTopics)
+
+		v1.POST("/users", h.CreateUser)
+		v1.GET("/users", h.ListUsers)
+		v1.GET("/users/:username", h.GetUser)
+		v1.PUT("/users/:username", h.UpdateUser)
+		v1.DELETE("/users/:username", h.DeleteUser)
+		v1.POST("/groups", h.CreateGroup)
+		v1.GET("/groups", h.ListGroups)
+		v1.GET("/groups/:name", h.GetGroup)
+		v1.PUT("/groups/:name", h.UpdateGroup)
+		v1.DELETE("/groups/:name", h.DeleteGroup)
+
+		v1.POST("/workflows", h.CreateWorkflow)
+		v1.GET("/workflows", h.ListWorkflows)
+		v1.GET("/workflows/:name", h.GetWorkflow)
+		v1.PUT("/workflows/:name", h.UpdateWorkflow)
+		v1.DELETE("/workflows/:name", h.DeleteWorkflow)
+
+		v1.GET("/audit", h.ListAuditEvents)
+		v1.GET("/audit/verify", h.VerifyAuditChain)
+		v1.GET("/audit/export", h.ExportAudit)
 	}
 }
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+// systemActor is recorded for changes made outside an authenticated request
+const systemActor = "system"
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+// auditIgnoredFields are left out of diffs: they change with every update, and
+// topic transitions already describe the change the event records
+var auditIgnoredFields = map[string]bool{
+	"updatedAt":   true,
+	"transitions": true,
+}
+
+// recordAudit appends an event for a change to a resource, with the actor, request
+// ID and source IP taken from ctx. before is nil for creations and after is nil for
+// deletions. It runs in the transaction that stores the change, so a change whose
+// event cannot be written fails with it.
+func (s *Service) recordAudit(ctx context.Context, action, resourceType, resource string, before, after interface{}) error {
+	logger := utils.GetLogger()
+	info := utils.RequestInfoFrom(ctx)
+
+	actor := info.Actor
+	if actor == "" {
+		actor = systemActor
+	}
+
+	event := &models.AuditEvent{
+		At:           time.Now().UTC().Truncate(time.Millisecond),
+		Actor:        actor,
+		Action:       action,
+		ResourceType: resourceType,
+		Resource:     resource,
+		Changes:      diffAudit(before, after),
+		RequestID:    info.RequestID,
+		SourceIP:     info.SourceIP,
+	}
+
+	if err := s.appendAuditEvent(ctx, event); err != nil {
+		logger.Errorf("Failed to record audit event %s on %s %s: %s", action, resourceType, resource, err.Error())
+		return err
+	}
+	logger.Debugf("Audit event recorded: %s on %s %s by %s", action, resourceType, resource, actor)
+	return nil
+}
+
+// diffAudit compares the JSON form of two resources field by field, descending
+// into nested objects so a change is reported as e.g. configs.retention.ms
+func diffAudit(before, after interface{}) []models.AuditChange {
+	changes := []models.AuditChange{}
+	diffValues("", auditFields(before), auditFields(after), &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func diffValues(prefix string, before, after map[string]interface{}, changes *[]models.AuditChange) {
+	keys := map[string]bool{}
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+
+	for key := range keys {
+		if prefix == "" && auditIgnoredFields[key] {
+			continue
+		}
+		field := key
+		if prefix != "" {
+			field = prefix + "." + key
+		}
+
+		b, a := before[key], after[key]
+		if reflect.DeepEqual(b, a) {
+			continue
+		}
+		bMap, bIsMap := b.(map[string]interface{})
+		aMap, aIsMap := a.(map[string]interface{})
+		if (bIsMap || b == nil) && (aIsMap || a == nil) && (bIsMap || aIsMap) {
+			diffValues(field, bMap, aMap, changes)
+			continue
+		}
+		*changes = append(*changes, models.AuditChange{Field: field, Before: b, After: a})
+	}
+}
+
+func auditFields(v interface{}) map[string]interface{} {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// ListAuditEvents returns a page of audit events matching filter, newest first.
+// cursor is the NextCursor of the previous page, or empty for the first page.
+func (s *Service) ListAuditEvents(ctx context.Context, filter models.AuditFilter, cursor string, limit int) (*models.AuditPage, error) {
+	logger := utils.GetLogger()
+	logger.Info("Retrieving audit events")
+
+	if limit <= 0 {
+		limit = defaultAuditPageSize
+	}
+	if limit > maxAuditPageSize {
+		limit = maxAuditPageSize
+	}
+
+	var afterAt *time.Time
+	var afterID string
+	if cursor != "" {
+		at, id, err := decodeAuditCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		afterAt, afterID = &at, id
+	}
+
+	// Fetch one extra event to know whether another page follows
+	events, err := s.store.Audit.List(ctx, filter, afterAt, afterID, limit+1)
+	if err != nil {
+		logger.Error("Failed to retrieve audit events")
+		return nil, err
+	}
+
+	page := &models.AuditPage{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		last := page.Events[limit-1]
+		page.NextCursor = encodeAuditCursor(last.At, last.ID)
+	}
+	if page.Events == nil {
+		page.Events = []models.AuditEvent{}
+	}
+	logger.Infof("Audit events retrieved successfully, count: %d", len(page.Events))
+	return page, nil
+}
+
+// Cursors are opaque to clients: the position of the last event of a page
+func encodeAuditCursor(at time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(at.UnixMilli(), 10) + ":" + id))
+}
+
+func decodeAuditCursor(cursor string) (time.Time, string, error) {
+	invalid := utils.NewInvalidInputError("Invalid cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", invalid
+	}
+	ms, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return time.Time{}, "", invalid
+	}
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, "", invalid
+	}
+	return time.UnixMilli(n).UTC(), id, nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"kafka-governance/db"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+// flakyAudit fails every insert while down
+type flakyAudit struct {
+	db.AuditRepository
+	down bool
+}
+
+func (f *flakyAudit) Insert(ctx context.Context, event *models.AuditEvent) error {
+	if f.down {
+		return errors.New("server selection timeout")
+	}
+	return f.AuditRepository.Insert(ctx, event)
+}
+
+// racingAudit appends an event of another instance just before the first insert,
+// taking the seq that insert was about to use
+type racingAudit struct {
+	db.AuditRepository
+	raced bool
+}
+
+func (r *racingAudit) Insert(ctx context.Context, event *models.AuditEvent) error {
+	if !r.raced {
+		r.raced = true
+		other := &models.AuditEvent{ID: "other", Seq: event.Seq, PrevHash: event.PrevHash, Action: "policy.create",
+			ResourceType: "policy", Resource: "p1", Actor: "bob", At: event.At}
+		other.Hash, _ = HashAuditEvent(other)
+		if err := r.AuditRepository.Insert(ctx, other); err != nil {
+			return err
+		}
+	}
+	return r.AuditRepository.Insert(ctx, event)
+}
+
+func TestChangeFailsWhenItsAuditEventCannotBeWritten(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+	audit := &flakyAudit{AuditRepository: svc.store.Audit}
+	svc.store.Audit = audit
+
+	created, err := svc.CreateTopic(ctx, &models.Topic{Name: "orders", Cluster: "dev", RequestedBy: "alice"})
+	if err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+
+	audit.down = true
+	if _, err := svc.CreateTopic(ctx, &models.Topic{Name: "payments", Cluster: "dev", RequestedBy: "alice"}); err == nil {
+		t.Fatal("CreateTopic succeeded without its audit event")
+	}
+	var apiErr *utils.APIError
+	if _, err := svc.store.Topics.Get(ctx, "dev", "payments"); !errors.As(err, &apiErr) || apiErr.StatusCode != 404 {
+		t.Errorf("payments was stored without its audit event: %v", err)
+	}
+
+	if _, err := svc.TransitionTopic(ctx, "dev", "orders", models.TopicRejected, "bob", "No"); err == nil {
+		t.Fatal("TransitionTopic succeeded without its audit event")
+	}
+	topic := getTopic(t, svc, "orders")
+	if topic.Status != models.TopicPending || topic.Revision != created.Revision {
+		t.Errorf("orders is %s at revision %d, want the change undone", topic.Status, topic.Revision)
+	}
+	revisions, err := svc.store.Revisions.List(ctx, created.ID, 0, 10)
+	if err != nil || len(revisions) != 1 {
+		t.Errorf("revisions = %d, %v, want only the creation", len(revisions), err)
+	}
+
+	audit.down = false
+	if _, err := svc.TransitionTopic(ctx, "dev", "orders", models.TopicRejected, "bob", "No"); err != nil {
+		t.Fatalf("TransitionTopic: %v", err)
+	}
+	var actions []string
+	audit.Walk(ctx, 1, 0, func(event models.AuditEvent) bool {
+		actions = append(actions, event.Action)
+		return true
+	})
+	if want := []string{"topic.create", "topic.rejected"}; !slices.Equal(actions, want) {
+		t.Errorf("chain = %v, want %v", actions, want)
+	}
+}
+
+func TestChangeIsRetriedWhenAnotherWriterTakesTheAuditSeq(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+	svc.store.Audit = &racingAudit{AuditRepository: svc.store.Audit}
+
+	if _, err := svc.CreateTopic(ctx, &models.Topic{Name: "orders", Cluster: "dev", RequestedBy: "alice"}); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+
+	head, err := svc.store.Audit.Last(ctx)
+	if err != nil || head == nil || head.Seq != 2 || head.Action != "topic.create" {
+		t.Fatalf("head = %+v, %v, want topic.create appended after the other event", head, err)
+	}
+	if result, err := svc.VerifyAuditChain(ctx); err != nil || !result.Valid {
+		t.Errorf("VerifyAuditChain = %+v, %v", result, err)
+	}
+	revisions, err := svc.store.Revisions.List(ctx, getTopic(t, svc, "orders").ID, 0, 10)
+	if err != nil || len(revisions) != 1 {
+		t.Errorf("revisions = %d, %v, want one", len(revisions), err)
+	}
+}
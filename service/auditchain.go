@@ -0,0 +1,407 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"kafka-governance/db"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/google/uuid"
+)
+
+// auditAppendAttempts bounds the retries when other instances append concurrently
+const auditAppendAttempts = 10
+
+// inTransaction runs fn in a store transaction, so a change is stored together
+// with the audit event and revision that record it or not at all. fn runs again
+// when another writer took the audit seq it tried to append.
+func (s *Service) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := s.store.WithTransaction(ctx, fn)
+		if !errors.Is(err, db.ErrAuditSeqTaken) {
+			return err
+		}
+		if attempt == auditAppendAttempts {
+			return fmt.Errorf("could not append audit event after %d attempts", auditAppendAttempts)
+		}
+		utils.GetLogger().Debug("Audit seq taken by another writer, retrying")
+	}
+}
+
+// AuditKeyID identifies a checkpoint signing key by the hash of its public key
+func AuditKeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// auditHashInput is the content covered by an event hash, in a fixed field order
+type auditHashInput struct {
+	Seq          int64                `json:"seq"`
+	ID           string               `json:"id"`
+	At           string               `json:"at"`
+	Actor        string               `json:"actor"`
+	Action       string               `json:"action"`
+	ResourceType string               `json:"resourceType"`
+	Resource     string               `json:"resource"`
+	Changes      []models.AuditChange `json:"changes"`
+	RequestID    string               `json:"requestId"`
+	SourceIP     string               `json:"sourceIp"`
+	PrevHash     string               `json:"prevHash"`
+}
+
+// HashAuditEvent computes the SHA-256 hash of an event's content and PrevHash.
+// Change values are hashed in their JSON form, which map key ordering keeps stable.
+func HashAuditEvent(event *models.AuditEvent) (string, error) {
+	changes := event.Changes
+	if changes == nil {
+		changes = []models.AuditChange{}
+	}
+	raw, err := json.Marshal(auditHashInput{
+		Seq:          event.Seq,
+		ID:           event.ID,
+		At:           event.At.UTC().Format(time.RFC3339Nano),
+		Actor:        event.Actor,
+		Action:       event.Action,
+		ResourceType: event.ResourceType,
+		Resource:     event.Resource,
+		Changes:      changes,
+		RequestID:    event.RequestID,
+		SourceIP:     event.SourceIP,
+		PrevHash:     event.PrevHash,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// appendAuditEvent links the event to the current chain head and stores it. It
+// returns db.ErrAuditSeqTaken when another writer appended first; the caller's
+// transaction then runs again against the new head.
+func (s *Service) appendAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	head, err := s.store.Audit.Last(ctx)
+	if err != nil {
+		return err
+	}
+
+	event.ID = uuid.New().String()
+	event.Seq, event.PrevHash = 1, ""
+	if head != nil {
+		event.Seq, event.PrevHash = head.Seq+1, head.Hash
+	}
+	if event.Hash, err = HashAuditEvent(event); err != nil {
+		return err
+	}
+	return s.store.Audit.Insert(ctx, event)
+}
+
+// auditChainVerifier checks events one at a time in seq order
+type auditChainVerifier struct {
+	result   models.AuditVerification
+	prevSeq  int64
+	prevHash string
+	started  bool
+}
+
+func newAuditChainVerifier() *auditChainVerifier {
+	return &auditChainVerifier{result: models.AuditVerification{Valid: true}}
+}
+
+// check verifies one event and reports whether the walk should continue. The first
+// event of a partial export is trusted for its PrevHash, since the event before it
+// is not part of the export.
+func (v *auditChainVerifier) check(event models.AuditEvent) bool {
+	fail := func(reason string) bool {
+		v.result.Valid = false
+		v.result.Break = &models.AuditBreak{Seq: event.Seq, EventID: event.ID, Reason: reason}
+		return false
+	}
+
+	if !v.started {
+		v.started = true
+		v.result.FromSeq = event.Seq
+	} else {
+		if event.Seq != v.prevSeq+1 {
+			return fail(fmt.Sprintf("expected seq %d, found %d: events are missing", v.prevSeq+1, event.Seq))
+		}
+		if event.PrevHash != v.prevHash {
+			return fail("prevHash does not match the hash of the previous event")
+		}
+	}
+	if event.Seq == 1 && event.PrevHash != "" {
+		return fail("first event must not have a prevHash")
+	}
+
+	hash, err := HashAuditEvent(&event)
+	if err != nil || hash != event.Hash {
+		return fail("event content does not match its hash")
+	}
+
+	v.prevSeq, v.prevHash = event.Seq, event.Hash
+	v.result.Checked++
+	v.result.HeadSeq, v.result.HeadHash = event.Seq, event.Hash
+	return true
+}
+
+// checkCheckpoint verifies a checkpoint's signature and that it matches the
+// chain at its seq. pub may be nil to skip the signature check.
+func checkCheckpoint(checkpoint models.AuditCheckpoint, hashAtSeq string, pub ed25519.PublicKey) string {
+	if hashAtSeq == "" {
+		return fmt.Sprintf("checkpoint at seq %d refers to an event that is not in the chain", checkpoint.Seq)
+	}
+	if checkpoint.Hash != hashAtSeq {
+		return fmt.Sprintf("checkpoint at seq %d does not match the chain", checkpoint.Seq)
+	}
+	if pub == nil {
+		return ""
+	}
+	if checkpoint.KeyID != AuditKeyID(pub) {
+		return fmt.Sprintf("checkpoint at seq %d is signed by unknown key %s", checkpoint.Seq, checkpoint.KeyID)
+	}
+	sig, err := base64.StdEncoding.DecodeString(checkpoint.Signature)
+	if err != nil || !ed25519.Verify(pub, checkpointMessage(checkpoint), sig) {
+		return fmt.Sprintf("checkpoint at seq %d has an invalid signature", checkpoint.Seq)
+	}
+	return ""
+}
+
+// checkpointMessage is the byte string a checkpoint signature covers
+func checkpointMessage(checkpoint models.AuditCheckpoint) []byte {
+	return []byte("kafka-governance-audit-checkpoint:v1:" +
+		strconv.FormatInt(checkpoint.Seq, 10) + ":" +
+		checkpoint.Hash + ":" +
+		checkpoint.At.UTC().Format(time.RFC3339Nano))
+}
+
+// VerifyAuditChain walks the whole stored chain and reports the first break,
+// then checks every checkpoint against the chain
+func (s *Service) VerifyAuditChain(ctx context.Context) (*models.AuditVerification, error) {
+	logger := utils.GetLogger()
+	logger.Info("Verifying audit chain")
+
+	verifier := newAuditChainVerifier()
+	checkpointHashes := map[int64]string{}
+	checkpoints, err := s.store.Audit.ListCheckpoints(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, checkpoint := range checkpoints {
+		checkpointHashes[checkpoint.Seq] = ""
+	}
+
+	err = s.store.Audit.Walk(ctx, 1, 0, func(event models.AuditEvent) bool {
+		if !verifier.started && event.Seq != 1 {
+			verifier.started = true
+			verifier.result.Valid = false
+			verifier.result.Break = &models.AuditBreak{Seq: 1, Reason: fmt.Sprintf("chain starts at seq %d: events are missing", event.Seq)}
+			return false
+		}
+		ok := verifier.check(event)
+		if _, wanted := checkpointHashes[event.Seq]; wanted && ok {
+			checkpointHashes[event.Seq] = event.Hash
+		}
+		return ok
+	})
+	if err != nil {
+		logger.Error("Failed to walk audit chain")
+		return nil, err
+	}
+
+	result := verifier.result
+	if result.Valid {
+		result.CheckpointsVerified, result.Break = verifyCheckpoints(checkpoints, checkpointHashes, s.auditPublicKey())
+		result.Valid = result.Break == nil
+	}
+	logger.Infof("Audit chain verified, checked: %d, valid: %t", result.Checked, result.Valid)
+	return &result, nil
+}
+
+// VerifyAuditExport verifies an exported range of the chain on its own: the events
+// must link up, and the last event must be covered by a checkpoint signed with pub
+// so the range cannot have been rewritten after export
+func VerifyAuditExport(export models.AuditExport, pub ed25519.PublicKey) models.AuditVerification {
+	verifier := newAuditChainVerifier()
+	hashes := map[int64]string{}
+	for _, event := range export.Events {
+		if !verifier.check(event) {
+			return verifier.result
+		}
+		hashes[event.Seq] = event.Hash
+	}
+
+	result := verifier.result
+	if result.Checked == 0 {
+		result.Valid = false
+		result.Break = &models.AuditBreak{Reason: "export contains no events"}
+		return result
+	}
+
+	result.CheckpointsVerified, result.Break = verifyCheckpoints(export.Checkpoints, hashes, pub)
+	if result.Break == nil {
+		covered := false
+		for _, checkpoint := range export.Checkpoints {
+			covered = covered || checkpoint.Seq == result.HeadSeq
+		}
+		if !covered {
+			result.Break = &models.AuditBreak{Seq: result.HeadSeq, Reason: "last event is not covered by a signed checkpoint"}
+		}
+	}
+	result.Valid = result.Break == nil
+	return result
+}
+
+func verifyCheckpoints(checkpoints []models.AuditCheckpoint, hashes map[int64]string, pub ed25519.PublicKey) (int, *models.AuditBreak) {
+	verified := 0
+	for _, checkpoint := range checkpoints {
+		if reason := checkCheckpoint(checkpoint, hashes[checkpoint.Seq], pub); reason != "" {
+			return verified, &models.AuditBreak{Seq: checkpoint.Seq, Reason: reason}
+		}
+		verified++
+	}
+	return verified, nil
+}
+
+// ExportAudit returns the chained events from fromSeq to toSeq (0 for the head)
+// together with the checkpoints in that range
+func (s *Service) ExportAudit(ctx context.Context, fromSeq, toSeq int64) (*models.AuditExport, error) {
+	logger := utils.GetLogger()
+	logger.Info("Exporting audit chain")
+
+	export := &models.AuditExport{Events: []models.AuditEvent{}}
+	err := s.store.Audit.Walk(ctx, fromSeq, toSeq, func(event models.AuditEvent) bool {
+		export.Events = append(export.Events, event)
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to export audit events")
+		return nil, err
+	}
+
+	if export.Checkpoints, err = s.store.Audit.ListCheckpoints(ctx, fromSeq, toSeq); err != nil {
+		logger.Error("Failed to export audit checkpoints")
+		return nil, err
+	}
+	if export.Checkpoints == nil {
+		export.Checkpoints = []models.AuditCheckpoint{}
+	}
+	logger.Infof("Audit chain exported, events: %d", len(export.Events))
+	return export, nil
+}
+
+// WriteAuditCheckpoint signs the current chain head, unless it is already covered
+// by the latest checkpoint or no signing key is configured
+func (s *Service) WriteAuditCheckpoint(ctx context.Context) (*models.AuditCheckpoint, error) {
+	logger := utils.GetLogger()
+	if s.auditSigner == nil {
+		return nil, nil
+	}
+
+	head, err := s.store.Audit.Last(ctx)
+	if err != nil || head == nil {
+		return nil, err
+	}
+	last, err := s.store.Audit.LastCheckpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil && last.Seq >= head.Seq {
+		logger.Debug("Audit chain head already checkpointed")
+		return last, nil
+	}
+
+	checkpoint := models.AuditCheckpoint{
+		Seq:   head.Seq,
+		Hash:  head.Hash,
+		At:    time.Now().UTC().Truncate(time.Millisecond),
+		KeyID: AuditKeyID(s.auditPublicKey()),
+	}
+	checkpoint.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.auditSigner, checkpointMessage(checkpoint)))
+
+	if err := s.store.Audit.InsertCheckpoint(ctx, &checkpoint); err != nil {
+		return nil, err
+	}
+	logger.Infof("Audit checkpoint written at seq %d", checkpoint.Seq)
+	return &checkpoint, nil
+}
+
+// RunAuditCheckpointer writes a checkpoint every interval until ctx is done
+func (s *Service) RunAuditCheckpointer(ctx context.Context, interval time.Duration) {
+	logger := utils.GetLogger()
+	if s.auditSigner == nil {
+		logger.Warn("No audit signing key configured, checkpoints are disabled")
+		return
+	}
+	logger.Infof("Audit checkpointer started, interval: %s", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Audit checkpointer stopped")
+			return
+		case <-ticker.C:
+			if _, err := s.WriteAuditCheckpoint(ctx); err != nil {
+				logger.Errorf("Failed to write audit checkpoint: %s", err.Error())
+			}
+		}
+	}
+}
+
+// ParseAuditSigningKey reads an Ed25519 private key from a PKCS #8 PEM block, as
+// written by `openssl genpkey -algorithm ed25519`
+func ParseAuditSigningKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in audit signing key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("audit signing key is not an Ed25519 key")
+	}
+	return signer, nil
+}
+
+// ParseAuditPublicKey reads an Ed25519 public key from a PKIX PEM block
+func ParseAuditPublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in audit public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("audit public key is not an Ed25519 key")
+	}
+	return pub, nil
+}
+
+func (s *Service) auditPublicKey() ed25519.PublicKey {
+	if s.auditSigner == nil {
+		return nil
+	}
+	return s.auditSigner.Public().(ed25519.PublicKey)
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"slices"
+	"testing"
+
+	"kafka-governance/db"
+	"kafka-governance/models"
+)
+
+// tamperedAudit serves the stored chain and checkpoints after passing them through
+// the tamper functions, the way someone with write access to the store could edit them
+type tamperedAudit struct {
+	db.AuditRepository
+	events      func([]models.AuditEvent) []models.AuditEvent
+	checkpoints func([]models.AuditCheckpoint) []models.AuditCheckpoint
+}
+
+func (r *tamperedAudit) Walk(ctx context.Context, fromSeq, toSeq int64, fn func(models.AuditEvent) bool) error {
+	var events []models.AuditEvent
+	if err := r.AuditRepository.Walk(ctx, 1, 0, func(event models.AuditEvent) bool {
+		events = append(events, event)
+		return true
+	}); err != nil {
+		return err
+	}
+	if r.events != nil {
+		events = r.events(events)
+	}
+	for _, event := range events {
+		if event.Seq < fromSeq || toSeq > 0 && event.Seq > toSeq {
+			continue
+		}
+		if !fn(event) {
+			break
+		}
+	}
+	return nil
+}
+
+func (r *tamperedAudit) ListCheckpoints(ctx context.Context, fromSeq, toSeq int64) ([]models.AuditCheckpoint, error) {
+	checkpoints, err := r.AuditRepository.ListCheckpoints(ctx, fromSeq, toSeq)
+	if err != nil || r.checkpoints == nil {
+		return checkpoints, err
+	}
+	return r.checkpoints(checkpoints), nil
+}
+
+// newAuditChain returns a service with a signing key and a chain of four events,
+// the last covered by a checkpoint
+func newAuditChain(t *testing.T) (*Service, ed25519.PublicKey) {
+	t.Helper()
+	svc, _ := newTestService(t)
+	pub, signer, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	svc.auditSigner = signer
+
+	ctx := context.Background()
+	for _, action := range []string{"topic.create", "topic.approved", "topic.provisioning", "topic.active"} {
+		if err := svc.recordAudit(ctx, action, "topic", "dev/orders", nil, map[string]interface{}{"status": action}); err != nil {
+			t.Fatalf("recordAudit: %v", err)
+		}
+	}
+	if _, err := svc.WriteAuditCheckpoint(ctx); err != nil {
+		t.Fatalf("WriteAuditCheckpoint: %v", err)
+	}
+	return svc, pub
+}
+
+func rehash(t *testing.T, event *models.AuditEvent) {
+	t.Helper()
+	var err error
+	if event.Hash, err = HashAuditEvent(event); err != nil {
+		t.Fatalf("HashAuditEvent: %v", err)
+	}
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+	t.Parallel()
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+
+	tests := []struct {
+		name        string
+		events      func([]models.AuditEvent) []models.AuditEvent
+		checkpoints func([]models.AuditCheckpoint) []models.AuditCheckpoint
+		wantBreak   *models.AuditBreak
+	}{
+		{name: "intact chain"},
+		{
+			name: "changed field",
+			events: func(events []models.AuditEvent) []models.AuditEvent {
+				events[1].Actor = "mallory"
+				return events
+			},
+			wantBreak: &models.AuditBreak{Seq: 2, Reason: "event content does not match its hash"},
+		},
+		{
+			name: "changed field with its hash recomputed",
+			events: func(events []models.AuditEvent) []models.AuditEvent {
+				events[1].Changes = nil
+				events[1].Hash, _ = HashAuditEvent(&events[1])
+				return events
+			},
+			wantBreak: &models.AuditBreak{Seq: 3, Reason: "prevHash does not match the hash of the previous event"},
+		},
+		{
+			name: "deleted event",
+			events: func(events []models.AuditEvent) []models.AuditEvent {
+				return slices.Delete(events, 1, 2)
+			},
+			wantBreak: &models.AuditBreak{Seq: 3, Reason: "expected seq 2, found 3: events are missing"},
+		},
+		{
+			name: "deleted first event",
+			events: func(events []models.AuditEvent) []models.AuditEvent {
+				return events[1:]
+			},
+			wantBreak: &models.AuditBreak{Seq: 1, Reason: "chain starts at seq 2: events are missing"},
+		},
+		{
+			name: "reordered events",
+			events: func(events []models.AuditEvent) []models.AuditEvent {
+				events[1], events[2] = events[2], events[1]
+				return events
+			},
+			wantBreak: &models.AuditBreak{Seq: 3, Reason: "expected seq 2, found 3: events are missing"},
+		},
+		{
+			name: "forged checkpoint signature",
+			checkpoints: func(checkpoints []models.AuditCheckpoint) []models.AuditCheckpoint {
+				checkpoints[0].Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(otherKey, checkpointMessage(checkpoints[0])))
+				return checkpoints
+			},
+			wantBreak: &models.AuditBreak{Seq: 4, Reason: "checkpoint at seq 4 has an invalid signature"},
+		},
+		{
+			name: "checkpoint moved to another hash",
+			checkpoints: func(checkpoints []models.AuditCheckpoint) []models.AuditCheckpoint {
+				checkpoints[0].Hash = "0000"
+				return checkpoints
+			},
+			wantBreak: &models.AuditBreak{Seq: 4, Reason: "checkpoint at seq 4 does not match the chain"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			svc, _ := newAuditChain(t)
+			svc.store.Audit = &tamperedAudit{AuditRepository: svc.store.Audit, events: tt.events, checkpoints: tt.checkpoints}
+
+			result, err := svc.VerifyAuditChain(context.Background())
+			if err != nil {
+				t.Fatalf("VerifyAuditChain: %v", err)
+			}
+			if tt.wantBreak == nil {
+				if !result.Valid || result.Checked != 4 || result.CheckpointsVerified != 1 {
+					t.Errorf("result = %+v, want 4 valid events and 1 checkpoint", result)
+				}
+				return
+			}
+			if result.Valid || result.Break == nil {
+				t.Fatalf("result = %+v, want a break", result)
+			}
+			if result.Break.Seq != tt.wantBreak.Seq || result.Break.Reason != tt.wantBreak.Reason {
+				t.Errorf("break = %+v, want %+v", result.Break, tt.wantBreak)
+			}
+		})
+	}
+}
+
+func TestVerifyAuditExport(t *testing.T) {
+	t.Parallel()
+	svc, pub := newAuditChain(t)
+	ctx := context.Background()
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+
+	export := func(fromSeq, toSeq int64) models.AuditExport {
+		t.Helper()
+		exported, err := svc.ExportAudit(ctx, fromSeq, toSeq)
+		if err != nil {
+			t.Fatalf("ExportAudit: %v", err)
+		}
+		return *exported
+	}
+
+	if result := VerifyAuditExport(export(1, 0), pub); !result.Valid || result.Checked != 4 || result.CheckpointsVerified != 1 {
+		t.Errorf("full export = %+v, want valid", result)
+	}
+	// A range starting mid-chain trusts the prevHash of its first event
+	if result := VerifyAuditExport(export(3, 4), pub); !result.Valid || result.FromSeq != 3 || result.Checked != 2 {
+		t.Errorf("partial export = %+v, want valid", result)
+	}
+
+	if result := VerifyAuditExport(export(1, 0), otherPub); result.Valid || result.Break == nil ||
+		result.Break.Reason != "checkpoint at seq 4 is signed by unknown key "+AuditKeyID(pub) {
+		t.Errorf("export checked with another key = %+v, want an unknown key", result)
+	}
+
+	tampered := export(1, 0)
+	tampered.Events[2].Resource = "dev/payments"
+	rehash(t, &tampered.Events[2])
+	tampered.Events[3].PrevHash = tampered.Events[2].Hash
+	rehash(t, &tampered.Events[3])
+	if result := VerifyAuditExport(tampered, pub); result.Valid || result.Break == nil ||
+		result.Break.Reason != "checkpoint at seq 4 does not match the chain" {
+		t.Errorf("rewritten export = %+v, want the checkpoint to catch it", result)
+	}
+
+	if err := svc.recordAudit(ctx, "topic.deprecated", "topic", "dev/orders", nil, nil); err != nil {
+		t.Fatalf("recordAudit: %v", err)
+	}
+	if result := VerifyAuditExport(export(1, 0), pub); result.Valid || result.Break == nil ||
+		result.Break.Seq != 5 || result.Break.Reason != "last event is not covered by a signed checkpoint" {
+		t.Errorf("export past the checkpoint = %+v, want the head uncovered", result)
+	}
+
+	if result := VerifyAuditExport(models.AuditExport{}, pub); result.Valid || result.Break == nil ||
+		result.Break.Reason != "export contains no events" {
+		t.Errorf("empty export = %+v, want it rejected", result)
+	}
+}
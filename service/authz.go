@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"kafka-governance/db"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+// Authorizer decides authorization requests
+type Authorizer interface {
+	Authorize(ctx context.Context, req models.AuthzRequest) (*models.AuthzDecision, error)
+}
+
+// PolicySyncer is implemented by authorizers that keep their own copy of the
+// policies, such as the cedar-agent client, and must be told about changes
+type PolicySyncer interface {
+	SyncPolicies(ctx context.Context, policies []models.Policy) error
+	// PoliciesMatch reports whether the authorizer holds exactly these policies
+	PoliciesMatch(ctx context.Context, policies []models.Policy) (bool, error)
+}
+
+// reconnectNotifier is implemented by authorizers that can tell when they are
+// reachable again after an outage, when they may have lost their policies
+type reconnectNotifier interface {
+	Reconnected() <-chan struct{}
+}
+
+// localAuthorizer evaluates the stored policies in-process
+type localAuthorizer struct {
+	policies db.PolicyRepository
+}
+
+func (a localAuthorizer) Authorize(ctx context.Context, req models.AuthzRequest) (*models.AuthzDecision, error) {
+	policies, err := a.policies.List(ctx, models.PolicyFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	decision, err := utils.EvaluatePolicy(req, policies)
+	if err != nil {
+		return nil, err
+	}
+	return &decision, nil
+}
+
+// Authorize evaluates the request with the configured authorizer
+func (s *Service) Authorize(ctx context.Context, req models.AuthzRequest) (*models.AuthzDecision, error) {
+	logger := utils.GetLogger()
+	logger.Debugf("Authorizing %s for %s on %s", req.Action, req.Principal.UID, req.Resource.UID)
+
+	principal, err := s.ResolvePrincipal(ctx, req.Principal)
+	if err != nil {
+		logger.Error("Failed to resolve principal groups")
+		return nil, err
+	}
+	req.Principal = principal
+
+	decision, err := s.authorizer.Authorize(ctx, req)
+	if err != nil {
+		logger.Error("Policy evaluation failed")
+		return nil, err
+	}
+
+	if decision.Allowed {
+		logger.Infof("Authorization allowed by policies: %s", strings.Join(decision.Policies, ", "))
+	} else {
+		logger.Warnf("Authorization denied for %s on %s", req.Principal.UID, req.Resource.UID)
+	}
+	return decision, nil
+}
+
+// SyncPolicies pushes the stored policies to the authorizer when it keeps its own copy
+func (s *Service) SyncPolicies(ctx context.Context) error {
+	return s.syncPolicies(ctx, false)
+}
+
+// syncPolicies pushes the stored policies to the authorizer. With onlyIfChanged
+// the authorizer's copy is compared first and left alone when it matches.
+func (s *Service) syncPolicies(ctx context.Context, onlyIfChanged bool) error {
+	syncer, ok := s.authorizer.(PolicySyncer)
+	if !ok {
+		return nil
+	}
+
+	logger := utils.GetLogger()
+	logger.Debug("Syncing policies to authorizer")
+
+	// Pushes replace the whole set, so one at a time keeps an older set from
+	// overwriting a newer one
+	s.policySyncMu.Lock()
+	defer s.policySyncMu.Unlock()
+
+	err := func() error {
+		policies, err := s.store.Policies.List(ctx, models.PolicyFilter{})
+		if err != nil {
+			logger.Error("Failed to load policies for sync")
+			return err
+		}
+		if onlyIfChanged {
+			match, err := syncer.PoliciesMatch(ctx, policies)
+			if err != nil {
+				logger.Error("Failed to compare policies with authorizer")
+				return err
+			}
+			if match {
+				logger.Debug("Authorizer holds the stored policies")
+				return nil
+			}
+			logger.Warn("Authorizer policies differ from the store, pushing them again")
+		}
+		if err := syncer.SyncPolicies(ctx, policies); err != nil {
+			logger.Error("Failed to sync policies to authorizer")
+			return err
+		}
+		logger.Info("Policies synced to authorizer")
+		return nil
+	}()
+
+	s.policyStatusMu.Lock()
+	defer s.policyStatusMu.Unlock()
+	s.policySync.InSync = err == nil
+	if err == nil {
+		now := time.Now()
+		s.policySync.LastSyncedAt = &now
+	}
+	return err
+}
+
+// PolicySyncStatus reports whether the authorizer held the stored policies at the
+// last sync. It is always in sync when policies are evaluated in-process.
+func (s *Service) PolicySyncStatus() models.PolicySyncStatus {
+	if _, ok := s.authorizer.(PolicySyncer); !ok {
+		return models.PolicySyncStatus{InSync: true}
+	}
+	s.policyStatusMu.Lock()
+	defer s.policyStatusMu.Unlock()
+	return s.policySync
+}
+
+// RunPolicySync keeps an external authorizer's policies in line with the store
+// until ctx is done: it syncs right away, then every interval compares the
+// authorizer's copy and pushes the stored set when they differ, and pushes
+// again as soon as the authorizer is reachable after an outage
+func (s *Service) RunPolicySync(ctx context.Context, interval time.Duration) {
+	if _, ok := s.authorizer.(PolicySyncer); !ok {
+		return
+	}
+	logger := utils.GetLogger()
+	logger.Infof("Policy sync started, interval: %s", interval)
+
+	var reconnected <-chan struct{}
+	if notifier, ok := s.authorizer.(reconnectNotifier); ok {
+		reconnected = notifier.Reconnected()
+	}
+
+	if err := s.syncPolicies(ctx, false); err != nil {
+		logger.Warn("Initial policy sync failed, retrying every interval")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Policy sync stopped")
+			return
+		case <-reconnected:
+			logger.Info("Authorizer reconnected, pushing policies")
+			_ = s.syncPolicies(ctx, false)
+		case <-ticker.C:
+			_ = s.syncPolicies(ctx, true)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"kafka-governance/db"
+	"kafka-governance/models"
+)
+
+// fakeAgent is an authorizer with its own policy copy, like cedar-agent. It
+// can be taken down, and forgets its policies on restart.
+type fakeAgent struct {
+	mu          sync.Mutex
+	down        bool
+	policies    []models.Policy
+	pushes      int
+	reconnected chan struct{}
+}
+
+func (a *fakeAgent) Authorize(ctx context.Context, req models.AuthzRequest) (*models.AuthzDecision, error) {
+	return &models.AuthzDecision{Policies: []string{}}, nil
+}
+
+func (a *fakeAgent) SyncPolicies(ctx context.Context, policies []models.Policy) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.down {
+		return errors.New("connection refused")
+	}
+	a.policies = policies
+	a.pushes++
+	return nil
+}
+
+func (a *fakeAgent) PoliciesMatch(ctx context.Context, policies []models.Policy) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.down {
+		return false, errors.New("connection refused")
+	}
+	return len(a.policies) == len(policies), nil
+}
+
+func (a *fakeAgent) Reconnected() <-chan struct{} {
+	return a.reconnected
+}
+
+func (a *fakeAgent) held() (int, int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.policies), a.pushes
+}
+
+func TestPolicySyncRetriesUntilAgentMatches(t *testing.T) {
+	t.Parallel()
+	agent := &fakeAgent{down: true, reconnected: make(chan struct{}, 1)}
+	svc := New(db.NewMemoryStore(), Config{Authorizer: agent})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	// The policy is stored while the agent is down
+	if _, err := svc.CreatePolicy(ctx, models.Policy{Principal: `Group::"admins"`, Action: "*", Resource: "*", Effect: "permit"}); err != nil {
+		t.Fatalf("CreatePolicy: %v", err)
+	}
+	if status := svc.PolicySyncStatus(); status.InSync {
+		t.Fatalf("status = %+v after a failed push, want out of sync", status)
+	}
+
+	go svc.RunPolicySync(ctx, 10*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	if n, _ := agent.held(); n != 0 {
+		t.Fatal("policies reached an agent that is down")
+	}
+
+	// The worker pushes once the agent is back
+	agent.mu.Lock()
+	agent.down = false
+	agent.mu.Unlock()
+	waitFor(t, func() bool { n, _ := agent.held(); return n == 1 })
+	waitFor(t, func() bool { return svc.PolicySyncStatus().InSync })
+
+	// Matching policies are left alone
+	_, pushes := agent.held()
+	time.Sleep(30 * time.Millisecond)
+	if _, after := agent.held(); after != pushes {
+		t.Errorf("pushed %d times while the agent held the stored policies", after-pushes)
+	}
+
+	// A restarted agent has lost its policies and gets them again
+	agent.mu.Lock()
+	agent.policies = nil
+	agent.mu.Unlock()
+	waitFor(t, func() bool { n, _ := agent.held(); return n == 1 })
+}
+
+func TestPolicySyncPushesOnReconnect(t *testing.T) {
+	t.Parallel()
+	agent := &fakeAgent{reconnected: make(chan struct{}, 1)}
+	svc := New(db.NewMemoryStore(), Config{Authorizer: agent})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	go svc.RunPolicySync(ctx, time.Hour)
+	waitFor(t, func() bool { _, pushes := agent.held(); return pushes == 1 })
+
+	agent.reconnected <- struct{}{}
+	waitFor(t, func() bool { _, pushes := agent.held(); return pushes == 2 })
+}
+
+func TestPolicySyncStatusWithLocalAuthorizer(t *testing.T) {
+	t.Parallel()
+	svc := New(db.NewMemoryStore(), Config{})
+	if status := svc.PolicySyncStatus(); !status.InSync {
+		t.Errorf("status = %+v, in-process evaluation is always in sync", status)
+	}
+}
+
+// waitFor polls until done holds, failing the test after a second
+func waitFor(t *testing.T, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatal("gave up waiting")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
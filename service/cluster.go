@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"kafka-governance/kafkaadmin"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+// ValidateClusterSecurity checks that a cluster only reads its SASL password from
+// a KAFKA_SASL_ environment variable and its CA file from the CA directory
+func (s *Service) ValidateClusterSecurity(sec models.ClusterSecurity) error {
+	if sec.PasswordEnv != "" {
+		if err := kafkaadmin.CheckPasswordEnv(sec.PasswordEnv); err != nil {
+			return utils.NewInvalidInputError(err.Error())
+		}
+	}
+	if sec.CAFile != "" {
+		if _, err := kafkaadmin.ResolveCAFile(s.clusterCADir, sec.CAFile); err != nil {
+			return utils.NewInvalidInputError(err.Error())
+		}
+	}
+	return nil
+}
+
+func (s *Service) CreateCluster(ctx context.Context, cluster models.Cluster) (*models.Cluster, error) {
+	logger := utils.GetLogger()
+	logger.Info("Registering new cluster")
+
+	var created *models.Cluster
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.store.Clusters.Insert(ctx, &cluster); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "cluster.create", "cluster", created.Name, nil, created)
+	})
+	if err != nil {
+		logger.Error("Cluster registration failed")
+		return nil, err
+	}
+	logger.Info("Cluster registered successfully")
+	return created, nil
+}
+
+func (s *Service) ListClusters(ctx context.Context) ([]models.Cluster, error) {
+	logger := utils.GetLogger()
+	logger.Info("Retrieving clusters list")
+
+	clusters, err := s.store.Clusters.List(ctx)
+	if err != nil {
+		logger.Error("Failed to retrieve clusters list")
+		return nil, err
+	}
+	logger.Infof("Clusters list retrieved successfully, count: %d", len(clusters))
+	return clusters, nil
+}
+
+func (s *Service) GetCluster(ctx context.Context, name string) (*models.Cluster, error) {
+	logger := utils.GetLogger()
+	logger.Info("Retrieving cluster by name")
+
+	cluster, err := s.store.Clusters.GetByName(ctx, name)
+	if err != nil {
+		logger.Error("Failed to retrieve cluster")
+		return nil, err
+	}
+	logger.Info("Cluster retrieved successfully")
+	return cluster, nil
+}
+
+func (s *Service) UpdateCluster(ctx context.Context, name string, cluster models.Cluster) (*models.Cluster, error) {
+	logger := utils.GetLogger()
+	logger.Info("Updating cluster")
+
+	var updated *models.Cluster
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.store.Clusters.GetByName(ctx, name)
+		if err != nil {
+			logger.Error("Failed to retrieve cluster for update")
+			return err
+		}
+		if updated, err = s.store.Clusters.Update(ctx, name, &cluster); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "cluster.update", "cluster", name, before, updated)
+	})
+	if err != nil {
+		logger.Error("Cluster update failed")
+		return nil, err
+	}
+	logger.Info("Cluster updated successfully")
+	return updated, nil
+}
+
+// DeleteCluster removes a cluster from the registry once no governed topics remain on it
+func (s *Service) DeleteCluster(ctx context.Context, name string) error {
+	logger := utils.GetLogger()
+	logger.Info("Deleting cluster")
+
+	count, err := s.store.Topics.CountOnCluster(ctx, name)
+	if err != nil {
+		logger.Error("Failed to check topics on cluster")
+		return err
+	}
+	if count > 0 {
+		logger.Errorf("Cluster still has %d topics", count)
+		return utils.NewConflictError(fmt.Sprintf("cluster still has %d topics", count))
+	}
+
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.store.Clusters.GetByName(ctx, name)
+		if err != nil {
+			logger.Error("Failed to retrieve cluster for deletion")
+			return err
+		}
+		if err := s.store.Clusters.Delete(ctx, name); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "cluster.delete", "cluster", name, before, nil)
+	})
+	if err != nil {
+		logger.Error("Cluster deletion failed")
+		return err
+	}
+	logger.Info("Cluster deleted successfully")
+	return nil
+}
+
+// ApplyClusterLimits fills in the cluster defaults for partitions and replicas the
+// request left out, then checks the topic against the cluster limits
+func ApplyClusterLimits(topic *models.Topic, cluster *models.Cluster) error {
+	if topic.Partitions == 0 {
+		topic.Partitions = cluster.DefaultPartitions
+	}
+	if topic.Replicas == 0 {
+		topic.Replicas = cluster.DefaultReplicas
+	}
+
+	if topic.Replicas > cluster.BrokerCount {
+		return utils.NewInvalidInputError(fmt.Sprintf("Replicas (%d) cannot exceed the broker count of cluster %s (%d)", topic.Replicas, cluster.Name, cluster.BrokerCount))
+	}
+	if cluster.MaxReplicas > 0 && topic.Replicas > cluster.MaxReplicas {
+		return utils.NewInvalidInputError(fmt.Sprintf("Replicas (%d) cannot exceed the maximum of cluster %s (%d)", topic.Replicas, cluster.Name, cluster.MaxReplicas))
+	}
+	if cluster.MaxPartitions > 0 && topic.Partitions > cluster.MaxPartitions {
+		return utils.NewInvalidInputError(fmt.Sprintf("Partitions (%d) cannot exceed the maximum of cluster %s (%d)", topic.Partitions, cluster.Name, cluster.MaxPartitions))
+	}
+	return nil
+}
@@ -0,0 +1,391 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+func (s *Service) CreateUser(ctx context.Context, user models.User) (*models.User, error) {
+	logger := utils.GetLogger()
+	logger.Info("Creating new user")
+
+	if err := s.checkGroupsExist(ctx, user.Groups); err != nil {
+		return nil, err
+	}
+
+	var created *models.User
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.store.Users.Insert(ctx, &user); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "user.create", "user", created.Username, nil, created)
+	})
+	if err != nil {
+		logger.Error("User creation failed")
+		return nil, err
+	}
+	logger.Info("User created successfully")
+	return created, nil
+}
+
+func (s *Service) ListUsers(ctx context.Context, group string) ([]models.User, error) {
+	logger := utils.GetLogger()
+	logger.Info("Retrieving users list")
+
+	users, err := s.store.Users.List(ctx, group)
+	if err != nil {
+		logger.Error("Failed to retrieve users list")
+		return nil, err
+	}
+	logger.Infof("Users list retrieved successfully, count: %d", len(users))
+	return users, nil
+}
+
+func (s *Service) GetUser(ctx context.Context, username string) (*models.User, error) {
+	logger := utils.GetLogger()
+	logger.Info("Retrieving user by username")
+
+	user, err := s.store.Users.GetByUsername(ctx, username)
+	if err != nil {
+		logger.Error("Failed to retrieve user")
+		return nil, err
+	}
+	logger.Info("User retrieved successfully")
+	return user, nil
+}
+
+func (s *Service) UpdateUser(ctx context.Context, username string, user models.User) (*models.User, error) {
+	logger := utils.GetLogger()
+	logger.Info("Updating user")
+
+	if err := s.checkGroupsExist(ctx, user.Groups); err != nil {
+		return nil, err
+	}
+
+	var updated *models.User
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.store.Users.GetByUsername(ctx, username)
+		if err != nil {
+			logger.Error("Failed to retrieve user for update")
+			return err
+		}
+		if updated, err = s.store.Users.Update(ctx, username, &user); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "user.update", "user", username, before, updated)
+	})
+	if err != nil {
+		logger.Error("User update failed")
+		return nil, err
+	}
+	logger.Info("User updated successfully")
+	return updated, nil
+}
+
+func (s *Service) DeleteUser(ctx context.Context, username string) error {
+	logger := utils.GetLogger()
+	logger.Info("Deleting user")
+
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.store.Users.GetByUsername(ctx, username)
+		if err != nil {
+			logger.Error("Failed to retrieve user for deletion")
+			return err
+		}
+		if err := s.store.Users.Delete(ctx, username); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "user.delete", "user", username, before, nil)
+	})
+	if err != nil {
+		logger.Error("User deletion failed")
+		return err
+	}
+	logger.Info("User deleted successfully")
+	return nil
+}
+
+func (s *Service) CreateGroup(ctx context.Context, group models.Group) (*models.Group, error) {
+	logger := utils.GetLogger()
+	logger.Info("Creating new group")
+
+	if err := s.checkGroupParents(ctx, group.Name, group.Parents); err != nil {
+		return nil, err
+	}
+
+	var created *models.Group
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.store.Groups.Insert(ctx, &group); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "group.create", "group", created.Name, nil, created)
+	})
+	if err != nil {
+		logger.Error("Group creation failed")
+		return nil, err
+	}
+	logger.Info("Group created successfully")
+	return created, nil
+}
+
+func (s *Service) ListGroups(ctx context.Context) ([]models.Group, error) {
+	logger := utils.GetLogger()
+	logger.Info("Retrieving groups list")
+
+	groups, err := s.store.Groups.List(ctx)
+	if err != nil {
+		logger.Error("Failed to retrieve groups list")
+		return nil, err
+	}
+	logger.Infof("Groups list retrieved successfully, count: %d", len(groups))
+	return groups, nil
+}
+
+func (s *Service) GetGroup(ctx context.Context, name string) (*models.Group, error) {
+	logger := utils.GetLogger()
+	logger.Info("Retrieving group by name")
+
+	group, err := s.store.Groups.GetByName(ctx, name)
+	if err != nil {
+		logger.Error("Failed to retrieve group")
+		return nil, err
+	}
+	logger.Info("Group retrieved successfully")
+	return group, nil
+}
+
+func (s *Service) UpdateGroup(ctx context.Context, name string, group models.Group) (*models.Group, error) {
+	logger := utils.GetLogger()
+	logger.Info("Updating group")
+
+	if err := s.checkGroupParents(ctx, name, group.Parents); err != nil {
+		return nil, err
+	}
+
+	var updated *models.Group
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.store.Groups.GetByName(ctx, name)
+		if err != nil {
+			logger.Error("Failed to retrieve group for update")
+			return err
+		}
+		if updated, err = s.store.Groups.Update(ctx, name, &group); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "group.update", "group", name, before, updated)
+	})
+	if err != nil {
+		logger.Error("Group update failed")
+		return nil, err
+	}
+	logger.Info("Group updated successfully")
+	return updated, nil
+}
+
+// DeleteGroup removes a group once no users or groups are members of it
+func (s *Service) DeleteGroup(ctx context.Context, name string) error {
+	logger := utils.GetLogger()
+	logger.Info("Deleting group")
+
+	users, err := s.store.Users.CountMembers(ctx, name)
+	if err != nil {
+		logger.Error("Failed to check group members")
+		return err
+	}
+	groups, err := s.store.Groups.CountChildren(ctx, name)
+	if err != nil {
+		logger.Error("Failed to check group members")
+		return err
+	}
+	if users > 0 || groups > 0 {
+		logger.Errorf("Group still has %d users and %d groups", users, groups)
+		return utils.NewConflictError(fmt.Sprintf("group still has %d users and %d member groups", users, groups))
+	}
+
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.store.Groups.GetByName(ctx, name)
+		if err != nil {
+			logger.Error("Failed to retrieve group for deletion")
+			return err
+		}
+		if err := s.store.Groups.Delete(ctx, name); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "group.delete", "group", name, before, nil)
+	})
+	if err != nil {
+		logger.Error("Group deletion failed")
+		return err
+	}
+	logger.Info("Group deleted successfully")
+	return nil
+}
+
+// PrincipalEntity places the user in the groups and roles carried by their token,
+// so policies can be scoped to Group::"platform-admins" or Role::"approver".
+// Authorize adds the memberships recorded in the user directory.
+func PrincipalEntity(principal *models.Principal) models.Entity {
+	entity := models.Entity{UID: utils.EntityUID("User", principal.Subject)}
+	for _, group := range principal.Groups {
+		entity.Parents = append(entity.Parents, utils.EntityUID("Group", group))
+	}
+	for _, role := range principal.Roles {
+		entity.Parents = append(entity.Parents, utils.EntityUID("Role", role))
+	}
+	return entity
+}
+
+// ResolvePrincipal adds the directory memberships of a User entity to its parents:
+// the user's groups and roles, every ancestor of those groups, and the roles the
+// groups grant. Cedar's `in` then matches the principal against any of them.
+// Principals that are not in the directory keep the groups and roles of their token.
+func (s *Service) ResolvePrincipal(ctx context.Context, principal models.Entity) (models.Entity, error) {
+	logger := utils.GetLogger()
+
+	entityType, id, err := utils.ParseEntityUID(principal.UID)
+	if err != nil || entityType != "User" {
+		return principal, nil
+	}
+
+	parents := map[string]bool{}
+	resolved := models.Entity{UID: principal.UID}
+	addParent := func(uid string) {
+		if !parents[uid] {
+			parents[uid] = true
+			resolved.Parents = append(resolved.Parents, uid)
+		}
+	}
+
+	var pending []string
+	for _, uid := range principal.Parents {
+		addParent(uid)
+		if t, name, err := utils.ParseEntityUID(uid); err == nil && t == "Group" {
+			pending = append(pending, name)
+		}
+	}
+
+	user, err := s.store.Users.GetByUsername(ctx, id)
+	switch {
+	case err == nil:
+		pending = append(pending, user.Groups...)
+		for _, role := range user.Roles {
+			addParent(utils.EntityUID("Role", role))
+		}
+	case isNotFound(err):
+		logger.Debugf("Principal %s is not in the directory", principal.UID)
+	default:
+		logger.Error("Failed to look up principal in the directory")
+		return models.Entity{}, err
+	}
+
+	// Walk up the group hierarchy; visited groups are skipped so cycles end
+	visited := map[string]bool{}
+	for len(pending) > 0 {
+		var batch []string
+		for _, name := range pending {
+			if !visited[name] {
+				visited[name] = true
+				addParent(utils.EntityUID("Group", name))
+				batch = append(batch, name)
+			}
+		}
+		pending = nil
+		if len(batch) == 0 {
+			break
+		}
+
+		groups, err := s.store.Groups.GetByNames(ctx, batch)
+		if err != nil {
+			logger.Error("Failed to look up principal groups in the directory")
+			return models.Entity{}, err
+		}
+		for _, group := range groups {
+			for _, role := range group.Roles {
+				addParent(utils.EntityUID("Role", role))
+			}
+			pending = append(pending, group.Parents...)
+		}
+	}
+	return resolved, nil
+}
+
+func (s *Service) checkGroupsExist(ctx context.Context, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	groups, err := s.store.Groups.GetByNames(ctx, names)
+	if err != nil {
+		return err
+	}
+	known := map[string]bool{}
+	for _, group := range groups {
+		known[group.Name] = true
+	}
+	for _, name := range names {
+		if !known[name] {
+			return utils.NewInvalidInputError(fmt.Sprintf("Unknown group %q", name))
+		}
+	}
+	return nil
+}
+
+func (s *Service) checkUsersExist(ctx context.Context, usernames []string) error {
+	if len(usernames) == 0 {
+		return nil
+	}
+	users, err := s.store.Users.GetByUsernames(ctx, usernames)
+	if err != nil {
+		return err
+	}
+	known := map[string]bool{}
+	for _, user := range users {
+		known[user.Username] = true
+	}
+	for _, username := range usernames {
+		if !known[username] {
+			return utils.NewInvalidInputError(fmt.Sprintf("Unknown user %q", username))
+		}
+	}
+	return nil
+}
+
+// checkGroupParents makes sure the parents exist and that none of them is the
+// group itself or one of its descendants
+func (s *Service) checkGroupParents(ctx context.Context, name string, parents []string) error {
+	if err := s.checkGroupsExist(ctx, parents); err != nil {
+		return err
+	}
+
+	pending := parents
+	visited := map[string]bool{}
+	for len(pending) > 0 {
+		var batch []string
+		for _, parent := range pending {
+			if parent == name {
+				return utils.NewInvalidInputError(fmt.Sprintf("Group %q cannot be its own ancestor", name))
+			}
+			if !visited[parent] {
+				visited[parent] = true
+				batch = append(batch, parent)
+			}
+		}
+		pending = nil
+		if len(batch) == 0 {
+			break
+		}
+
+		groups, err := s.store.Groups.GetByNames(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for _, group := range groups {
+			pending = append(pending, group.Parents...)
+		}
+	}
+	return nil
+}
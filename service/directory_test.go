@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"kafka-governance/models"
+)
+
+func TestResolvePrincipal(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	// payments-eu sits under payments, which sits under engineering; ring-a and
+	// ring-b are parents of each other, which the API refuses but a store may hold
+	for _, group := range []*models.Group{
+		{Name: "engineering", Roles: []string{"reader"}},
+		{Name: "payments", Parents: []string{"engineering"}, Roles: []string{"approver"}},
+		{Name: "payments-eu", Parents: []string{"payments"}},
+		{Name: "ring-a", Parents: []string{"ring-b"}, Roles: []string{"ring"}},
+		{Name: "ring-b", Parents: []string{"ring-a"}},
+	} {
+		if _, err := svc.store.Groups.Insert(ctx, group); err != nil {
+			t.Fatalf("inserting group: %v", err)
+		}
+	}
+	for _, user := range []*models.User{
+		{Username: "alice", Groups: []string{"payments-eu"}, Roles: []string{"oncall"}},
+		{Username: "carol", Groups: []string{"ring-a"}},
+	} {
+		if _, err := svc.store.Users.Insert(ctx, user); err != nil {
+			t.Fatalf("inserting user: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		principal models.Entity
+		want      []string
+	}{
+		{
+			name:      "nested group ancestors and roles granted through groups",
+			principal: models.Entity{UID: `User::"alice"`},
+			want: []string{`Role::"oncall"`, `Group::"payments-eu"`, `Group::"payments"`,
+				`Role::"approver"`, `Group::"engineering"`, `Role::"reader"`},
+		},
+		{
+			name:      "token memberships are kept next to the directory",
+			principal: models.Entity{UID: `User::"alice"`, Parents: []string{`Group::"sre"`, `Role::"admin"`}},
+			want: []string{`Group::"sre"`, `Role::"admin"`, `Role::"oncall"`, `Group::"payments-eu"`,
+				`Group::"payments"`, `Role::"approver"`, `Group::"engineering"`, `Role::"reader"`},
+		},
+		{
+			name:      "group cycle terminates",
+			principal: models.Entity{UID: `User::"carol"`},
+			want:      []string{`Group::"ring-a"`, `Role::"ring"`, `Group::"ring-b"`},
+		},
+		{
+			name:      "user outside the directory keeps the token memberships",
+			principal: models.Entity{UID: `User::"dave"`, Parents: []string{`Group::"sre"`, `Role::"admin"`}},
+			want:      []string{`Group::"sre"`, `Role::"admin"`},
+		},
+		{
+			name:      "token groups known to the directory are expanded",
+			principal: models.Entity{UID: `User::"erin"`, Parents: []string{`Group::"payments"`}},
+			want:      []string{`Group::"payments"`, `Role::"approver"`, `Group::"engineering"`, `Role::"reader"`},
+		},
+		{
+			name:      "other entity types are left alone",
+			principal: models.Entity{UID: `Service::"billing"`, Parents: []string{`Group::"payments"`}},
+			want:      []string{`Group::"payments"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := svc.ResolvePrincipal(ctx, tt.principal)
+			if err != nil {
+				t.Fatalf("ResolvePrincipal: %v", err)
+			}
+			if resolved.UID != tt.principal.UID {
+				t.Errorf("uid = %s, want %s", resolved.UID, tt.principal.UID)
+			}
+			got := slices.Clone(resolved.Parents)
+			want := slices.Clone(tt.want)
+			slices.Sort(got)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Errorf("parents = %v, want %v", resolved.Parents, tt.want)
+			}
+		})
+	}
+}
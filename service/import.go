@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+// ImportTopics brings the topics that already exist on a cluster under governance.
+// Imported topics are ACTIVE with no owner so teams can claim them later. Topics
+// that are already governed are skipped, so the import can be run repeatedly.
+func (s *Service) ImportTopics(ctx context.Context, cluster, actor string) (*models.ImportResult, error) {
+	logger := utils.GetLogger()
+	logger.Infof("Importing existing topics from cluster %s", cluster)
+	ctx = utils.WithActor(ctx, actor)
+
+	if s.adminConnector == nil {
+		logger.Error("No Kafka admin client configured")
+		return nil, errors.New("kafka admin is not configured")
+	}
+
+	admin, err := s.adminForCluster(ctx, cluster)
+	if err != nil {
+		logger.Error("Failed to connect to cluster for import")
+		return nil, err
+	}
+
+	live, err := admin.ListTopics(ctx)
+	if err != nil {
+		logger.Error("Failed to fetch live topic metadata")
+		return nil, err
+	}
+
+	result := &models.ImportResult{
+		Cluster:  cluster,
+		Imported: []string{},
+		Skipped:  []models.ImportSkip{},
+	}
+	for _, meta := range live {
+		now := time.Now()
+		topic := &models.Topic{
+			Name:       meta.Name,
+			Cluster:    cluster,
+			Partitions: meta.Partitions,
+			Replicas:   meta.Replicas,
+			Configs:    meta.Configs,
+			Status:     models.TopicActive,
+			Revision:   1,
+			Imported:   true,
+			CreatedAt:  now,
+			Transitions: []models.TopicTransition{{
+				To:     models.TopicActive,
+				Actor:  actor,
+				Reason: "Imported from cluster " + cluster,
+				At:     now,
+			}},
+		}
+
+		var existing *models.Topic
+		err := s.inTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			if existing, err = s.store.Topics.Import(ctx, topic); err != nil || existing != nil {
+				return err
+			}
+			if err := s.recordAudit(ctx, "topic.import", "topic", topicResource(cluster, meta.Name), nil, topic); err != nil {
+				return err
+			}
+			return s.recordRevision(ctx, "topic.import", topic)
+		})
+		if err != nil {
+			logger.Errorf("Failed to import topic %s", meta.Name)
+			return nil, err
+		}
+		if existing == nil {
+			result.Imported = append(result.Imported, meta.Name)
+			continue
+		}
+
+		result.Skipped = append(result.Skipped, models.ImportSkip{Topic: meta.Name, Reason: "already governed"})
+	}
+
+	logger.Infof("Import of cluster %s finished, imported: %d, skipped: %d", cluster, len(result.Imported), len(result.Skipped))
+	return result, nil
+}
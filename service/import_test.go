@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"kafka-governance/kafkaadmin"
+	"kafka-governance/models"
+)
+
+func TestImportTopicsIsIdempotent(t *testing.T) {
+	t.Parallel()
+	svc, broker := newTestService(t)
+	ctx := context.Background()
+
+	broker.PutTopic(kafkaadmin.TopicSpec{Name: "legacy", Partitions: 6, Replicas: 3, Configs: map[string]string{"retention.ms": "1000"}})
+	broker.PutTopic(kafkaadmin.TopicSpec{Name: "orders", Partitions: 3, Replicas: 1})
+	if _, err := svc.store.Topics.Create(ctx, &models.Topic{Name: "orders", Cluster: "dev", Status: models.TopicActive,
+		Partitions: 3, Replicas: 1, Revision: 1}); err != nil {
+		t.Fatalf("creating topic: %v", err)
+	}
+
+	first, err := svc.ImportTopics(ctx, "dev", "system:import")
+	if err != nil {
+		t.Fatalf("ImportTopics: %v", err)
+	}
+	if !reflect.DeepEqual(first.Imported, []string{"legacy"}) ||
+		!reflect.DeepEqual(first.Skipped, []models.ImportSkip{{Topic: "orders", Reason: "already governed"}}) {
+		t.Fatalf("first import = %+v", first)
+	}
+	legacy := getTopic(t, svc, "legacy")
+	if legacy.Status != models.TopicActive || !legacy.Imported || legacy.Partitions != 6 || legacy.Configs["retention.ms"] != "1000" {
+		t.Errorf("imported topic = %+v", legacy)
+	}
+	events, revisions := countImportRecords(t, svc, legacy.ID)
+	if events != 1 || revisions != 1 {
+		t.Fatalf("first import wrote %d audit events and %d revisions, want 1 and 1", events, revisions)
+	}
+
+	second, err := svc.ImportTopics(ctx, "dev", "system:import")
+	if err != nil {
+		t.Fatalf("second ImportTopics: %v", err)
+	}
+	if len(second.Imported) != 0 || len(second.Skipped) != 2 {
+		t.Fatalf("second import = %+v, want every topic skipped", second)
+	}
+	for _, skip := range second.Skipped {
+		if skip.Reason != "already governed" {
+			t.Errorf("%s skipped with %q", skip.Topic, skip.Reason)
+		}
+	}
+	if events, revisions := countImportRecords(t, svc, legacy.ID); events != 1 || revisions != 1 {
+		t.Errorf("after the second import: %d audit events and %d revisions, want no new ones", events, revisions)
+	}
+	if again := getTopic(t, svc, "legacy"); again.Revision != legacy.Revision || len(again.Transitions) != 1 {
+		t.Errorf("second import changed the topic: revision %d, transitions %+v", again.Revision, again.Transitions)
+	}
+}
+
+// countImportRecords counts the topic.import audit events and the revisions of topicID
+func countImportRecords(t *testing.T, svc *Service, topicID string) (int, int) {
+	t.Helper()
+	ctx := context.Background()
+
+	events := 0
+	if err := svc.store.Audit.Walk(ctx, 1, 0, func(event models.AuditEvent) bool {
+		if event.Action == "topic.import" {
+			events++
+		}
+		return true
+	}); err != nil {
+		t.Fatalf("walking audit: %v", err)
+	}
+	revisions, err := svc.store.Revisions.List(ctx, topicID, 0, maxRevisionPageSize)
+	if err != nil {
+		t.Fatalf("listing revisions: %v", err)
+	}
+	return events, len(revisions)
+}
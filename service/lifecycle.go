@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+// topicTransitions lists the statuses each topic status may move to
+var topicTransitions = map[models.TopicStatus][]models.TopicStatus{
+	models.TopicPending:      {models.TopicApproved, models.TopicRejected},
+	models.TopicApproved:     {models.TopicProvisioning},
+	models.TopicProvisioning: {models.TopicActive, models.TopicFailed},
+	models.TopicFailed:       {models.TopicProvisioning},
+	models.TopicActive:       {models.TopicDeprecated},
+	models.TopicDeprecated:   {models.TopicDeleted, models.TopicActive},
+}
+
+// CanTransition reports whether a topic may move from one status to another
+func CanTransition(from, to models.TopicStatus) bool {
+	for _, next := range topicTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// newTransition validates a status change for the topic and builds its history entry
+func newTransition(topic *models.Topic, to models.TopicStatus, actor, reason string) (models.TopicTransition, error) {
+	if actor == "" {
+		return models.TopicTransition{}, utils.NewInvalidInputError("actor is required for a status change")
+	}
+	if reason == "" {
+		return models.TopicTransition{}, utils.NewInvalidInputError("reason is required for a status change")
+	}
+	if !CanTransition(topic.Status, to) {
+		return models.TopicTransition{}, utils.NewConflictError(fmt.Sprintf("topic is %s, cannot move to %s", topic.Status, to))
+	}
+
+	return models.TopicTransition{
+		From:   topic.Status,
+		To:     to,
+		Actor:  actor,
+		Reason: reason,
+		At:     time.Now(),
+	}, nil
+}
+
+// TransitionTopic moves a topic to the given status if the lifecycle allows it
+func (s *Service) TransitionTopic(ctx context.Context, cluster, name string, to models.TopicStatus, actor, reason string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Infof("Processing topic status change to %s", to)
+
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		logger.Error("Failed to retrieve topic for status change")
+		return nil, err
+	}
+
+	transition, err := newTransition(topic, to, actor, reason)
+	if err != nil {
+		logger.Errorf("Topic status change rejected: %s", err.Error())
+		return nil, err
+	}
+
+	var updated *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if updated, err = s.store.Topics.Transition(ctx, cluster, name, transition); err != nil {
+			return err
+		}
+		if err := s.recordAudit(ctx, transitionAction(to), "topic", topicResource(cluster, name), topic, updated); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, transitionAction(to), updated)
+	})
+	if err != nil {
+		logger.Error("Topic status change failed")
+		return nil, err
+	}
+	logger.Infof("Topic moved from %s to %s", transition.From, transition.To)
+	return updated, nil
+}
+
+// transitionAction names the audit action of a status change, e.g. topic.rejected
+func transitionAction(to models.TopicStatus) string {
+	return "topic." + strings.ToLower(string(to))
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"kafka-governance/models"
+)
+
+// waitForTopic polls the stored topic until done accepts it; approvals provision
+// and apply changes in the background
+func waitForTopic(t *testing.T, svc *Service, name string, done func(*models.Topic) bool) *models.Topic {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		topic := getTopic(t, svc, name)
+		if done(topic) {
+			return topic
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("topic is %s with change %+v, gave up waiting", topic.Status, topic.Change)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTopicLifecycle(t *testing.T) {
+	t.Parallel()
+	svc, broker := newTestService(t)
+	ctx := context.Background()
+
+	if _, err := svc.store.Groups.Insert(ctx, &models.Group{Name: "platform"}); err != nil {
+		t.Fatalf("inserting group: %v", err)
+	}
+	if _, err := svc.CreateWorkflow(ctx, models.ApprovalWorkflow{Name: "dev-signoff", Cluster: "dev",
+		Stages: []models.ApprovalStage{{Name: "platform", Group: "platform", MinApprovals: 1}}}); err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	alice := &models.Principal{Subject: "alice"}
+	bob := &models.Principal{Subject: "bob", Groups: []string{"platform"}}
+	mallory := &models.Principal{Subject: "mallory"}
+
+	// Create
+	created, err := svc.CreateTopic(ctx, &models.Topic{Name: "orders", Cluster: "dev", Partitions: 3, Replicas: 1,
+		Configs: models.TopicConfigs{"retention.ms": "86400000"}, RequestedBy: "alice"})
+	if err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	if created.Status != models.TopicPending || created.Approval == nil {
+		t.Fatalf("created topic is %s with approval %+v", created.Status, created.Approval)
+	}
+
+	// Approve: the requester and non-members of the stage group are refused
+	if _, err := svc.ApproveTopic(ctx, "dev", "orders", alice, ""); err == nil {
+		t.Error("the requester approved their own topic")
+	}
+	if _, err := svc.ApproveTopic(ctx, "dev", "orders", mallory, ""); err == nil {
+		t.Error("a user outside the platform group approved the topic")
+	}
+	if _, err := svc.ApproveTopic(ctx, "dev", "orders", bob, "Looks good"); err != nil {
+		t.Fatalf("ApproveTopic: %v", err)
+	}
+
+	// Provision
+	active := waitForTopic(t, svc, "orders", func(topic *models.Topic) bool { return topic.Status == models.TopicActive })
+	if spec, ok := broker.Topic("orders"); !ok || spec.Partitions != 3 || spec.Configs["retention.ms"] != "86400000" {
+		t.Fatalf("broker topic = %+v, present %v", spec, ok)
+	}
+
+	// Change
+	partitions, retention := 6, "3600000"
+	if _, err := svc.RequestTopicChange(ctx, "dev", "orders", models.TopicChange{Partitions: &partitions,
+		Configs: map[string]*string{"retention.ms": &retention}, RequestedBy: "alice", Reason: "More consumers"}); err != nil {
+		t.Fatalf("RequestTopicChange: %v", err)
+	}
+	if _, err := svc.RequestTopicDeletion(ctx, "dev", "orders", "alice", ""); err == nil {
+		t.Error("a deletion was requested while a change was open")
+	}
+	if _, err := svc.ApproveTopicChange(ctx, "dev", "orders", bob, ""); err != nil {
+		t.Fatalf("ApproveTopicChange: %v", err)
+	}
+	changed := waitForTopic(t, svc, "orders", func(topic *models.Topic) bool { return topic.Change == nil })
+	if changed.Partitions != 6 || changed.Configs["retention.ms"] != retention || changed.Revision <= active.Revision {
+		t.Errorf("changed topic has %d partitions, configs %v, revision %d", changed.Partitions, changed.Configs, changed.Revision)
+	}
+	if spec, _ := broker.Topic("orders"); spec.Partitions != 6 || spec.Configs["retention.ms"] != retention {
+		t.Errorf("broker topic after the change = %+v", spec)
+	}
+
+	// Delete: approval deprecates the topic, the provisioner removes it after the grace period
+	if _, err := svc.RequestTopicDeletion(ctx, "dev", "orders", "alice", "Replaced by orders-v2"); err != nil {
+		t.Fatalf("RequestTopicDeletion: %v", err)
+	}
+	deprecated, err := svc.ApproveTopicChange(ctx, "dev", "orders", bob, "")
+	if err != nil {
+		t.Fatalf("approving the deletion: %v", err)
+	}
+	if deprecated.Status != models.TopicDeprecated {
+		t.Fatalf("topic is %s after the deletion was approved, want DEPRECATED", deprecated.Status)
+	}
+	svc.RetryProvisioning(ctx)
+	deleted := getTopic(t, svc, "orders")
+	if deleted.Status != models.TopicDeleted {
+		t.Fatalf("topic is %s after the grace period, want DELETED", deleted.Status)
+	}
+	if _, ok := broker.Topic("orders"); ok {
+		t.Error("topic is still on the broker")
+	}
+
+	var statuses []models.TopicStatus
+	for _, transition := range deleted.Transitions {
+		statuses = append(statuses, transition.To)
+	}
+	want := []models.TopicStatus{models.TopicPending, models.TopicApproved, models.TopicProvisioning,
+		models.TopicActive, models.TopicDeprecated, models.TopicDeleted}
+	if !slices.Equal(statuses, want) {
+		t.Errorf("transitions = %v, want %v", statuses, want)
+	}
+
+	if result, err := svc.VerifyAuditChain(ctx); err != nil || !result.Valid {
+		t.Errorf("VerifyAuditChain = %+v, %v", result, err)
+	}
+}
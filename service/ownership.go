@@ -0,0 +1,436 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/google/uuid"
+)
+
+// 403 and 409 reason codes of ownership transfers
+const (
+	ReasonNotReceivingTeam = "NOT_RECEIVING_TEAM"
+	ReasonTransferPending  = "TRANSFER_PENDING"
+)
+
+const (
+	maxTopicOwners  = 20
+	maxOnCallLength = 256
+)
+
+// ValidateTopicOwnership checks the owner team, owners and on-call contact of a
+// topic request. The team must be a directory group so its members can be
+// resolved; owners must be directory users and are trimmed and deduplicated in
+// place.
+func (s *Service) ValidateTopicOwnership(ctx context.Context, topic *models.Topic) error {
+	if topic.OwnerTeam == "" {
+		return utils.NewInvalidInputError("Owner team is required")
+	}
+	if err := s.checkOwnerTeam(ctx, topic.OwnerTeam); err != nil {
+		return err
+	}
+	owners, err := s.normalizeOwners(ctx, topic.Owners)
+	if err != nil {
+		return err
+	}
+	topic.Owners = owners
+	if topic.OnCall == "" {
+		return utils.NewInvalidInputError("On-call contact is required")
+	}
+	return validateOnCall(topic.OnCall)
+}
+
+func (s *Service) checkOwnerTeam(ctx context.Context, team string) error {
+	if _, err := s.store.Groups.GetByName(ctx, team); err != nil {
+		if isNotFound(err) {
+			return utils.NewInvalidInputError("Unknown owner team: " + team)
+		}
+		utils.GetLogger().Error("Failed to look up owner team")
+		return err
+	}
+	return nil
+}
+
+// normalizeOwners trims and deduplicates owner usernames and makes sure the
+// directory knows each of them
+func (s *Service) normalizeOwners(ctx context.Context, owners []string) ([]string, error) {
+	var normalized []string
+	for _, owner := range owners {
+		owner = strings.TrimSpace(owner)
+		if owner == "" {
+			return nil, utils.NewInvalidInputError("Owners must not be empty")
+		}
+		if !slices.Contains(normalized, owner) {
+			normalized = append(normalized, owner)
+		}
+	}
+	if len(normalized) > maxTopicOwners {
+		return nil, utils.NewInvalidInputError(fmt.Sprintf("A topic may have at most %d owners", maxTopicOwners))
+	}
+	if err := s.checkUsersExist(ctx, normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// validateOnCall accepts any single-line contact, e.g. a pager alias, an email
+// address or a channel. An empty contact is left to the caller.
+func validateOnCall(onCall string) error {
+	if len(onCall) > maxOnCallLength {
+		return utils.NewInvalidInputError(fmt.Sprintf("On-call contact must be at most %d characters", maxOnCallLength))
+	}
+	if strings.IndexFunc(onCall, unicode.IsControl) >= 0 {
+		return utils.NewInvalidInputError("On-call contact must be a single line")
+	}
+	return nil
+}
+
+// IsTopicOwner reports whether the principal owns the topic: they are one of its
+// owners or belong to its owner team. Topics from before team ownership, which
+// have neither, are owned by whoever requested them.
+func (s *Service) IsTopicOwner(ctx context.Context, topic *models.Topic, principal *models.Principal) (bool, error) {
+	if slices.Contains(topic.Owners, principal.Subject) {
+		return true, nil
+	}
+	if topic.OwnerTeam == "" {
+		return len(topic.Owners) == 0 && principal.Subject == topic.RequestedBy, nil
+	}
+	return s.inGroup(ctx, principal, topic.OwnerTeam)
+}
+
+// UpdateTopicOwnership replaces the owners and on-call contact of a topic
+// within its owner team. Moving the topic to another team takes a transfer.
+func (s *Service) UpdateTopicOwnership(ctx context.Context, cluster, name string, owners []string, onCall string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Updating topic ownership")
+
+	owners, err := s.normalizeOwners(ctx, owners)
+	if err != nil {
+		return nil, err
+	}
+	if onCall == "" {
+		return nil, utils.NewInvalidInputError("On-call contact is required")
+	}
+	if err := validateOnCall(onCall); err != nil {
+		return nil, err
+	}
+
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		logger.Error("Failed to retrieve topic for ownership update")
+		return nil, err
+	}
+	if topic.Status == models.TopicDeleted {
+		return nil, utils.NewConflictError("topic is DELETED, its ownership cannot be changed")
+	}
+
+	var updated *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if updated, err = s.store.Topics.UpdateOwnership(ctx, cluster, name, owners, onCall, time.Now()); err != nil {
+			return err
+		}
+		if updated == nil {
+			return utils.NewNotFoundError("topic not found")
+		}
+		if err := s.recordAudit(ctx, "topic.ownership_update", "topic", topicResource(cluster, name),
+			map[string]interface{}{"owners": topic.Owners, "onCall": topic.OnCall},
+			map[string]interface{}{"owners": updated.Owners, "onCall": updated.OnCall},
+		); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.ownership_update", updated)
+	})
+	if err != nil {
+		logger.Error("Failed to store topic ownership")
+		return nil, err
+	}
+	logger.Info("Topic ownership updated successfully")
+	return updated, nil
+}
+
+// RequestOwnershipTransfer asks another team to take the topic over. Nothing
+// changes until a member of that team accepts.
+func (s *Service) RequestOwnershipTransfer(ctx context.Context, cluster, name string, transfer models.OwnershipTransfer) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Processing topic ownership transfer request")
+
+	if transfer.ToTeam == "" {
+		return nil, utils.NewInvalidInputError("Receiving team is required")
+	}
+	owners, err := s.normalizeOwners(ctx, transfer.Owners)
+	if err != nil {
+		return nil, err
+	}
+	transfer.Owners = owners
+	if err := validateOnCall(transfer.OnCall); err != nil {
+		return nil, err
+	}
+
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		logger.Error("Failed to retrieve topic for ownership transfer")
+		return nil, err
+	}
+	if topic.Status == models.TopicDeleted || topic.Status == models.TopicRejected {
+		return nil, utils.NewConflictError("topic is " + string(topic.Status) + ", its ownership cannot be transferred")
+	}
+	if topic.Transfer != nil {
+		return nil, utils.NewConflictReason(
+			ReasonTransferPending,
+			"Topic already has an open ownership transfer to "+topic.Transfer.ToTeam,
+			map[string]interface{}{"topic": topic.Name, "transfer": topic.Transfer.ID, "toTeam": topic.Transfer.ToTeam},
+		)
+	}
+	if transfer.ToTeam == topic.OwnerTeam {
+		return nil, utils.NewInvalidInputError("Topic is already owned by " + transfer.ToTeam)
+	}
+	if err := s.checkOwnerTeam(ctx, transfer.ToTeam); err != nil {
+		return nil, err
+	}
+
+	transfer.ID = uuid.New().String()
+	transfer.FromTeam = topic.OwnerTeam
+	transfer.RequestedAt = time.Now()
+	var requested *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if requested, err = s.store.Topics.RequestTransfer(ctx, cluster, name, &transfer); err != nil {
+			return err
+		}
+		if requested == nil {
+			logger.Error("Topic ownership changed while requesting transfer")
+			return utils.NewConflictError("topic ownership changed, retry the request")
+		}
+		if err := s.recordAudit(ctx, "topic.transfer_request", "topic", topicResource(cluster, name), nil, requested.Transfer); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.transfer_request", requested)
+	})
+	if err != nil {
+		logger.Error("Failed to store topic ownership transfer")
+		return nil, err
+	}
+	logger.Info("Topic ownership transfer requested successfully")
+	return requested, nil
+}
+
+// AcceptOwnershipTransfer hands the topic to the receiving team. Only its
+// members may accept; owners and onCall override those of the request, and the
+// accepting member becomes the sole owner when neither names any.
+func (s *Service) AcceptOwnershipTransfer(ctx context.Context, cluster, name string, principal *models.Principal, owners []string, onCall string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Processing topic ownership transfer acceptance")
+
+	topic, transfer, err := s.openTransfer(ctx, cluster, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireRecipient(ctx, transfer, principal); err != nil {
+		return nil, err
+	}
+
+	if owners == nil {
+		owners = transfer.Owners
+	}
+	if owners, err = s.normalizeOwners(ctx, owners); err != nil {
+		return nil, err
+	}
+	if len(owners) == 0 {
+		owners = []string{principal.Subject}
+	}
+	if onCall == "" {
+		onCall = transfer.OnCall
+	}
+	if onCall == "" {
+		return nil, utils.NewInvalidInputError("On-call contact is required: the transfer request names none")
+	}
+	if err := validateOnCall(onCall); err != nil {
+		return nil, err
+	}
+
+	var accepted *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if accepted, err = s.store.Topics.AcceptTransfer(ctx, cluster, name, transfer.ID, transfer.ToTeam, owners, onCall, time.Now()); err != nil {
+			return err
+		}
+		if accepted == nil {
+			logger.Error("Transfer changed while accepting")
+			return utils.NewConflictError("transfer changed, retry the request")
+		}
+		if err := s.recordAudit(ctx, "topic.transfer_accept", "topic", topicResource(cluster, name),
+			map[string]interface{}{"ownerTeam": topic.OwnerTeam, "owners": topic.Owners, "onCall": topic.OnCall},
+			map[string]interface{}{"ownerTeam": accepted.OwnerTeam, "owners": accepted.Owners, "onCall": accepted.OnCall},
+		); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.transfer_accept", accepted)
+	})
+	if err != nil {
+		logger.Error("Failed to store topic ownership transfer")
+		return nil, err
+	}
+	logger.Infof("Topic ownership transferred to %s", transfer.ToTeam)
+	return accepted, nil
+}
+
+// DeclineOwnershipTransfer closes the open transfer of a topic. The receiving
+// team declines it; the caller checks that anyone else may withdraw it.
+func (s *Service) DeclineOwnershipTransfer(ctx context.Context, cluster, name, actor, reason, action string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Closing topic ownership transfer")
+
+	_, transfer, err := s.openTransfer(ctx, cluster, name)
+	if err != nil {
+		return nil, err
+	}
+	var closed *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if closed, err = s.store.Topics.CloseTransfer(ctx, cluster, name, transfer.ID); err != nil {
+			return err
+		}
+		if closed == nil {
+			logger.Error("Transfer changed while closing")
+			return utils.NewConflictError("transfer changed, retry the request")
+		}
+		if err := s.recordAudit(ctx, action, "topic", topicResource(cluster, name), transfer,
+			map[string]interface{}{"closedBy": actor, "reason": reason},
+		); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, action, closed)
+	})
+	if err != nil {
+		logger.Error("Failed to close topic ownership transfer")
+		return nil, err
+	}
+	logger.Info("Topic ownership transfer closed successfully")
+	return closed, nil
+}
+
+// IsTransferRecipient reports whether the principal belongs to the team the
+// topic's open transfer is addressed to
+func (s *Service) IsTransferRecipient(ctx context.Context, topic *models.Topic, principal *models.Principal) (bool, error) {
+	if topic.Transfer == nil {
+		return false, nil
+	}
+	return s.inGroup(ctx, principal, topic.Transfer.ToTeam)
+}
+
+func (s *Service) openTransfer(ctx context.Context, cluster, name string) (*models.Topic, *models.OwnershipTransfer, error) {
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		utils.GetLogger().Error("Failed to retrieve topic for ownership transfer")
+		return nil, nil, err
+	}
+	if topic.Transfer == nil {
+		return nil, nil, utils.NewNotFoundError("topic has no open ownership transfer")
+	}
+	return topic, topic.Transfer, nil
+}
+
+func (s *Service) requireRecipient(ctx context.Context, transfer *models.OwnershipTransfer, principal *models.Principal) error {
+	member, err := s.inGroup(ctx, principal, transfer.ToTeam)
+	if err != nil {
+		utils.GetLogger().Error("Failed to check receiving team membership")
+		return err
+	}
+	if !member {
+		return utils.NewForbiddenReason(ReasonNotReceivingTeam,
+			"Only members of "+transfer.ToTeam+" can accept the transfer",
+			map[string]interface{}{"toTeam": transfer.ToTeam},
+		)
+	}
+	return nil
+}
+
+// OrphanedTopics lists the topics not yet deleted that no team answers for: they
+// have no owner team, their team is gone from the directory, or no directory
+// user belongs to the team directly or through one of its member groups
+func (s *Service) OrphanedTopics(ctx context.Context, cluster string) ([]models.OrphanedTopic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Looking for orphaned topics")
+
+	groups, err := s.store.Groups.List(ctx)
+	if err != nil {
+		logger.Error("Failed to retrieve groups for orphan report")
+		return nil, err
+	}
+	users, err := s.store.Users.List(ctx, "")
+	if err != nil {
+		logger.Error("Failed to retrieve users for orphan report")
+		return nil, err
+	}
+
+	// A user counts for their groups and every ancestor of them
+	parents := map[string][]string{}
+	for _, group := range groups {
+		parents[group.Name] = group.Parents
+	}
+	staffed := map[string]bool{}
+	for _, user := range users {
+		pending := slices.Clone(user.Groups)
+		for len(pending) > 0 {
+			name := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			if !staffed[name] {
+				staffed[name] = true
+				pending = append(pending, parents[name]...)
+			}
+		}
+	}
+
+	// Only topics of unstaffed or unknown teams, or of none, come back from the store
+	filter := models.TopicFilter{Cluster: cluster}
+	for _, status := range models.TopicStatuses {
+		if status != models.TopicDeleted && status != models.TopicRejected {
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+	for team := range parents {
+		if staffed[team] {
+			filter.ExcludeOwnerTeams = append(filter.ExcludeOwnerTeams, team)
+		}
+	}
+	topics, err := s.store.Topics.ListMatching(ctx, filter)
+	if err != nil {
+		logger.Error("Failed to retrieve topics for orphan report")
+		return nil, err
+	}
+
+	orphaned := []models.OrphanedTopic{}
+	for _, topic := range topics {
+		var reason string
+		_, known := parents[topic.OwnerTeam]
+		switch {
+		case topic.OwnerTeam == "":
+			reason = models.OrphanNoOwnerTeam
+		case !known:
+			reason = models.OrphanTeamNotFound
+		case !staffed[topic.OwnerTeam]:
+			reason = models.OrphanNoActiveMembers
+		default:
+			continue
+		}
+		orphaned = append(orphaned, models.OrphanedTopic{
+			Cluster:   topic.Cluster,
+			Name:      topic.Name,
+			Status:    topic.Status,
+			OwnerTeam: topic.OwnerTeam,
+			Owners:    topic.Owners,
+			OnCall:    topic.OnCall,
+			Reason:    reason,
+		})
+	}
+	logger.Infof("Orphan report built, orphaned topics: %d", len(orphaned))
+	return orphaned, nil
+}
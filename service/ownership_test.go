@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"kafka-governance/models"
+)
+
+func TestOrphanedTopics(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	// payments is staffed through its child group, checkout has nobody
+	for _, group := range []*models.Group{
+		{Name: "payments"},
+		{Name: "payments-eu", Parents: []string{"payments"}},
+		{Name: "checkout"},
+	} {
+		if _, err := svc.store.Groups.Insert(ctx, group); err != nil {
+			t.Fatalf("inserting group: %v", err)
+		}
+	}
+	if _, err := svc.store.Users.Insert(ctx, &models.User{Username: "alice", Groups: []string{"payments-eu"}}); err != nil {
+		t.Fatalf("inserting user: %v", err)
+	}
+	for _, topic := range []*models.Topic{
+		{Name: "payments", Cluster: "dev", Status: models.TopicActive, OwnerTeam: "payments"},
+		{Name: "carts", Cluster: "dev", Status: models.TopicActive, OwnerTeam: "checkout"},
+		{Name: "legacy", Cluster: "dev", Status: models.TopicActive},
+		{Name: "shipping", Cluster: "dev", Status: models.TopicPending, OwnerTeam: "logistics"},
+		{Name: "gone", Cluster: "dev", Status: models.TopicDeleted, OwnerTeam: "checkout"},
+		{Name: "refused", Cluster: "dev", Status: models.TopicRejected},
+		{Name: "carts", Cluster: "prod", Status: models.TopicActive, OwnerTeam: "checkout"},
+	} {
+		if _, err := svc.store.Topics.Create(ctx, topic); err != nil {
+			t.Fatalf("creating topic: %v", err)
+		}
+	}
+
+	orphaned, err := svc.OrphanedTopics(ctx, "dev")
+	if err != nil {
+		t.Fatalf("OrphanedTopics: %v", err)
+	}
+	got := map[string]string{}
+	for _, topic := range orphaned {
+		got[topic.Cluster+"/"+topic.Name] = topic.Reason
+	}
+	want := map[string]string{
+		"dev/carts":    models.OrphanNoActiveMembers,
+		"dev/legacy":   models.OrphanNoOwnerTeam,
+		"dev/shipping": models.OrphanTeamNotFound,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("orphaned = %v, want %v", got, want)
+	}
+
+	if all, _ := svc.OrphanedTopics(ctx, ""); len(all) != 4 {
+		t.Errorf("orphaned on all clusters = %+v, want 4 topics", all)
+	}
+}
+
+func TestOwnersMustBeDirectoryUsers(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"payments", "checkout"} {
+		if _, err := svc.store.Groups.Insert(ctx, &models.Group{Name: name}); err != nil {
+			t.Fatalf("inserting group: %v", err)
+		}
+	}
+	if _, err := svc.store.Users.Insert(ctx, &models.User{Username: "alice", Groups: []string{"payments"}}); err != nil {
+		t.Fatalf("inserting user: %v", err)
+	}
+	if _, err := svc.store.Topics.Create(ctx, &models.Topic{Name: "orders", Cluster: "dev", Status: models.TopicActive,
+		OwnerTeam: "payments", Owners: []string{"alice"}, OnCall: "#payments"}); err != nil {
+		t.Fatalf("creating topic: %v", err)
+	}
+
+	request := &models.Topic{OwnerTeam: "payments", Owners: []string{"alice", "ghost"}, OnCall: "#payments"}
+	if err := svc.ValidateTopicOwnership(ctx, request); err == nil {
+		t.Error("a topic request named an unknown owner")
+	}
+	if _, err := svc.UpdateTopicOwnership(ctx, "dev", "orders", []string{"ghost"}, "#payments"); err == nil {
+		t.Error("ownership was given to an unknown user")
+	}
+	if _, err := svc.RequestOwnershipTransfer(ctx, "dev", "orders", models.OwnershipTransfer{ToTeam: "checkout", Owners: []string{"ghost"}}); err == nil {
+		t.Error("a transfer proposed an unknown owner")
+	}
+
+	updated, err := svc.UpdateTopicOwnership(ctx, "dev", "orders", []string{" alice ", "alice"}, "#payments-oncall")
+	if err != nil {
+		t.Fatalf("UpdateTopicOwnership: %v", err)
+	}
+	if !reflect.DeepEqual(updated.Owners, []string{"alice"}) {
+		t.Errorf("owners = %v, want [alice]", updated.Owners)
+	}
+}
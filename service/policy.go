@@ -2,26 +2,222 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
-	"kafka-governance/db"
 	"kafka-governance/models"
 	"kafka-governance/utils"
 )
 
-func CreatePolicy(
+const maxPolicyConditions = 16
+
+var (
+	// entityTypePattern matches Cedar entity types, optionally namespaced, e.g. Topic or Acme::Topic
+	entityTypePattern   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(::[A-Za-z_][A-Za-z0-9_]*)*$`)
+	conditionKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+)
+
+// ValidatePolicy checks that a policy can be both evaluated in-process and
+// rendered for cedar-agent: every scope is * or an entity reference such as
+// Group::"admins", the action scope names an Action, and condition keys are
+// identifiers. A policy that fails here would never match locally and would
+// stop the whole policy set from syncing to the agent.
+func ValidatePolicy(policy *models.Policy) error {
+	if policy.Effect != utils.EffectPermit && policy.Effect != utils.EffectForbid {
+		return utils.NewInvalidInputError("Effect must be either 'permit' or 'forbid'")
+	}
+	for _, scope := range []struct{ name, value string }{
+		{"Principal", policy.Principal},
+		{"Action", policy.Action},
+		{"Resource", policy.Resource},
+	} {
+		if scope.value == "" {
+			return utils.NewInvalidInputError(scope.name + " is required")
+		}
+		if scope.value == "*" {
+			continue
+		}
+		entityType, id, err := utils.ParseEntityUID(scope.value)
+		if err != nil || !entityTypePattern.MatchString(entityType) {
+			return utils.NewInvalidInputError(fmt.Sprintf(`%s must be * or an entity reference such as Type::"id"`, scope.name))
+		}
+		if scope.name == "Action" && entityType != "Action" {
+			return utils.NewInvalidInputError(`Action must be * or an Action::"name" reference`)
+		}
+		if scope.name == "Action" && id == "*" {
+			return utils.NewInvalidInputError(`Use * rather than Action::"*" for any action`)
+		}
+		if entityType == "Topic" && id != "*" && !strings.Contains(id, "/") {
+			return utils.NewInvalidInputError(`Topics are named with their cluster, e.g. Topic::"prod-eu/orders.created"; use a "topic" condition for a name on any cluster`)
+		}
+	}
+	if len(policy.Conditions) > maxPolicyConditions {
+		return utils.NewInvalidInputError(fmt.Sprintf("A policy may have at most %d conditions", maxPolicyConditions))
+	}
+	for key := range policy.Conditions {
+		if !conditionKeyPattern.MatchString(key) {
+			return utils.NewInvalidInputError("Invalid condition key " + key + ": use up to 64 letters, digits and _, not starting with a digit")
+		}
+	}
+	return nil
+}
+
+func (s *Service) CreatePolicy(
 	ctx context.Context,
 	policy models.Policy,
-) error {
+) (*models.Policy, error) {
 	logger := utils.GetLogger()
 	logger.Info("Creating new policy")
 
+	if err := ValidatePolicy(&policy); err != nil {
+		return nil, err
+	}
 	policy.CreatedAt = time.Now()
-	err := db.InsertPolicy(ctx, policy)
+	var created *models.Policy
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.store.Policies.Insert(ctx, &policy); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "policy.create", "policy", created.ID, nil, created)
+	})
 	if err != nil {
 		logger.Error("Policy creation failed")
-		return err
+		return nil, err
 	}
 	logger.Info("Policy created successfully")
+
+	s.syncPoliciesAfterChange(ctx)
+	return created, nil
+}
+
+func (s *Service) ListPolicies(ctx context.Context, filter models.PolicyFilter) ([]models.Policy, error) {
+	logger := utils.GetLogger()
+	logger.Info("Retrieving policies list")
+
+	policies, err := s.store.Policies.List(ctx, filter)
+	if err != nil {
+		logger.Error("Failed to retrieve policies list")
+		return nil, err
+	}
+	logger.Infof("Policies list retrieved successfully, count: %d", len(policies))
+	return policies, nil
+}
+
+func (s *Service) GetPolicy(ctx context.Context, id string) (*models.Policy, error) {
+	logger := utils.GetLogger()
+	logger.Info("Retrieving policy by id")
+
+	policy, err := s.store.Policies.GetByID(ctx, id)
+	if err != nil {
+		logger.Error("Failed to retrieve policy")
+		return nil, err
+	}
+	logger.Info("Policy retrieved successfully")
+	return policy, nil
+}
+
+func (s *Service) UpdatePolicy(ctx context.Context, id string, policy models.Policy) (*models.Policy, error) {
+	logger := utils.GetLogger()
+	logger.Info("Updating policy")
+
+	if err := ValidatePolicy(&policy); err != nil {
+		return nil, err
+	}
+	var updated *models.Policy
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.store.Policies.GetByID(ctx, id)
+		if err != nil {
+			logger.Error("Failed to retrieve policy for update")
+			return err
+		}
+		if updated, err = s.store.Policies.Update(ctx, id, &policy); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "policy.update", "policy", id, before, updated)
+	})
+	if err != nil {
+		logger.Error("Policy update failed")
+		return nil, err
+	}
+	logger.Info("Policy updated successfully")
+
+	s.syncPoliciesAfterChange(ctx)
+	return updated, nil
+}
+
+func (s *Service) DeletePolicy(ctx context.Context, id string) error {
+	logger := utils.GetLogger()
+	logger.Info("Deleting policy")
+
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.store.Policies.GetByID(ctx, id)
+		if err != nil {
+			logger.Error("Failed to retrieve policy for deletion")
+			return err
+		}
+		if err := s.store.Policies.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "policy.delete", "policy", id, before, nil)
+	})
+	if err != nil {
+		logger.Error("Policy deletion failed")
+		return err
+	}
+	logger.Info("Policy deleted successfully")
+
+	s.syncPoliciesAfterChange(ctx)
 	return nil
 }
+
+// EnsureAdminPolicy permits every action to principal unless a policy already
+// does. Managing policies takes a ManagePolicies permit, so the first
+// administrator is set up from the configuration rather than through the API.
+func (s *Service) EnsureAdminPolicy(ctx context.Context, principal string) error {
+	logger := utils.GetLogger()
+
+	if principal == "*" {
+		return utils.NewInvalidInputError("The bootstrap admin must be a user, group or role, not *")
+	}
+
+	existing, err := s.store.Policies.List(ctx, models.PolicyFilter{
+		Principal: principal,
+		Action:    "*",
+		Resource:  "*",
+		Effect:    utils.EffectPermit,
+	})
+	if err != nil {
+		logger.Error("Failed to look up the bootstrap admin policy")
+		return err
+	}
+	for _, policy := range existing {
+		if len(policy.Conditions) == 0 {
+			logger.Debugf("Bootstrap admin policy %s already exists", policy.ID)
+			return nil
+		}
+	}
+
+	created, err := s.CreatePolicy(utils.WithActor(ctx, systemActor+":bootstrap"), models.Policy{
+		Principal: principal,
+		Action:    "*",
+		Resource:  "*",
+		Effect:    utils.EffectPermit,
+	})
+	if err != nil {
+		return err
+	}
+	logger.Infof("Bootstrap admin policy %s created for %s", created.ID, principal)
+	return nil
+}
+
+// syncPoliciesAfterChange pushes the new policy set to an external authorizer.
+// The change is already stored; when the push fails the authorizer is marked out
+// of sync and RunPolicySync pushes again until it holds the stored policies.
+func (s *Service) syncPoliciesAfterChange(ctx context.Context) {
+	if err := s.SyncPolicies(ctx); err != nil {
+		utils.GetLogger().Error("Policy stored but authorizer sync failed, it will be retried")
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"kafka-governance/db"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+func TestValidatePolicyTopicScopes(t *testing.T) {
+	tests := []struct {
+		resource string
+		wantErr  bool
+	}{
+		{resource: `Topic::"prod-eu/orders.created"`},
+		{resource: `Topic::"*"`},
+		{resource: `Cluster::"prod-eu"`},
+		{resource: `Topic::"orders.created"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			policy := &models.Policy{Principal: "*", Action: "*", Resource: tt.resource, Effect: utils.EffectPermit}
+			err := ValidatePolicy(policy)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("ValidatePolicy: %v", err)
+				}
+				return
+			}
+			var apiErr *utils.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+				t.Errorf("ValidatePolicy = %v, want a 400", err)
+			}
+		})
+	}
+}
+
+func TestTopicPoliciesAreScopedToTheCluster(t *testing.T) {
+	t.Parallel()
+	svc := New(db.NewMemoryStore(), Config{})
+	ctx := context.Background()
+
+	for _, policy := range []models.Policy{
+		{Principal: `User::"alice"`, Action: `Action::"UpdateTopic"`, Resource: `Topic::"dev/orders"`, Effect: utils.EffectPermit},
+		{Principal: `User::"bob"`, Action: `Action::"UpdateTopic"`, Resource: `Topic::"*"`, Effect: utils.EffectPermit,
+			Conditions: map[string]string{"topic": "orders"}},
+	} {
+		if _, err := svc.CreatePolicy(ctx, policy); err != nil {
+			t.Fatalf("CreatePolicy: %v", err)
+		}
+	}
+
+	request := func(user, cluster, name string) models.AuthzRequest {
+		return models.AuthzRequest{
+			Principal: models.Entity{UID: utils.EntityUID("User", user)},
+			Action:    utils.EntityUID("Action", "UpdateTopic"),
+			Resource:  models.Entity{UID: TopicUID(cluster, name), Parents: []string{utils.EntityUID("Cluster", cluster)}},
+			Context:   map[string]string{"cluster": cluster, "topic": name},
+		}
+	}
+	tests := []struct {
+		user, cluster, name string
+		want                bool
+	}{
+		{"alice", "dev", "orders", true},
+		{"alice", "prod", "orders", false},
+		{"alice", "dev", "payments", false},
+		{"bob", "dev", "orders", true},
+		{"bob", "prod", "orders", true},
+		{"bob", "prod", "payments", false},
+	}
+	for _, tt := range tests {
+		decision, err := svc.Authorize(ctx, request(tt.user, tt.cluster, tt.name))
+		if err != nil {
+			t.Fatalf("Authorize: %v", err)
+		}
+		if decision.Allowed != tt.want {
+			t.Errorf("%s on %s/%s allowed = %t, want %t", tt.user, tt.cluster, tt.name, decision.Allowed, tt.want)
+		}
+	}
+}
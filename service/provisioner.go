@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"kafka-governance/kafkaadmin"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+// provisionerActor is recorded as the actor of transitions made by the provisioner
+const provisionerActor = "system:provisioner"
+
+const (
+	defaultProvisionMaxAttempts = 10
+	defaultProvisionBaseBackoff = 30 * time.Second
+	defaultProvisionTimeout     = 30 * time.Second
+)
+
+// adminForCluster returns an admin client for a registered cluster
+func (s *Service) adminForCluster(ctx context.Context, name string) (kafkaadmin.Admin, error) {
+	cluster, err := s.store.Clusters.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return s.adminConnector.Connect(cluster)
+}
+
+// errExistingTopicMismatch marks a topic that already exists on the cluster with
+// other settings than the governed ones; retrying cannot fix it
+var errExistingTopicMismatch = errors.New("topic already exists on the cluster with different settings")
+
+// ProvisionTopic creates an approved topic on its cluster. On success the topic
+// becomes ACTIVE; on failure the broker error is recorded and the topic stays
+// PROVISIONING so RetryProvisioning picks it up again. After provisionMaxAttempts
+// failures, or when the topic already exists with different settings, it moves
+// to FAILED until RetryTopicProvisioning is called.
+func (s *Service) ProvisionTopic(ctx context.Context, cluster, name string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Provisioning topic on Kafka cluster")
+
+	if s.adminConnector == nil {
+		logger.Error("No Kafka admin client configured")
+		return nil, errors.New("kafka provisioning is not configured")
+	}
+
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		logger.Error("Failed to retrieve topic for provisioning")
+		return nil, err
+	}
+
+	if topic.Status == models.TopicApproved {
+		topic, err = s.TransitionTopic(ctx, cluster, name, models.TopicProvisioning, provisionerActor, "Provisioning started")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if topic.Status != models.TopicProvisioning {
+		logger.Errorf("Topic is %s, not ready for provisioning", topic.Status)
+		return nil, utils.NewConflictError("topic is " + string(topic.Status) + ", cannot provision")
+	}
+
+	admin, err := s.adminForCluster(ctx, topic.Cluster)
+	if err == nil {
+		err = admin.CreateTopic(ctx, kafkaadmin.TopicSpec{
+			Name:       topic.Name,
+			Partitions: topic.Partitions,
+			Replicas:   topic.Replicas,
+			Configs:    topic.Configs,
+		})
+		if errors.Is(err, kafkaadmin.ErrTopicExists) {
+			// A previous attempt may have succeeded before we could record it, but
+			// the topic may as well have been created outside of governance
+			logger.Warn("Topic already exists on cluster, comparing its settings")
+			err = checkExistingTopic(ctx, admin, topic)
+		}
+	}
+	if err != nil {
+		logger.Errorf("Topic provisioning failed: %s", err.Error())
+		recordErr := s.inTransaction(ctx, func(ctx context.Context) error {
+			if err := s.store.Topics.RecordProvisionFailure(ctx, cluster, name, err.Error(), time.Now()); err != nil {
+				return err
+			}
+			return s.recordAudit(ctx, "topic.provision_failed", "topic", topicResource(cluster, name),
+				map[string]interface{}{"provisionError": topic.ProvisionError, "provisionAttempts": topic.ProvisionAttempts},
+				map[string]interface{}{"provisionError": err.Error(), "provisionAttempts": topic.ProvisionAttempts + 1},
+			)
+		})
+		if recordErr != nil {
+			logger.Error("Failed to record provisioning failure")
+			return nil, err
+		}
+		switch {
+		case errors.Is(err, errExistingTopicMismatch):
+			s.failProvisioning(ctx, cluster, name, err.Error())
+		case topic.ProvisionAttempts+1 >= s.provisionMaxAttempts:
+			s.failProvisioning(ctx, cluster, name, fmt.Sprintf("Provisioning failed %d times: %s", topic.ProvisionAttempts+1, err.Error()))
+		}
+		return nil, err
+	}
+
+	transition, err := newTransition(topic, models.TopicActive, provisionerActor, "Topic created on cluster")
+	if err != nil {
+		return nil, err
+	}
+	var active *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if active, err = s.store.Topics.Activate(ctx, cluster, name, transition); err != nil {
+			return err
+		}
+		if err := s.recordAudit(ctx, transitionAction(models.TopicActive), "topic", topicResource(cluster, name), topic, active); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, transitionAction(models.TopicActive), active)
+	})
+	if err != nil {
+		logger.Error("Failed to mark topic as active")
+		return nil, err
+	}
+	logger.Info("Topic provisioned successfully")
+	return active, nil
+}
+
+// checkExistingTopic compares a topic found on the cluster with the governed one.
+// Only a topic with the requested partitions, replicas and configs counts as provisioned.
+func checkExistingTopic(ctx context.Context, admin kafkaadmin.Admin, topic *models.Topic) error {
+	live, err := admin.ListTopics(ctx)
+	if err != nil {
+		return err
+	}
+	for _, meta := range live {
+		if meta.Name != topic.Name {
+			continue
+		}
+		if meta.Partitions != topic.Partitions || meta.Replicas != topic.Replicas {
+			return fmt.Errorf("%w: it has %d partitions and %d replicas, %d partitions and %d replicas were requested",
+				errExistingTopicMismatch, meta.Partitions, meta.Replicas, topic.Partitions, topic.Replicas)
+		}
+		for key, value := range topic.Configs {
+			if meta.Configs[key] != value {
+				return fmt.Errorf("%w: config %s is %q, %q was requested", errExistingTopicMismatch, key, meta.Configs[key], value)
+			}
+		}
+		return nil
+	}
+	// Gone again since CreateTopic: let the next attempt create it
+	return kafkaadmin.ErrTopicNotFound
+}
+
+// failProvisioning moves a topic the provisioner gave up on to FAILED
+func (s *Service) failProvisioning(ctx context.Context, cluster, name, reason string) {
+	logger := utils.GetLogger()
+	if _, err := s.TransitionTopic(ctx, cluster, name, models.TopicFailed, provisionerActor, reason); err != nil {
+		logger.Error("Failed to mark topic provisioning as failed")
+		return
+	}
+	logger.Warnf("Provisioning of topic %s failed: %s", name, reason)
+}
+
+// RetryTopicProvisioning moves a FAILED topic back to PROVISIONING with its
+// attempts cleared and starts provisioning it again
+func (s *Service) RetryTopicProvisioning(ctx context.Context, cluster, name, actor, reason string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Processing topic provisioning retry")
+
+	if s.adminConnector == nil {
+		logger.Error("No Kafka admin client configured")
+		return nil, utils.NewConflictError("kafka provisioning is not configured")
+	}
+	if reason == "" {
+		reason = "Provisioning retried"
+	}
+
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		logger.Error("Failed to retrieve topic for provisioning retry")
+		return nil, err
+	}
+	if topic.Status != models.TopicFailed {
+		logger.Errorf("Topic is %s, not FAILED", topic.Status)
+		return nil, utils.NewConflictError("topic is " + string(topic.Status) + ", only a FAILED topic can be retried")
+	}
+
+	transition, err := newTransition(topic, models.TopicProvisioning, actor, reason)
+	if err != nil {
+		return nil, err
+	}
+	var updated *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if updated, err = s.store.Topics.ResetProvisioning(ctx, cluster, name, transition); err != nil {
+			return err
+		}
+		if err := s.recordAudit(ctx, "topic.provision_retried", "topic", topicResource(cluster, name), topic, updated); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.provision_retried", updated)
+	})
+	if err != nil {
+		logger.Error("Failed to reset topic provisioning")
+		return nil, err
+	}
+	logger.Info("Topic provisioning reset")
+
+	s.provisionAfterApproval(cluster, name)
+	return updated, nil
+}
+
+// RetryProvisioning provisions approved topics and applies approved changes,
+// retrying failed attempts with exponential backoff until provisionMaxAttempts
+// is reached
+func (s *Service) RetryProvisioning(ctx context.Context) {
+	if s.adminConnector == nil {
+		return
+	}
+
+	ctx = utils.WithActor(ctx, provisionerActor)
+	s.retryTopics(ctx)
+	s.retryChanges(ctx)
+}
+
+func (s *Service) retryTopics(ctx context.Context) {
+	logger := utils.GetLogger()
+
+	topics, err := s.store.Topics.ListByStatus(ctx, models.TopicApproved, models.TopicProvisioning)
+	if err != nil {
+		logger.Error("Failed to list topics awaiting provisioning")
+		return
+	}
+
+	now := time.Now()
+	for _, topic := range topics {
+		if topic.Status == models.TopicProvisioning && topic.ProvisionAttempts >= s.provisionMaxAttempts {
+			// Left behind by an earlier run or a lower PROVISION_MAX_ATTEMPTS
+			s.failProvisioning(ctx, topic.Cluster, topic.Name, fmt.Sprintf("Provisioning failed %d times: %s", topic.ProvisionAttempts, topic.ProvisionError))
+			continue
+		}
+		if topic.LastProvisionAttemptAt != nil && now.Before(topic.LastProvisionAttemptAt.Add(s.provisionBackoff(topic.ProvisionAttempts))) {
+			continue
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, s.provisionTimeout)
+		if _, err := s.ProvisionTopic(attemptCtx, topic.Cluster, topic.Name); err != nil {
+			logger.Warnf("Provisioning attempt for topic %s failed", topic.Name)
+		}
+		cancel()
+	}
+}
+
+// RunProvisioner calls RetryProvisioning every interval until ctx is done
+func (s *Service) RunProvisioner(ctx context.Context, interval time.Duration) {
+	logger := utils.GetLogger()
+	logger.Infof("Topic provisioner started, interval: %s", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Topic provisioner stopped")
+			return
+		case <-ticker.C:
+			s.RetryProvisioning(ctx)
+		}
+	}
+}
+
+// provisionAfterApproval starts provisioning right away instead of waiting for the next run
+func (s *Service) provisionAfterApproval(cluster, name string) {
+	if s.adminConnector == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(utils.WithActor(context.Background(), provisionerActor), s.provisionTimeout)
+		defer cancel()
+		if _, err := s.ProvisionTopic(ctx, cluster, name); err != nil {
+			utils.GetLogger().Warn("Provisioning after approval failed, will retry")
+		}
+	}()
+}
+
+func (s *Service) provisionBackoff(attempts int) time.Duration {
+	backoff := s.provisionBaseBackoff
+	for i := 1; i < attempts && backoff < time.Hour; i++ {
+		backoff *= 2
+	}
+	return backoff
+}
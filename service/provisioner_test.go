@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"kafka-governance/db"
+	"kafka-governance/kafkaadmin"
+	"kafka-governance/models"
+)
+
+// newTestService returns a service on a memory store with a three broker "dev"
+// cluster and the fake broker behind it
+func newTestService(t *testing.T) (*Service, *kafkaadmin.MemoryAdmin) {
+	t.Helper()
+
+	connector := kafkaadmin.NewMemoryConnector()
+	svc := New(db.NewMemoryStore(), Config{AdminConnector: connector, ProvisionRetries: 3})
+	svc.provisionBaseBackoff = 0
+
+	cluster := &models.Cluster{Name: "dev", BootstrapServers: []string{"localhost:9092"}, Environment: models.EnvDev,
+		BrokerCount: 3, DefaultPartitions: 3, MaxPartitions: 50, DefaultReplicas: 1, MaxReplicas: 3}
+	if _, err := svc.store.Clusters.Insert(context.Background(), cluster); err != nil {
+		t.Fatalf("inserting cluster: %v", err)
+	}
+	return svc, connector.Cluster("dev", cluster.BrokerCount)
+}
+
+// approvedTopic stores an APPROVED topic on the "dev" cluster
+func approvedTopic(t *testing.T, svc *Service, name string, partitions, replicas int, configs map[string]string) {
+	t.Helper()
+	topic := &models.Topic{Name: name, Cluster: "dev", Status: models.TopicApproved,
+		Partitions: partitions, Replicas: replicas, Configs: configs, RequestedBy: "alice"}
+	if _, err := svc.store.Topics.Create(context.Background(), topic); err != nil {
+		t.Fatalf("creating topic: %v", err)
+	}
+}
+
+func getTopic(t *testing.T, svc *Service, name string) *models.Topic {
+	t.Helper()
+	topic, err := svc.store.Topics.Get(context.Background(), "dev", name)
+	if err != nil {
+		t.Fatalf("getting topic: %v", err)
+	}
+	return topic
+}
+
+func TestProvisionTopic(t *testing.T) {
+	t.Parallel()
+	svc, broker := newTestService(t)
+	approvedTopic(t, svc, "orders", 6, 3, map[string]string{"retention.ms": "86400000"})
+
+	active, err := svc.ProvisionTopic(context.Background(), "dev", "orders")
+	if err != nil {
+		t.Fatalf("ProvisionTopic: %v", err)
+	}
+	if active.Status != models.TopicActive || active.ProvisionedAt == nil {
+		t.Errorf("topic = %s, provisionedAt %v, want ACTIVE and provisioned", active.Status, active.ProvisionedAt)
+	}
+
+	spec, ok := broker.Topic("orders")
+	if !ok {
+		t.Fatal("topic was not created on the broker")
+	}
+	if spec.Partitions != 6 || spec.Replicas != 3 || spec.Configs["retention.ms"] != "86400000" {
+		t.Errorf("broker topic = %+v", spec)
+	}
+
+	var statuses []models.TopicStatus
+	for _, transition := range active.Transitions {
+		statuses = append(statuses, transition.To)
+	}
+	if want := []models.TopicStatus{models.TopicProvisioning, models.TopicActive}; !slices.Equal(statuses, want) {
+		t.Errorf("transitions = %v, want %v", statuses, want)
+	}
+}
+
+func TestProvisionTopicRetriesFailures(t *testing.T) {
+	t.Parallel()
+	svc, broker := newTestService(t)
+	approvedTopic(t, svc, "orders", 3, 1, nil)
+	broker.FailNext(1, errors.New("broker not available"))
+
+	if _, err := svc.ProvisionTopic(context.Background(), "dev", "orders"); err == nil {
+		t.Fatal("ProvisionTopic succeeded against a failing broker")
+	}
+	topic := getTopic(t, svc, "orders")
+	if topic.Status != models.TopicProvisioning || topic.ProvisionAttempts != 1 || topic.ProvisionError != "broker not available" {
+		t.Fatalf("after failure: status %s, attempts %d, error %q", topic.Status, topic.ProvisionAttempts, topic.ProvisionError)
+	}
+
+	svc.RetryProvisioning(context.Background())
+
+	topic = getTopic(t, svc, "orders")
+	if topic.Status != models.TopicActive || topic.ProvisionError != "" {
+		t.Errorf("after retry: status %s, error %q, want ACTIVE without an error", topic.Status, topic.ProvisionError)
+	}
+}
+
+func TestProvisionTopicFailsAfterMaxAttempts(t *testing.T) {
+	t.Parallel()
+	svc, broker := newTestService(t)
+	approvedTopic(t, svc, "orders", 3, 1, nil)
+	broker.FailNext(svc.provisionMaxAttempts, errors.New("broker not available"))
+
+	for i := 0; i < svc.provisionMaxAttempts; i++ {
+		svc.RetryProvisioning(context.Background())
+	}
+	topic := getTopic(t, svc, "orders")
+	if topic.Status != models.TopicFailed || topic.ProvisionAttempts != svc.provisionMaxAttempts {
+		t.Fatalf("status %s after %d attempts, want FAILED", topic.Status, topic.ProvisionAttempts)
+	}
+
+	// The provisioner leaves a failed topic alone
+	svc.RetryProvisioning(context.Background())
+	if _, ok := broker.Topic("orders"); ok {
+		t.Fatal("the provisioner retried a FAILED topic")
+	}
+
+	retried, err := svc.RetryTopicProvisioning(context.Background(), "dev", "orders", "bob", "Broker is back")
+	if err != nil {
+		t.Fatalf("RetryTopicProvisioning: %v", err)
+	}
+	if retried.Status != models.TopicProvisioning || retried.ProvisionAttempts != 0 || retried.LastProvisionAttemptAt != nil {
+		t.Errorf("after reset: status %s, attempts %d, last attempt %v", retried.Status, retried.ProvisionAttempts, retried.LastProvisionAttemptAt)
+	}
+
+	// The retry provisions right away in the background
+	deadline := time.Now().Add(time.Second)
+	for getTopic(t, svc, "orders").Status != models.TopicActive {
+		if time.Now().After(deadline) {
+			t.Fatalf("topic is %s, want ACTIVE after the retry", getTopic(t, svc, "orders").Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := svc.RetryTopicProvisioning(context.Background(), "dev", "orders", "bob", ""); err == nil {
+		t.Error("an ACTIVE topic was retried")
+	}
+}
+
+func TestProvisionTopicAlreadyOnCluster(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  kafkaadmin.TopicSpec
+		want      models.TopicStatus
+		wantError string
+	}{
+		{
+			name:     "same settings",
+			existing: kafkaadmin.TopicSpec{Name: "orders", Partitions: 6, Replicas: 3, Configs: map[string]string{"retention.ms": "1000"}},
+			want:     models.TopicActive,
+		},
+		{
+			name:      "other partitions",
+			existing:  kafkaadmin.TopicSpec{Name: "orders", Partitions: 2, Replicas: 3, Configs: map[string]string{"retention.ms": "1000"}},
+			want:      models.TopicFailed,
+			wantError: "it has 2 partitions and 3 replicas",
+		},
+		{
+			name:      "other replicas",
+			existing:  kafkaadmin.TopicSpec{Name: "orders", Partitions: 6, Replicas: 1, Configs: map[string]string{"retention.ms": "1000"}},
+			want:      models.TopicFailed,
+			wantError: "it has 6 partitions and 1 replicas",
+		},
+		{
+			name:      "other config",
+			existing:  kafkaadmin.TopicSpec{Name: "orders", Partitions: 6, Replicas: 3},
+			want:      models.TopicFailed,
+			wantError: `config retention.ms is ""`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			svc, broker := newTestService(t)
+			approvedTopic(t, svc, "orders", 6, 3, map[string]string{"retention.ms": "1000"})
+			broker.PutTopic(tt.existing)
+
+			_, err := svc.ProvisionTopic(context.Background(), "dev", "orders")
+			if (err != nil) != (tt.wantError != "") {
+				t.Fatalf("ProvisionTopic err = %v", err)
+			}
+
+			topic := getTopic(t, svc, "orders")
+			if topic.Status != tt.want {
+				t.Errorf("status = %s, want %s", topic.Status, tt.want)
+			}
+			if !strings.Contains(topic.ProvisionError, tt.wantError) {
+				t.Errorf("provisionError = %q, want it to contain %q", topic.ProvisionError, tt.wantError)
+			}
+			// The existing topic is left as it was
+			if spec, _ := broker.Topic("orders"); spec.Partitions != tt.existing.Partitions {
+				t.Errorf("broker topic changed to %+v", spec)
+			}
+		})
+	}
+}
+
+// approvedChange provisions the topic and stores an APPROVED change of kind on it,
+// a deletion with its grace period already over
+func approvedChange(t *testing.T, svc *Service, name string, kind models.TopicChangeKind) *models.Topic {
+	t.Helper()
+	ctx := context.Background()
+	approvedTopic(t, svc, name, 3, 1, nil)
+	topic, err := svc.ProvisionTopic(ctx, "dev", name)
+	if err != nil {
+		t.Fatalf("ProvisionTopic: %v", err)
+	}
+
+	partitions := 6
+	change := &models.TopicChange{ID: "c1", Kind: kind, Status: models.ChangePending, RequestedBy: "alice", RequestedAt: time.Now()}
+	if kind == models.ChangeUpdate {
+		change.Partitions = &partitions
+	}
+	if topic, err = svc.store.Topics.RequestChange(ctx, "dev", name, change); err != nil || topic == nil {
+		t.Fatalf("RequestChange: %v", err)
+	}
+	if kind == models.ChangeDelete {
+		transition, err := newTransition(topic, models.TopicDeprecated, "bob", "Deletion approved")
+		if err != nil {
+			t.Fatalf("newTransition: %v", err)
+		}
+		topic, err = svc.store.Topics.ScheduleDeletion(ctx, "dev", name, change.ID, transition, time.Now().Add(-time.Second))
+	} else {
+		topic, err = svc.store.Topics.ApproveChange(ctx, "dev", name, change.ID, "bob", time.Now())
+	}
+	if err != nil || topic == nil {
+		t.Fatalf("approving change: %v", err)
+	}
+	return topic
+}
+
+func TestTopicChangeFailsAfterMaxAttempts(t *testing.T) {
+	t.Parallel()
+	svc, broker := newTestService(t)
+	ctx := context.Background()
+	approvedChange(t, svc, "orders", models.ChangeUpdate)
+	broker.FailNext(svc.provisionMaxAttempts, errors.New("broker not available"))
+
+	for i := 0; i < svc.provisionMaxAttempts; i++ {
+		svc.RetryProvisioning(ctx)
+	}
+	topic := getTopic(t, svc, "orders")
+	if topic.Change == nil || topic.Change.Status != models.ChangeFailed || topic.Change.ApplyAttempts != svc.provisionMaxAttempts {
+		t.Fatalf("change = %+v after %d attempts, want FAILED", topic.Change, svc.provisionMaxAttempts)
+	}
+
+	// The provisioner leaves a failed change alone and it keeps the change slot
+	svc.RetryProvisioning(ctx)
+	if spec, _ := broker.Topic("orders"); spec.Partitions != 3 {
+		t.Fatal("the provisioner retried a FAILED change")
+	}
+	partitions := 12
+	if _, err := svc.RequestTopicChange(ctx, "dev", "orders", models.TopicChange{Partitions: &partitions, RequestedBy: "alice"}); err == nil {
+		t.Error("a change was requested while a failed one was open")
+	}
+
+	retried, err := svc.RetryTopicChange(ctx, "dev", "orders", "bob", "Broker is back")
+	if err != nil {
+		t.Fatalf("RetryTopicChange: %v", err)
+	}
+	if retried.Change.Status != models.ChangeApproved || retried.Change.ApplyAttempts != 0 || retried.Change.LastApplyAttemptAt != nil {
+		t.Errorf("after reset: change %+v", retried.Change)
+	}
+
+	// The retry applies the change right away in the background
+	deadline := time.Now().Add(time.Second)
+	for getTopic(t, svc, "orders").Change != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("change = %+v, want it applied after the retry", getTopic(t, svc, "orders").Change)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if topic := getTopic(t, svc, "orders"); topic.Partitions != 6 {
+		t.Errorf("partitions = %d, want 6", topic.Partitions)
+	}
+
+	if _, err := svc.RetryTopicChange(ctx, "dev", "orders", "bob", ""); err == nil {
+		t.Error("a topic without a failed change was retried")
+	}
+}
+
+func TestCancelFailedTopicChange(t *testing.T) {
+	t.Parallel()
+	for _, kind := range []models.TopicChangeKind{models.ChangeUpdate, models.ChangeDelete} {
+		t.Run(string(kind), func(t *testing.T) {
+			t.Parallel()
+			svc, broker := newTestService(t)
+			ctx := context.Background()
+			approved := approvedChange(t, svc, "orders", kind)
+
+			if _, err := svc.CancelTopicChange(ctx, "dev", "orders", "bob", ""); err == nil {
+				t.Fatal("an APPROVED change was cancelled")
+			}
+
+			broker.FailNext(svc.provisionMaxAttempts, errors.New("broker not available"))
+			for i := 0; i < svc.provisionMaxAttempts; i++ {
+				svc.RetryProvisioning(ctx)
+			}
+			if change := getTopic(t, svc, "orders").Change; change == nil || change.Status != models.ChangeFailed {
+				t.Fatalf("change = %+v, want FAILED", change)
+			}
+
+			cancelled, err := svc.CancelTopicChange(ctx, "dev", "orders", "bob", "Not needed anymore")
+			if err != nil {
+				t.Fatalf("CancelTopicChange: %v", err)
+			}
+			// A failed deletion returns the topic to ACTIVE
+			if cancelled.Change != nil || cancelled.Status != models.TopicActive || cancelled.Partitions != approved.Partitions {
+				t.Errorf("topic is %s with %d partitions and change %+v, want it ACTIVE and unchanged", cancelled.Status, cancelled.Partitions, cancelled.Change)
+			}
+			if _, ok := broker.Topic("orders"); !ok {
+				t.Error("the topic was removed from the broker")
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"kafka-governance/kafkaadmin"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+// DetectDrift compares the governed topics of a cluster with its live metadata
+func DetectDrift(governed []models.Topic, live []kafkaadmin.TopicMetadata) []models.DriftFinding {
+	liveByName := make(map[string]kafkaadmin.TopicMetadata, len(live))
+	for _, t := range live {
+		liveByName[t.Name] = t
+	}
+
+	findings := []models.DriftFinding{}
+	seen := make(map[string]bool, len(governed))
+	for _, topic := range governed {
+		seen[topic.Name] = true
+		meta, exists := liveByName[topic.Name]
+
+		switch topic.Status {
+		case models.TopicPending, models.TopicRejected:
+			if exists {
+				findings = append(findings, models.DriftFinding{Topic: topic.Name, Type: models.DriftUngoverned, Status: topic.Status})
+			}
+			continue
+		case models.TopicDeleted:
+			if exists {
+				findings = append(findings, models.DriftFinding{Topic: topic.Name, Type: models.DriftDeletedButPresent, Status: topic.Status})
+			}
+			continue
+		case models.TopicApproved, models.TopicProvisioning, models.TopicFailed:
+			if !exists {
+				findings = append(findings, models.DriftFinding{Topic: topic.Name, Type: models.DriftNotProvisioned, Status: topic.Status})
+				continue
+			}
+		default:
+			if !exists {
+				findings = append(findings, models.DriftFinding{Topic: topic.Name, Type: models.DriftMissing, Status: topic.Status})
+				continue
+			}
+		}
+
+		if meta.Partitions != topic.Partitions {
+			findings = append(findings, models.DriftFinding{
+				Topic:    topic.Name,
+				Type:     models.DriftPartitionsMismatch,
+				Status:   topic.Status,
+				Expected: strconv.Itoa(topic.Partitions),
+				Actual:   strconv.Itoa(meta.Partitions),
+			})
+		}
+		if meta.Replicas != topic.Replicas {
+			findings = append(findings, models.DriftFinding{
+				Topic:    topic.Name,
+				Type:     models.DriftReplicasMismatch,
+				Status:   topic.Status,
+				Expected: strconv.Itoa(topic.Replicas),
+				Actual:   strconv.Itoa(meta.Replicas),
+			})
+		}
+	}
+
+	for _, t := range live {
+		if !seen[t.Name] {
+			findings = append(findings, models.DriftFinding{Topic: t.Name, Type: models.DriftUngoverned})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Topic < findings[j].Topic })
+	return findings
+}
+
+// ReconcileCluster compares governance with the live state of one cluster and stores the findings
+func (s *Service) ReconcileCluster(ctx context.Context, name string) (*models.DriftReport, error) {
+	logger := utils.GetLogger()
+	logger.Infof("Reconciling cluster %s", name)
+
+	if s.adminConnector == nil {
+		logger.Error("No Kafka admin client configured")
+		return nil, errors.New("kafka admin is not configured")
+	}
+
+	admin, err := s.adminForCluster(ctx, name)
+	if err != nil {
+		logger.Error("Failed to connect to cluster for reconciliation")
+		return nil, err
+	}
+
+	live, err := admin.ListTopics(ctx)
+	if err != nil {
+		logger.Error("Failed to fetch live topic metadata")
+		return nil, err
+	}
+
+	governed, err := s.store.Topics.ListMatching(ctx, models.TopicFilter{Cluster: name})
+	if err != nil {
+		logger.Error("Failed to fetch governed topics")
+		return nil, err
+	}
+
+	report := &models.DriftReport{
+		Cluster:   name,
+		CheckedAt: time.Now(),
+		Findings:  DetectDrift(governed, live),
+	}
+	if err := s.store.Drift.Save(ctx, report); err != nil {
+		logger.Error("Failed to store drift report")
+		return nil, err
+	}
+	logger.Infof("Cluster %s reconciled, findings: %d", name, len(report.Findings))
+	return report, nil
+}
+
+// GetDriftReport returns the latest drift findings of a cluster
+func (s *Service) GetDriftReport(ctx context.Context, cluster string) (*models.DriftReport, error) {
+	logger := utils.GetLogger()
+	logger.Info("Retrieving drift report")
+
+	report, err := s.store.Drift.Get(ctx, cluster)
+	if err != nil {
+		logger.Error("Failed to retrieve drift report")
+		return nil, err
+	}
+	logger.Info("Drift report retrieved successfully")
+	return report, nil
+}
+
+// RunReconciler reconciles every registered cluster each interval until ctx is done
+func (s *Service) RunReconciler(ctx context.Context, interval time.Duration) {
+	logger := utils.GetLogger()
+	if s.adminConnector == nil {
+		logger.Warn("Drift reconciler disabled, no Kafka admin client configured")
+		return
+	}
+	logger.Infof("Drift reconciler started, interval: %s", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Drift reconciler stopped")
+			return
+		case <-ticker.C:
+			s.reconcileAll(ctx)
+		}
+	}
+}
+
+func (s *Service) reconcileAll(ctx context.Context) {
+	logger := utils.GetLogger()
+
+	clusters, err := s.store.Clusters.List(ctx)
+	if err != nil {
+		logger.Error("Failed to list clusters for reconciliation")
+		return
+	}
+	for _, cluster := range clusters {
+		if _, err := s.ReconcileCluster(ctx, cluster.Name); err != nil {
+			logger.Warnf("Reconciliation of cluster %s failed", cluster.Name)
+		}
+	}
+}
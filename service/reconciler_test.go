@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"kafka-governance/kafkaadmin"
+	"kafka-governance/models"
+)
+
+// fakeMetadata is an admin client whose live topics are scripted by the test
+type fakeMetadata struct {
+	kafkaadmin.Admin
+	topics []kafkaadmin.TopicMetadata
+	err    error
+}
+
+func (f *fakeMetadata) ListTopics(ctx context.Context) ([]kafkaadmin.TopicMetadata, error) {
+	return f.topics, f.err
+}
+
+// fakeConnector hands out the same admin client for every cluster
+type fakeConnector struct {
+	admin kafkaadmin.Admin
+}
+
+func (f fakeConnector) Connect(*models.Cluster) (kafkaadmin.Admin, error) {
+	return f.admin, nil
+}
+
+func TestDetectDrift(t *testing.T) {
+	governed := []models.Topic{
+		{Name: "in-sync", Status: models.TopicActive, Partitions: 3, Replicas: 3},
+		{Name: "grown", Status: models.TopicActive, Partitions: 3, Replicas: 3},
+		{Name: "under-replicated", Status: models.TopicDeprecated, Partitions: 3, Replicas: 3},
+		{Name: "gone", Status: models.TopicActive, Partitions: 3, Replicas: 3},
+		{Name: "waiting", Status: models.TopicApproved, Partitions: 3, Replicas: 3},
+		{Name: "failed", Status: models.TopicFailed, Partitions: 3, Replicas: 3},
+		{Name: "jumped-the-queue", Status: models.TopicPending, Partitions: 3, Replicas: 3},
+		{Name: "deleted", Status: models.TopicDeleted, Partitions: 3, Replicas: 3},
+		{Name: "deleted-and-gone", Status: models.TopicDeleted, Partitions: 3, Replicas: 3},
+	}
+	live := []kafkaadmin.TopicMetadata{
+		{Name: "in-sync", Partitions: 3, Replicas: 3},
+		{Name: "grown", Partitions: 6, Replicas: 3},
+		{Name: "under-replicated", Partitions: 3, Replicas: 1},
+		{Name: "jumped-the-queue", Partitions: 3, Replicas: 3},
+		{Name: "deleted", Partitions: 3, Replicas: 3},
+		{Name: "shadow", Partitions: 1, Replicas: 1},
+	}
+
+	want := []models.DriftFinding{
+		{Topic: "deleted", Type: models.DriftDeletedButPresent, Status: models.TopicDeleted},
+		{Topic: "failed", Type: models.DriftNotProvisioned, Status: models.TopicFailed},
+		{Topic: "gone", Type: models.DriftMissing, Status: models.TopicActive},
+		{Topic: "grown", Type: models.DriftPartitionsMismatch, Status: models.TopicActive, Expected: "3", Actual: "6"},
+		{Topic: "jumped-the-queue", Type: models.DriftUngoverned, Status: models.TopicPending},
+		{Topic: "shadow", Type: models.DriftUngoverned},
+		{Topic: "under-replicated", Type: models.DriftReplicasMismatch, Status: models.TopicDeprecated, Expected: "3", Actual: "1"},
+		{Topic: "waiting", Type: models.DriftNotProvisioned, Status: models.TopicApproved},
+	}
+	if got := DetectDrift(governed, live); !reflect.DeepEqual(got, want) {
+		t.Errorf("DetectDrift =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestReconcileCluster(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	prod := &models.Cluster{Name: "prod", BootstrapServers: []string{"localhost:9093"}, Environment: models.EnvProd,
+		BrokerCount: 3, DefaultPartitions: 3, DefaultReplicas: 3, MaxReplicas: 3}
+	if _, err := svc.store.Clusters.Insert(ctx, prod); err != nil {
+		t.Fatalf("inserting cluster: %v", err)
+	}
+	for _, topic := range []*models.Topic{
+		{Name: "orders", Cluster: "dev", Status: models.TopicActive, Partitions: 3, Replicas: 1},
+		{Name: "payments", Cluster: "dev", Status: models.TopicActive, Partitions: 3, Replicas: 1},
+		// Same name on another cluster: must not hide the ungoverned dev topic
+		{Name: "audit", Cluster: "prod", Status: models.TopicActive, Partitions: 3, Replicas: 3},
+	} {
+		if _, err := svc.store.Topics.Create(ctx, topic); err != nil {
+			t.Fatalf("creating topic: %v", err)
+		}
+	}
+
+	metadata := &fakeMetadata{topics: []kafkaadmin.TopicMetadata{
+		{Name: "orders", Partitions: 3, Replicas: 1},
+		{Name: "audit", Partitions: 3, Replicas: 1},
+	}}
+	svc.adminConnector = fakeConnector{admin: metadata}
+
+	report, err := svc.ReconcileCluster(ctx, "dev")
+	if err != nil {
+		t.Fatalf("ReconcileCluster: %v", err)
+	}
+	want := []models.DriftFinding{
+		{Topic: "audit", Type: models.DriftUngoverned},
+		{Topic: "payments", Type: models.DriftMissing, Status: models.TopicActive},
+	}
+	if !reflect.DeepEqual(report.Findings, want) {
+		t.Errorf("findings = %+v, want %+v", report.Findings, want)
+	}
+
+	stored, err := svc.GetDriftReport(ctx, "dev")
+	if err != nil {
+		t.Fatalf("GetDriftReport: %v", err)
+	}
+	if !reflect.DeepEqual(stored.Findings, want) {
+		t.Errorf("stored findings = %+v, want %+v", stored.Findings, want)
+	}
+
+	// A failing metadata source keeps the previous report
+	metadata.err = errors.New("broker not available")
+	metadata.topics = nil
+	if _, err := svc.ReconcileCluster(ctx, "dev"); err == nil {
+		t.Fatal("ReconcileCluster succeeded without metadata")
+	}
+	if stored, _ := svc.GetDriftReport(ctx, "dev"); !reflect.DeepEqual(stored.Findings, want) {
+		t.Errorf("report after a failed run = %+v, want the previous one", stored.Findings)
+	}
+
+	if _, err := svc.ReconcileCluster(ctx, "staging"); err == nil {
+		t.Error("an unregistered cluster was reconciled")
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"sync"
+	"time"
+
+	"kafka-governance/db"
+	"kafka-governance/kafkaadmin"
+	"kafka-governance/models"
+)
+
+// Config holds the collaborators and settings of a Service
+type Config struct {
+	// Authorizer decides requests; nil evaluates the stored policies in-process
+	Authorizer Authorizer
+	// AdminConnector creates Kafka admin clients for provisioning. Without one,
+	// approved topics stay APPROVED.
+	AdminConnector kafkaadmin.Connector
+	// ProvisionRetries is how many failed attempts move a topic to FAILED; 0 keeps the default
+	ProvisionRetries int
+	// ClusterCADir is the directory cluster CA files must be in; empty disables them
+	ClusterCADir string
+	// DeletionGrace is how long an approved deletion keeps the topic DEPRECATED
+	// before the provisioner removes it from its cluster
+	DeletionGrace time.Duration
+	// AuditSigner signs audit checkpoints. Without a key no checkpoints are
+	// written and checkpoint signatures are not verified.
+	AuditSigner ed25519.PrivateKey
+}
+
+// Service implements the governance operations against a store
+type Service struct {
+	store      *db.Store
+	authorizer Authorizer
+
+	adminConnector       kafkaadmin.Connector
+	provisionMaxAttempts int
+	provisionBaseBackoff time.Duration
+	provisionTimeout     time.Duration
+
+	clusterCADir  string
+	deletionGrace time.Duration
+
+	// auditMu serializes appends within this process; the unique seq index
+	// handles writers in other processes
+	auditMu     sync.Mutex
+	auditSigner ed25519.PrivateKey
+
+	// policySyncMu serializes pushes to the authorizer, policyStatusMu guards
+	// the outcome of the last one
+	policySyncMu   sync.Mutex
+	policyStatusMu sync.Mutex
+	policySync     models.PolicySyncStatus
+}
+
+// New creates a Service working against store, MongoDB or in-memory
+func New(store *db.Store, cfg Config) *Service {
+	s := &Service{
+		store:                store,
+		authorizer:           cfg.Authorizer,
+		adminConnector:       cfg.AdminConnector,
+		provisionMaxAttempts: defaultProvisionMaxAttempts,
+		provisionBaseBackoff: defaultProvisionBaseBackoff,
+		provisionTimeout:     defaultProvisionTimeout,
+		clusterCADir:         cfg.ClusterCADir,
+		deletionGrace:        cfg.DeletionGrace,
+		auditSigner:          cfg.AuditSigner,
+	}
+	if s.authorizer == nil {
+		s.authorizer = localAuthorizer{policies: store.Policies}
+	}
+	if cfg.ProvisionRetries > 0 {
+		s.provisionMaxAttempts = cfg.ProvisionRetries
+	}
+	if s.deletionGrace < 0 {
+		s.deletionGrace = 0
+	}
+	return s
+}
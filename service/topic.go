@@ -2,15 +2,65 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"time"
 
-	"kafka-governance/db"
 	"kafka-governance/models"
 	"kafka-governance/utils"
 )
 
-func CreateTopic(ctx context.Context, topic *models.Topic) (*models.Topic, error) {
-	response, err := db.CreateTopic(ctx, topic)
+// ReasonAmbiguousTopic is the 409 code for a name-only lookup of a topic that
+// exists on several clusters
+const ReasonAmbiguousTopic = "AMBIGUOUS_TOPIC"
+
+// CreateTopic stores a new PENDING topic request. Only the fields a requester
+// may choose are taken from request; approval, provisioning and every other
+// server-owned field start empty.
+func (s *Service) CreateTopic(ctx context.Context, request *models.Topic) (*models.Topic, error) {
+	topic := &models.Topic{
+		Name:        request.Name,
+		Cluster:     request.Cluster,
+		Partitions:  request.Partitions,
+		Replicas:    request.Replicas,
+		Configs:     request.Configs,
+		OwnerTeam:   request.OwnerTeam,
+		Owners:      request.Owners,
+		OnCall:      request.OnCall,
+		Labels:      request.Labels,
+		RequestedBy: request.RequestedBy,
+		Status:      models.TopicPending,
+		Revision:    1,
+	}
+	if len(topic.Owners) == 0 {
+		topic.Owners = []string{topic.RequestedBy}
+	}
+	topic.Transitions = []models.TopicTransition{{
+		To:     models.TopicPending,
+		Actor:  topic.RequestedBy,
+		Reason: "Topic requested",
+		At:     time.Now(),
+	}}
+
 	logger := utils.GetLogger()
+	approval, err := s.approvalFor(ctx, topic.Cluster)
+	if err != nil {
+		logger.Error("Failed to look up approval workflow")
+		return nil, err
+	}
+	topic.Approval = approval
+
+	var response *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if response, err = s.store.Topics.Create(ctx, topic); err != nil {
+			return err
+		}
+		if err := s.recordAudit(ctx, "topic.create", "topic", topicResource(response.Cluster, response.Name), nil, response); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.create", response)
+	})
 	if err != nil {
 		logger.Error("Topic creation failed at database layer")
 		return nil, err
@@ -18,24 +68,35 @@ func CreateTopic(ctx context.Context, topic *models.Topic) (*models.Topic, error
 	return response, nil
 }
 
-func ListTopics(ctx context.Context) ([]models.Topic, error) {
-	logger := utils.GetLogger()
-	logger.Info("Retrieving topics list")
+const maxTopicLabels = 32
 
-	topics, err := db.ListTopics(ctx)
-	if err != nil {
-		logger.Error("Failed to retrieve topics list")
-		return nil, err
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9_-]{0,61}[A-Za-z0-9])?$`)
+	labelValuePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,63}$`)
+)
+
+// ValidateTopicLabels checks label keys and values. Keys are limited to
+// characters that are safe in MongoDB field paths, since labels are queried by key.
+func ValidateTopicLabels(topic *models.Topic) error {
+	if len(topic.Labels) > maxTopicLabels {
+		return utils.NewInvalidInputError(fmt.Sprintf("A topic may carry at most %d labels", maxTopicLabels))
+	}
+	for key, value := range topic.Labels {
+		if !labelKeyPattern.MatchString(key) {
+			return utils.NewInvalidInputError("Invalid label key " + key + ": use up to 63 letters, digits, - and _, starting and ending with a letter or digit")
+		}
+		if !labelValuePattern.MatchString(value) {
+			return utils.NewInvalidInputError("Invalid value for label " + key + ": use 1 to 63 letters, digits, ., - and _")
+		}
 	}
-	logger.Infof("Topics list retrieved successfully, count: %d", len(topics))
-	return topics, nil
+	return nil
 }
 
-func GetTopic(ctx context.Context, name string) (*models.Topic, error) {
+func (s *Service) GetTopic(ctx context.Context, cluster, name string) (*models.Topic, error) {
 	logger := utils.GetLogger()
-	logger.Info("Retrieving topic by name")
+	logger.Info("Retrieving topic by cluster and name")
 
-	topic, err := db.GetTopicByName(ctx, name)
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
 	if err != nil {
 		logger.Error("Failed to retrieve topic")
 		return nil, err
@@ -44,15 +105,124 @@ func GetTopic(ctx context.Context, name string) (*models.Topic, error) {
 	return topic, nil
 }
 
-func ApproveTopic(ctx context.Context, name, admin string) error {
+// ResolveTopic looks a topic up by name alone. When the name exists on several
+// clusters it returns a conflict listing the candidates, so the caller can retry
+// with the cluster.
+func (s *Service) ResolveTopic(ctx context.Context, name string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Resolving topic by name")
+
+	topics, err := s.store.Topics.FindByName(ctx, name)
+	if err != nil {
+		logger.Error("Failed to retrieve topics by name")
+		return nil, err
+	}
+	switch len(topics) {
+	case 0:
+		logger.Error("Topic not found")
+		return nil, utils.NewNotFoundError("topic not found")
+	case 1:
+		return &topics[0], nil
+	}
+
+	candidates := make([]map[string]interface{}, 0, len(topics))
+	for _, t := range topics {
+		candidates = append(candidates, map[string]interface{}{"cluster": t.Cluster, "status": t.Status})
+	}
+	logger.Errorf("Topic name exists on %d clusters", len(topics))
+	return nil, utils.NewConflictReason(
+		ReasonAmbiguousTopic,
+		fmt.Sprintf("topic %s exists on %d clusters, address it by cluster", name, len(topics)),
+		map[string]interface{}{"topic": name, "candidates": candidates},
+	)
+}
+
+// topicResource names a topic in audit events, e.g. "prod-eu/orders.created"
+func topicResource(cluster, name string) string {
+	return cluster + "/" + name
+}
+
+// TopicUID is the Cedar entity of a topic. Names are only unique on a cluster, so
+// the entity carries both, e.g. Topic::"prod-eu/orders.created".
+func TopicUID(cluster, name string) string {
+	return utils.EntityUID("Topic", topicResource(cluster, name))
+}
+
+// ApproveTopic approves a pending topic on behalf of approver, who must not be the
+// requester. When a workflow applies to the topic's cluster the approval counts as
+// a vote on the current stage, and the topic is approved once every stage has
+// its quorum.
+func (s *Service) ApproveTopic(ctx context.Context, cluster, name string, approver *models.Principal, reason string) (*models.Topic, error) {
 	logger := utils.GetLogger()
 	logger.Info("Processing topic approval request")
 
-	err := db.ApproveTopic(ctx, name, admin)
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		logger.Error("Failed to retrieve topic for approval")
+		return nil, err
+	}
+
+	// Separation of duties: a topic needs a second person to approve it
+	if topic.RequestedBy != "" && topic.RequestedBy == approver.Subject {
+		logger.Errorf("Self-approval of topic by %s rejected", approver.Subject)
+		return nil, utils.NewForbiddenReason(
+			ReasonSelfApproval,
+			"Topics cannot be approved by the user who requested them",
+			map[string]interface{}{"topic": topic.Name, "requestedBy": topic.RequestedBy},
+		)
+	}
+
+	if reason == "" {
+		reason = "Approved"
+	}
+
+	// The workflow was recorded on the request; votes never re-read it
+	if topic.Approval != nil {
+		return s.voteOnTopic(ctx, topic, approver, reason)
+	}
+	return s.finishApproval(ctx, topic, approver.Subject, reason)
+}
+
+// finishApproval moves the topic to APPROVED and starts provisioning it
+func (s *Service) finishApproval(ctx context.Context, topic *models.Topic, admin, reason string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+
+	transition, err := newTransition(topic, models.TopicApproved, admin, reason)
+	if err != nil {
+		logger.Errorf("Topic approval rejected: %s", err.Error())
+		return nil, err
+	}
+
+	var approved *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if approved, err = s.store.Topics.Approve(ctx, topic.Cluster, topic.Name, transition); err != nil {
+			return err
+		}
+		if err := s.recordAudit(ctx, transitionAction(models.TopicApproved), "topic", topicResource(topic.Cluster, topic.Name), topic, approved); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, transitionAction(models.TopicApproved), approved)
+	})
 	if err != nil {
 		logger.Error("Topic approval failed")
-		return err
+		return nil, err
 	}
 	logger.Info("Topic approved successfully")
-	return nil
+
+	s.provisionAfterApproval(topic.Cluster, topic.Name)
+	return approved, nil
+}
+
+func (s *Service) RejectTopic(ctx context.Context, cluster, name, admin, reason string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Processing topic rejection request")
+
+	rejected, err := s.TransitionTopic(ctx, cluster, name, models.TopicRejected, admin, reason)
+	if err != nil {
+		logger.Error("Topic rejection failed")
+		return nil, err
+	}
+	logger.Info("Topic rejected successfully")
+	return rejected, nil
 }
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kafka-governance/models"
+)
+
+func TestCreateTopicIgnoresServerOwnedFields(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	partitions := 12
+	request := &models.Topic{
+		ID: "forged", Name: "orders", Cluster: "dev", Partitions: 3, Replicas: 1,
+		Configs: models.TopicConfigs{"retention.ms": "86400000"}, Labels: map[string]string{"domain": "sales"},
+		RequestedBy: "alice",
+
+		Status:                 models.TopicActive,
+		ApprovedBy:             "mallory",
+		ApprovedAt:             &now,
+		UpdatedAt:              &now,
+		Transitions:            []models.TopicTransition{{To: models.TopicActive, Actor: "mallory"}},
+		Approval:               &models.TopicApproval{Workflow: "forged"},
+		Revision:               7,
+		Change:                 &models.TopicChange{Partitions: &partitions},
+		Clients:                []models.TopicClient{{Application: "billing", Role: models.ClientProducer}},
+		Transfer:               &models.OwnershipTransfer{ToTeam: "mallory"},
+		Imported:               true,
+		ProvisionedAt:          &now,
+		ProvisionAttempts:      9,
+		ProvisionError:         "forged",
+		LastProvisionAttemptAt: &now,
+	}
+
+	created, err := svc.CreateTopic(ctx, request)
+	if err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	for _, topic := range []*models.Topic{created, getTopic(t, svc, "orders")} {
+		if topic.ID == "forged" || topic.Status != models.TopicPending || topic.Revision != 1 {
+			t.Errorf("topic has id %q, status %s, revision %d", topic.ID, topic.Status, topic.Revision)
+		}
+		if topic.ApprovedBy != "" || topic.ApprovedAt != nil || topic.UpdatedAt != nil || topic.Approval != nil {
+			t.Errorf("topic kept approval fields: by %q at %v, approval %+v", topic.ApprovedBy, topic.ApprovedAt, topic.Approval)
+		}
+		if topic.ProvisionedAt != nil || topic.ProvisionAttempts != 0 || topic.ProvisionError != "" || topic.LastProvisionAttemptAt != nil {
+			t.Errorf("topic kept provisioning fields: %v, %d, %q, %v",
+				topic.ProvisionedAt, topic.ProvisionAttempts, topic.ProvisionError, topic.LastProvisionAttemptAt)
+		}
+		if topic.Change != nil || topic.Clients != nil || topic.Transfer != nil || topic.Imported {
+			t.Errorf("topic kept change %+v, clients %v, transfer %+v, imported %v", topic.Change, topic.Clients, topic.Transfer, topic.Imported)
+		}
+		if len(topic.Transitions) != 1 || topic.Transitions[0].To != models.TopicPending || topic.Transitions[0].Actor != "alice" {
+			t.Errorf("transitions = %+v, want the request by alice", topic.Transitions)
+		}
+		if topic.Partitions != 3 || topic.Configs["retention.ms"] != "86400000" || topic.Labels["domain"] != "sales" {
+			t.Errorf("requested fields were not kept: %+v", topic)
+		}
+	}
+}
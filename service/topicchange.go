@@ -0,0 +1,553 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/google/uuid"
+)
+
+// ReasonChangePending is the 409 code for a change request on a topic that
+// already has an open one
+const ReasonChangePending = "CHANGE_PENDING"
+
+// validateTopicChange rejects changes Kafka cannot make, such as fewer
+// partitions, and checks the new values against the cluster's limits
+func validateTopicChange(topic *models.Topic, change *models.TopicChange, cluster *models.Cluster) error {
+	if change.Partitions == nil && len(change.Configs) == 0 {
+		return utils.NewInvalidInputError("A change must set partitions or configs")
+	}
+
+	if change.Partitions != nil {
+		partitions := *change.Partitions
+		if partitions < topic.Partitions {
+			return utils.NewInvalidInputError(fmt.Sprintf("Kafka cannot reduce the partitions of a topic: it has %d, %d requested", topic.Partitions, partitions))
+		}
+		if partitions == topic.Partitions {
+			return utils.NewInvalidInputError(fmt.Sprintf("Topic already has %d partitions", partitions))
+		}
+		if cluster.MaxPartitions > 0 && partitions > cluster.MaxPartitions {
+			return utils.NewInvalidInputError(fmt.Sprintf("Partitions (%d) cannot exceed the maximum of cluster %s (%d)", partitions, cluster.Name, cluster.MaxPartitions))
+		}
+	}
+
+	// Only the configs being set are checked, so existing values that predate a
+	// cluster limit do not block unrelated changes
+	set := &models.Topic{Replicas: topic.Replicas, Configs: models.TopicConfigs{}}
+	for key, value := range change.Configs {
+		current, isSet := topic.Configs[key]
+		if value == nil {
+			if !isSet {
+				return utils.NewInvalidInputError(fmt.Sprintf("Config %s is not set on the topic", key))
+			}
+			continue
+		}
+		if isSet && current == *value {
+			return utils.NewInvalidInputError(fmt.Sprintf("Config %s is already %s", key, current))
+		}
+		set.Configs[key] = *value
+	}
+	return ValidateTopicConfigs(set, cluster)
+}
+
+// RequestTopicChange opens a change request on an active topic. The change goes
+// through the approval workflow of the topic's cluster and is applied to the
+// cluster once approved.
+func (s *Service) RequestTopicChange(ctx context.Context, cluster, name string, change models.TopicChange) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Processing topic change request")
+
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		logger.Error("Failed to retrieve topic for change")
+		return nil, err
+	}
+	if topic.Status != models.TopicActive {
+		logger.Errorf("Topic is %s, cannot be changed", topic.Status)
+		return nil, utils.NewConflictError("topic is " + string(topic.Status) + ", only ACTIVE topics can be changed")
+	}
+	if topic.Change != nil {
+		return nil, changePendingError(topic)
+	}
+
+	registered, err := s.store.Clusters.GetByName(ctx, cluster)
+	if err != nil {
+		logger.Error("Failed to look up cluster of topic")
+		return nil, err
+	}
+	if err := validateTopicChange(topic, &change, registered); err != nil {
+		logger.Errorf("Topic change validation failed: %s", err.Error())
+		return nil, err
+	}
+
+	approval, err := s.approvalFor(ctx, cluster)
+	if err != nil {
+		logger.Error("Failed to look up approval workflow")
+		return nil, err
+	}
+	change.ID = uuid.New().String()
+	change.Kind = models.ChangeUpdate
+	change.Status = models.ChangePending
+	change.RequestedAt = time.Now()
+	change.ApprovedBy = ""
+	change.ApprovedAt = nil
+	change.Approval = approval
+	change.Impact = nil
+	change.RemoveAfter = nil
+
+	var requested *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if requested, err = s.requestChange(ctx, topic, &change); err != nil {
+			return err
+		}
+		if err := s.recordAudit(ctx, "topic.change_request", "topic", topicResource(cluster, name), topic, requested); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.change_request", requested)
+	})
+	if err != nil {
+		logger.Error("Failed to store topic change request")
+		return nil, err
+	}
+	logger.Info("Topic change requested successfully")
+	return requested, nil
+}
+
+// requestChange opens change on the topic, reporting a conflict when another
+// change was opened, or the topic left ACTIVE, since it was read
+func (s *Service) requestChange(ctx context.Context, topic *models.Topic, change *models.TopicChange) (*models.Topic, error) {
+	requested, err := s.store.Topics.RequestChange(ctx, topic.Cluster, topic.Name, change)
+	if err != nil || requested != nil {
+		return requested, err
+	}
+	current, err := s.store.Topics.Get(ctx, topic.Cluster, topic.Name)
+	if err != nil {
+		return nil, err
+	}
+	if current.Change != nil {
+		return nil, changePendingError(current)
+	}
+	return nil, utils.NewConflictError("topic is " + string(current.Status) + ", only ACTIVE topics can be changed")
+}
+
+func changePendingError(topic *models.Topic) error {
+	return utils.NewConflictReason(
+		ReasonChangePending,
+		"Topic already has an open change request",
+		map[string]interface{}{"topic": topic.Name, "change": topic.Change.ID, "status": topic.Change.Status},
+	)
+}
+
+// openChange returns the topic with its open change request, or an error when
+// it has none in the given status
+func (s *Service) openChange(ctx context.Context, cluster, name string, status models.TopicChangeStatus) (*models.Topic, error) {
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		return nil, err
+	}
+	if topic.Change == nil {
+		return nil, utils.NewNotFoundError("topic has no open change request")
+	}
+	if topic.Change.Status != status {
+		return nil, utils.NewConflictError("change request is " + string(topic.Change.Status))
+	}
+	return topic, nil
+}
+
+// ApproveTopicChange records the approver's vote on the topic's pending change
+// request, or approves it outright when the cluster has no workflow. The
+// approved change is applied to the cluster right away.
+func (s *Service) ApproveTopicChange(ctx context.Context, cluster, name string, approver *models.Principal, reason string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Processing topic change approval request")
+
+	topic, err := s.openChange(ctx, cluster, name, models.ChangePending)
+	if err != nil {
+		logger.Error("Failed to retrieve change request for approval")
+		return nil, err
+	}
+	change := topic.Change
+
+	if change.RequestedBy == approver.Subject {
+		logger.Errorf("Self-approval of topic change by %s rejected", approver.Subject)
+		return nil, utils.NewForbiddenReason(
+			ReasonSelfApproval,
+			"Changes cannot be approved by the user who requested them",
+			map[string]interface{}{"topic": topic.Name, "requestedBy": change.RequestedBy},
+		)
+	}
+
+	if reason == "" {
+		reason = "Approved"
+	}
+
+	finish := func(ctx context.Context, voted *models.Topic) (*models.Topic, error) {
+		return s.finishChangeApproval(ctx, voted, approver.Subject)
+	}
+	if change.Approval == nil {
+		return finish(ctx, topic)
+	}
+	return s.castVote(ctx, topic, approver, reason, approvalFlow{
+		subject:     "change",
+		voteAction:  "topic.change_vote",
+		stageAction: "topic.change_stage",
+		approval:    func(t *models.Topic) *models.TopicApproval { return t.Change.Approval },
+		record: func(ctx context.Context, vote models.ApprovalVote) (*models.Topic, error) {
+			return s.store.Topics.RecordChangeVote(ctx, cluster, name, change.ID, vote)
+		},
+		advance: func(ctx context.Context, stage int) (*models.Topic, error) {
+			return s.store.Topics.AdvanceChangeStage(ctx, cluster, name, change.ID, stage)
+		},
+		finish: finish,
+	})
+}
+
+// finishChangeApproval marks the change approved and starts applying it. An
+// approved deletion instead deprecates the topic for the grace period.
+func (s *Service) finishChangeApproval(ctx context.Context, topic *models.Topic, approver string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+
+	if topic.Change.Kind == models.ChangeDelete {
+		return s.scheduleDeletion(ctx, topic, approver)
+	}
+
+	var approved *models.Topic
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if approved, err = s.store.Topics.ApproveChange(ctx, topic.Cluster, topic.Name, topic.Change.ID, approver, time.Now()); err != nil {
+			return err
+		}
+		if approved == nil {
+			logger.Error("Change request changed while approving")
+			return utils.NewConflictError("change request changed, retry the request")
+		}
+		if err := s.recordAudit(ctx, "topic.change_approved", "topic", topicResource(topic.Cluster, topic.Name), topic, approved); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.change_approved", approved)
+	})
+	if err != nil {
+		logger.Error("Topic change approval failed")
+		return nil, err
+	}
+	logger.Info("Topic change approved successfully")
+
+	s.applyAfterApproval(topic.Cluster, topic.Name)
+	return approved, nil
+}
+
+// RejectTopicChange closes the topic's pending change request without applying it
+func (s *Service) RejectTopicChange(ctx context.Context, cluster, name, admin, reason string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Processing topic change rejection request")
+
+	topic, err := s.openChange(ctx, cluster, name, models.ChangePending)
+	if err != nil {
+		logger.Error("Failed to retrieve change request for rejection")
+		return nil, err
+	}
+
+	var closed *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if closed, err = s.store.Topics.CloseChange(ctx, cluster, name, topic.Change.ID, models.ChangePending); err != nil {
+			return err
+		}
+		if closed == nil {
+			logger.Error("Change request changed while rejecting")
+			return utils.NewConflictError("change request changed, retry the request")
+		}
+		if err := s.recordAudit(ctx, "topic.change_rejected", "topic", topicResource(cluster, name),
+			map[string]interface{}{"change": topic.Change},
+			map[string]interface{}{"rejectedBy": admin, "reason": reason},
+		); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.change_rejected", closed)
+	})
+	if err != nil {
+		logger.Error("Topic change rejection failed")
+		return nil, err
+	}
+	logger.Info("Topic change rejected successfully")
+	return closed, nil
+}
+
+// ApplyTopicChange makes an approved change on the Kafka cluster and stores the
+// result as the topic's next revision, or removes a topic whose deletion grace
+// period is over. On failure the broker error is recorded and the change stays
+// APPROVED so RetryProvisioning picks it up again. After provisionMaxAttempts it
+// moves to FAILED until RetryTopicChange or CancelTopicChange is called. Every
+// step can be repeated safely after a partial success.
+func (s *Service) ApplyTopicChange(ctx context.Context, cluster, name string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Applying topic change to Kafka cluster")
+
+	if s.adminConnector == nil {
+		logger.Error("No Kafka admin client configured")
+		return nil, errors.New("kafka provisioning is not configured")
+	}
+
+	topic, err := s.openChange(ctx, cluster, name, models.ChangeApproved)
+	if err != nil {
+		logger.Error("Failed to retrieve change request to apply")
+		return nil, err
+	}
+	change := topic.Change
+	if change.Kind == models.ChangeDelete {
+		return s.removeTopic(ctx, topic)
+	}
+
+	partitions := topic.Partitions
+	if change.Partitions != nil {
+		partitions = *change.Partitions
+	}
+	configs := models.TopicConfigs{}
+	for key, value := range topic.Configs {
+		configs[key] = value
+	}
+	set := map[string]string{}
+	var remove []string
+	for key, value := range change.Configs {
+		if value == nil {
+			delete(configs, key)
+			remove = append(remove, key)
+			continue
+		}
+		configs[key] = *value
+		set[key] = *value
+	}
+	sort.Strings(remove)
+
+	admin, err := s.adminForCluster(ctx, cluster)
+	if err == nil && partitions != topic.Partitions {
+		err = admin.CreatePartitions(ctx, name, partitions)
+	}
+	if err == nil && len(change.Configs) > 0 {
+		err = admin.AlterConfigs(ctx, name, set, remove)
+	}
+	if err != nil {
+		logger.Errorf("Applying topic change failed: %s", err.Error())
+		s.recordChangeFailure(ctx, topic, err)
+		return nil, err
+	}
+
+	var applied *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if applied, err = s.store.Topics.ApplyChange(ctx, cluster, name, change.ID, partitions, configs, time.Now()); err != nil {
+			return err
+		}
+		if applied == nil {
+			logger.Warn("Change request was closed while applying")
+			return utils.NewConflictError("change request changed while applying")
+		}
+		if err := s.recordAudit(ctx, "topic.change_applied", "topic", topicResource(cluster, name), topic, applied); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.change_applied", applied)
+	})
+	if err != nil {
+		logger.Error("Failed to store applied topic change")
+		return nil, err
+	}
+	logger.Infof("Topic change applied, revision %d", applied.Revision)
+	return applied, nil
+}
+
+// recordChangeFailure stores the broker error of a failed attempt to apply the
+// topic's change and moves the change to FAILED on the last attempt
+func (s *Service) recordChangeFailure(ctx context.Context, topic *models.Topic, brokerErr error) {
+	change := topic.Change
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.store.Topics.RecordChangeFailure(ctx, topic.Cluster, topic.Name, change.ID, brokerErr.Error(), time.Now()); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "topic.change_failed", "topic", topicResource(topic.Cluster, topic.Name),
+			map[string]interface{}{"applyError": change.ApplyError, "applyAttempts": change.ApplyAttempts},
+			map[string]interface{}{"applyError": brokerErr.Error(), "applyAttempts": change.ApplyAttempts + 1},
+		)
+	})
+	if err != nil {
+		utils.GetLogger().Error("Failed to record topic change failure")
+		return
+	}
+	if change.ApplyAttempts+1 >= s.provisionMaxAttempts {
+		s.failChange(ctx, topic, fmt.Sprintf("Applying the change failed %d times: %s", change.ApplyAttempts+1, brokerErr.Error()))
+	}
+}
+
+// failChange moves a change the provisioner gave up on to FAILED
+func (s *Service) failChange(ctx context.Context, topic *models.Topic, reason string) {
+	logger := utils.GetLogger()
+	change := topic.Change
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		failed, err := s.store.Topics.FailChange(ctx, topic.Cluster, topic.Name, change.ID, time.Now())
+		if err != nil {
+			return err
+		}
+		if failed == nil {
+			return utils.NewConflictError("change request changed while failing it")
+		}
+		if err := s.recordAudit(ctx, "topic.change_exhausted", "topic", topicResource(topic.Cluster, topic.Name),
+			map[string]interface{}{"change": change.ID, "status": change.Status},
+			map[string]interface{}{"change": change.ID, "status": models.ChangeFailed, "reason": reason},
+		); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.change_exhausted", failed)
+	})
+	if err != nil {
+		logger.Error("Failed to mark topic change as failed")
+		return
+	}
+	logger.Warnf("Change of topic %s failed: %s", topic.Name, reason)
+}
+
+// RetryTopicChange moves a FAILED change back to APPROVED with its attempts
+// cleared and starts applying it again
+func (s *Service) RetryTopicChange(ctx context.Context, cluster, name, actor, reason string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Processing topic change retry")
+
+	if s.adminConnector == nil {
+		logger.Error("No Kafka admin client configured")
+		return nil, utils.NewConflictError("kafka provisioning is not configured")
+	}
+	if reason == "" {
+		reason = "Change retried"
+	}
+
+	topic, err := s.openChange(ctx, cluster, name, models.ChangeFailed)
+	if err != nil {
+		logger.Error("Failed to retrieve change request for retry")
+		return nil, err
+	}
+
+	var retried *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if retried, err = s.store.Topics.RetryChange(ctx, cluster, name, topic.Change.ID, time.Now()); err != nil {
+			return err
+		}
+		if retried == nil {
+			logger.Error("Change request changed while retrying")
+			return utils.NewConflictError("change request changed, retry the request")
+		}
+		if err := s.recordAudit(ctx, "topic.change_retried", "topic", topicResource(cluster, name),
+			map[string]interface{}{"change": topic.Change},
+			map[string]interface{}{"status": models.ChangeApproved, "retriedBy": actor, "reason": reason},
+		); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.change_retried", retried)
+	})
+	if err != nil {
+		logger.Error("Failed to reset topic change attempts")
+		return nil, err
+	}
+	logger.Info("Topic change reset")
+
+	s.applyAfterApproval(cluster, name)
+	return retried, nil
+}
+
+// CancelTopicChange closes a FAILED change without applying it. A failed
+// deletion returns the topic to ACTIVE, as CancelTopicDeletion does.
+func (s *Service) CancelTopicChange(ctx context.Context, cluster, name, actor, reason string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Processing topic change cancellation")
+
+	topic, err := s.openChange(ctx, cluster, name, models.ChangeFailed)
+	if err != nil {
+		logger.Error("Failed to retrieve change request for cancellation")
+		return nil, err
+	}
+	if topic.Change.Kind == models.ChangeDelete {
+		return s.CancelTopicDeletion(ctx, cluster, name, actor, reason)
+	}
+	if reason == "" {
+		reason = "Change cancelled"
+	}
+
+	var cancelled *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if cancelled, err = s.store.Topics.CloseChange(ctx, cluster, name, topic.Change.ID, models.ChangeFailed); err != nil {
+			return err
+		}
+		if cancelled == nil {
+			logger.Error("Change request changed while cancelling")
+			return utils.NewConflictError("change request changed, retry the request")
+		}
+		if err := s.recordAudit(ctx, "topic.change_cancelled", "topic", topicResource(cluster, name),
+			map[string]interface{}{"change": topic.Change},
+			map[string]interface{}{"cancelledBy": actor, "reason": reason},
+		); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.change_cancelled", cancelled)
+	})
+	if err != nil {
+		logger.Error("Topic change cancellation failed")
+		return nil, err
+	}
+	logger.Info("Topic change cancelled successfully")
+	return cancelled, nil
+}
+
+// retryChanges applies approved changes and deletions whose grace period is
+// over, retrying failed attempts with the same backoff and attempt limit as
+// provisioning
+func (s *Service) retryChanges(ctx context.Context) {
+	logger := utils.GetLogger()
+
+	topics, err := s.store.Topics.ListApprovedChanges(ctx)
+	if err != nil {
+		logger.Error("Failed to list approved topic changes")
+		return
+	}
+
+	now := time.Now()
+	for _, topic := range topics {
+		change := topic.Change
+		if change.RemoveAfter != nil && now.Before(*change.RemoveAfter) {
+			continue
+		}
+		if change.ApplyAttempts >= s.provisionMaxAttempts {
+			// Left behind by an earlier run or a lower PROVISION_MAX_ATTEMPTS
+			s.failChange(ctx, &topic, fmt.Sprintf("Applying the change failed %d times: %s", change.ApplyAttempts, change.ApplyError))
+			continue
+		}
+		if change.LastApplyAttemptAt != nil && now.Before(change.LastApplyAttemptAt.Add(s.provisionBackoff(change.ApplyAttempts))) {
+			continue
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, s.provisionTimeout)
+		if _, err := s.ApplyTopicChange(attemptCtx, topic.Cluster, topic.Name); err != nil {
+			logger.Warnf("Attempt to apply change of topic %s failed", topic.Name)
+		}
+		cancel()
+	}
+}
+
+// applyAfterApproval applies an approved change right away instead of waiting for the next run
+func (s *Service) applyAfterApproval(cluster, name string) {
+	if s.adminConnector == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(utils.WithActor(context.Background(), provisionerActor), s.provisionTimeout)
+		defer cancel()
+		if _, err := s.ApplyTopicChange(ctx, cluster, name); err != nil {
+			utils.GetLogger().Warn("Applying change after approval failed, will retry")
+		}
+	}()
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+type configType int
+
+const (
+	configLong configType = iota
+	configInt
+	configDouble
+	configBool
+	configEnum
+	configList // comma-separated values from the enum
+)
+
+// topicConfigSpec describes the value type and range Kafka accepts for a topic config
+type topicConfigSpec struct {
+	typ       configType
+	min       float64
+	max       float64
+	values    []string
+	unlimited bool // -1 means no limit, e.g. retention.ms
+}
+
+// kafkaTopicConfigs are the topic-level configs a topic request may set
+var kafkaTopicConfigs = map[string]topicConfigSpec{
+	"cleanup.policy":                 {typ: configList, values: []string{"delete", "compact"}},
+	"compression.type":               {typ: configEnum, values: []string{"uncompressed", "zstd", "lz4", "snappy", "gzip", "producer"}},
+	"delete.retention.ms":            {typ: configLong, min: 0, max: math.MaxInt64},
+	"file.delete.delay.ms":           {typ: configLong, min: 0, max: math.MaxInt64},
+	"flush.messages":                 {typ: configLong, min: 1, max: math.MaxInt64},
+	"flush.ms":                       {typ: configLong, min: 0, max: math.MaxInt64},
+	"index.interval.bytes":           {typ: configInt, min: 0, max: math.MaxInt32},
+	"max.compaction.lag.ms":          {typ: configLong, min: 1, max: math.MaxInt64},
+	"max.message.bytes":              {typ: configInt, min: 0, max: math.MaxInt32},
+	"message.timestamp.type":         {typ: configEnum, values: []string{"CreateTime", "LogAppendTime"}},
+	"min.cleanable.dirty.ratio":      {typ: configDouble, min: 0, max: 1},
+	"min.compaction.lag.ms":          {typ: configLong, min: 0, max: math.MaxInt64},
+	"min.insync.replicas":            {typ: configInt, min: 1, max: math.MaxInt32},
+	"preallocate":                    {typ: configBool},
+	"retention.bytes":                {typ: configLong, min: -1, max: math.MaxInt64, unlimited: true},
+	"retention.ms":                   {typ: configLong, min: -1, max: math.MaxInt64, unlimited: true},
+	"segment.bytes":                  {typ: configInt, min: 14, max: math.MaxInt32},
+	"segment.index.bytes":            {typ: configInt, min: 4, max: math.MaxInt32},
+	"segment.jitter.ms":              {typ: configLong, min: 0, max: math.MaxInt64},
+	"segment.ms":                     {typ: configLong, min: 1, max: math.MaxInt64},
+	"unclean.leader.election.enable": {typ: configBool},
+}
+
+// ValidateTopicConfigs checks each config against the known Kafka topic configs,
+// their value types and ranges, and the limits of the target cluster
+func ValidateTopicConfigs(topic *models.Topic, cluster *models.Cluster) error {
+	keys := make([]string, 0, len(topic.Configs))
+	for key := range topic.Configs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := topic.Configs[key]
+		spec, ok := kafkaTopicConfigs[key]
+		if !ok {
+			return utils.NewInvalidInputError(fmt.Sprintf("Unknown topic config %q", key))
+		}
+
+		number, err := parseConfigValue(key, value, spec)
+		if err != nil {
+			return err
+		}
+
+		if limit, ok := cluster.ConfigLimits[key]; ok {
+			if err := checkConfigLimit(key, value, number, spec, limit, cluster.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if value, ok := topic.Configs["min.insync.replicas"]; ok {
+		minISR, _ := strconv.Atoi(value)
+		if minISR > topic.Replicas {
+			return utils.NewInvalidInputError(fmt.Sprintf("min.insync.replicas (%d) cannot exceed replicas (%d)", minISR, topic.Replicas))
+		}
+	}
+	return nil
+}
+
+// ValidateConfigLimits checks that a cluster's config limits refer to known configs
+// and only use bounds that fit the config type
+func ValidateConfigLimits(limits map[string]models.ConfigLimit) error {
+	for key, limit := range limits {
+		spec, ok := kafkaTopicConfigs[key]
+		if !ok {
+			return utils.NewInvalidInputError(fmt.Sprintf("Unknown topic config %q in config limits", key))
+		}
+
+		switch spec.typ {
+		case configEnum, configList:
+			if limit.Min != nil || limit.Max != nil {
+				return utils.NewInvalidInputError(fmt.Sprintf("Config limit for %q only supports allowed values", key))
+			}
+			for _, v := range limit.Allowed {
+				if !contains(spec.values, v) {
+					return utils.NewInvalidInputError(fmt.Sprintf("Invalid allowed value %q for %q", v, key))
+				}
+			}
+		case configBool:
+			for _, v := range limit.Allowed {
+				if v != "true" && v != "false" {
+					return utils.NewInvalidInputError(fmt.Sprintf("Invalid allowed value %q for %q", v, key))
+				}
+			}
+		default:
+			if len(limit.Allowed) > 0 {
+				return utils.NewInvalidInputError(fmt.Sprintf("Config limit for %q only supports min and max", key))
+			}
+			if limit.Min != nil && limit.Max != nil && *limit.Min > *limit.Max {
+				return utils.NewInvalidInputError(fmt.Sprintf("Config limit for %q has min greater than max", key))
+			}
+		}
+	}
+	return nil
+}
+
+// parseConfigValue checks the value against the config type and Kafka's own range,
+// returning the numeric value for numeric configs
+func parseConfigValue(key, value string, spec topicConfigSpec) (float64, error) {
+	invalid := func(expected string) error {
+		return utils.NewInvalidInputError(fmt.Sprintf("Invalid value %q for %s: expected %s", value, key, expected))
+	}
+
+	switch spec.typ {
+	case configLong, configInt:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, invalid("an integer")
+		}
+		if float64(n) < spec.min || float64(n) > spec.max {
+			return 0, invalid(fmt.Sprintf("an integer between %.0f and %.0f", spec.min, spec.max))
+		}
+		return float64(n), nil
+	case configDouble:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || f < spec.min || f > spec.max {
+			return 0, invalid(fmt.Sprintf("a number between %g and %g", spec.min, spec.max))
+		}
+		return f, nil
+	case configBool:
+		if value != "true" && value != "false" {
+			return 0, invalid("true or false")
+		}
+	case configEnum:
+		if !contains(spec.values, value) {
+			return 0, invalid("one of " + strings.Join(spec.values, ", "))
+		}
+	case configList:
+		for _, v := range strings.Split(value, ",") {
+			if !contains(spec.values, strings.TrimSpace(v)) {
+				return 0, invalid("a comma-separated list of " + strings.Join(spec.values, ", "))
+			}
+		}
+	}
+	return 0, nil
+}
+
+func checkConfigLimit(key, value string, number float64, spec topicConfigSpec, limit models.ConfigLimit, cluster string) error {
+	outOfRange := func(bound string) error {
+		return utils.NewInvalidInputError(fmt.Sprintf("%s=%s is not allowed on cluster %s: %s", key, value, cluster, bound))
+	}
+
+	switch spec.typ {
+	case configLong, configInt, configDouble:
+		// -1 means unlimited, which exceeds any cap
+		if spec.unlimited && number == -1 {
+			number = math.Inf(1)
+		}
+		if limit.Min != nil && number < *limit.Min {
+			return outOfRange(fmt.Sprintf("minimum is %g", *limit.Min))
+		}
+		if limit.Max != nil && number > *limit.Max {
+			return outOfRange(fmt.Sprintf("maximum is %g", *limit.Max))
+		}
+	case configList:
+		if len(limit.Allowed) == 0 {
+			return nil
+		}
+		for _, v := range strings.Split(value, ",") {
+			if !contains(limit.Allowed, strings.TrimSpace(v)) {
+				return outOfRange("allowed values are " + strings.Join(limit.Allowed, ", "))
+			}
+		}
+	default:
+		if len(limit.Allowed) > 0 && !contains(limit.Allowed, value) {
+			return outOfRange("allowed values are " + strings.Join(limit.Allowed, ", "))
+		}
+	}
+	return nil
+}
+
+func contains(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+func TestValidateTopicConfigs(t *testing.T) {
+	maxRetention, minSegment := 604800000.0, 1048576.0
+	cluster := &models.Cluster{Name: "prod", ConfigLimits: map[string]models.ConfigLimit{
+		"retention.ms":     {Max: &maxRetention},
+		"segment.bytes":    {Min: &minSegment},
+		"cleanup.policy":   {Allowed: []string{"delete"}},
+		"compression.type": {Allowed: []string{"zstd", "lz4"}},
+	}}
+
+	tests := []struct {
+		name     string
+		configs  models.TopicConfigs
+		replicas int
+		wantErr  string
+	}{
+		{name: "no configs"},
+		{
+			name: "allowed configs",
+			configs: models.TopicConfigs{
+				"retention.ms":              "86400000",
+				"cleanup.policy":            "delete",
+				"compression.type":          "zstd",
+				"min.cleanable.dirty.ratio": "0.5",
+				"preallocate":               "false",
+				"min.insync.replicas":       "2",
+			},
+			replicas: 3,
+		},
+		{
+			name:    "unknown config",
+			configs: models.TopicConfigs{"retention.hours": "24"},
+			wantErr: `Unknown topic config "retention.hours"`,
+		},
+		{
+			name:    "broker-only config",
+			configs: models.TopicConfigs{"log.retention.ms": "1000"},
+			wantErr: `Unknown topic config "log.retention.ms"`,
+		},
+		{
+			name:    "not an integer",
+			configs: models.TopicConfigs{"retention.ms": "1d"},
+			wantErr: `Invalid value "1d" for retention.ms: expected an integer`,
+		},
+		{
+			name:    "integer below the Kafka minimum",
+			configs: models.TopicConfigs{"segment.bytes": "10"},
+			wantErr: `Invalid value "10" for segment.bytes: expected an integer between 14 and 2147483647`,
+		},
+		{
+			name:    "integer above the int range",
+			configs: models.TopicConfigs{"max.message.bytes": "4294967296"},
+			wantErr: `Invalid value "4294967296" for max.message.bytes: expected an integer between 0 and 2147483647`,
+		},
+		{
+			name:    "double out of range",
+			configs: models.TopicConfigs{"min.cleanable.dirty.ratio": "1.5"},
+			wantErr: `Invalid value "1.5" for min.cleanable.dirty.ratio: expected a number between 0 and 1`,
+		},
+		{
+			name:    "bool",
+			configs: models.TopicConfigs{"preallocate": "yes"},
+			wantErr: `Invalid value "yes" for preallocate: expected true or false`,
+		},
+		{
+			name:    "enum",
+			configs: models.TopicConfigs{"message.timestamp.type": "EventTime"},
+			wantErr: `Invalid value "EventTime" for message.timestamp.type: expected one of CreateTime, LogAppendTime`,
+		},
+		{
+			name:    "list with an unknown value",
+			configs: models.TopicConfigs{"cleanup.policy": "delete,archive"},
+			wantErr: `Invalid value "delete,archive" for cleanup.policy: expected a comma-separated list of delete, compact`,
+		},
+		{
+			name:    "cluster maximum",
+			configs: models.TopicConfigs{"retention.ms": "700000000"},
+			wantErr: "retention.ms=700000000 is not allowed on cluster prod: maximum is 6.048e+08",
+		},
+		{
+			name:    "unlimited exceeds the cluster maximum",
+			configs: models.TopicConfigs{"retention.ms": "-1"},
+			wantErr: "retention.ms=-1 is not allowed on cluster prod: maximum is 6.048e+08",
+		},
+		{
+			name:    "cluster minimum",
+			configs: models.TopicConfigs{"segment.bytes": "1024"},
+			wantErr: "segment.bytes=1024 is not allowed on cluster prod: minimum is 1.048576e+06",
+		},
+		{
+			name:    "list value outside the cluster allow-list",
+			configs: models.TopicConfigs{"cleanup.policy": "delete, compact"},
+			wantErr: "cleanup.policy=delete, compact is not allowed on cluster prod: allowed values are delete",
+		},
+		{
+			name:    "enum value outside the cluster allow-list",
+			configs: models.TopicConfigs{"compression.type": "gzip"},
+			wantErr: "compression.type=gzip is not allowed on cluster prod: allowed values are zstd, lz4",
+		},
+		{
+			name:     "min.insync.replicas above the replicas",
+			configs:  models.TopicConfigs{"min.insync.replicas": "3"},
+			replicas: 2,
+			wantErr:  "min.insync.replicas (3) cannot exceed replicas (2)",
+		},
+		{
+			name:    "first invalid key in sorted order is reported",
+			configs: models.TopicConfigs{"segment.ms": "0", "preallocate": "maybe"},
+			wantErr: `Invalid value "maybe" for preallocate: expected true or false`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTopicConfigs(&models.Topic{Name: "orders", Configs: tt.configs, Replicas: tt.replicas}, cluster)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateTopicConfigs: %v", err)
+				}
+				return
+			}
+			var apiErr *utils.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+				t.Fatalf("ValidateTopicConfigs = %v, want a 400", err)
+			}
+			if apiErr.Message != tt.wantErr {
+				t.Errorf("message = %q, want %q", apiErr.Message, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"time"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+
+	"github.com/google/uuid"
+)
+
+var applicationPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]{0,127}$`)
+
+// AnalyzeTopicImpact lists the registered producers and consumers of a topic and
+// the topics its consumers produce to, which would lose their input if it were removed
+func (s *Service) AnalyzeTopicImpact(ctx context.Context, cluster, name string) (*models.TopicImpact, error) {
+	logger := utils.GetLogger()
+	logger.Info("Analysing topic deletion impact")
+
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		logger.Error("Failed to retrieve topic for impact analysis")
+		return nil, err
+	}
+	return s.topicImpact(ctx, topic)
+}
+
+func (s *Service) topicImpact(ctx context.Context, topic *models.Topic) (*models.TopicImpact, error) {
+	impact := &models.TopicImpact{
+		Producers:  []models.TopicClient{},
+		Consumers:  []models.TopicClient{},
+		Dependents: []models.TopicDependent{},
+	}
+	var consumers []string
+	for _, client := range topic.Clients {
+		if client.Role == models.ClientProducer {
+			impact.Producers = append(impact.Producers, client)
+			continue
+		}
+		impact.Consumers = append(impact.Consumers, client)
+		consumers = append(consumers, client.Application)
+	}
+	if len(consumers) == 0 {
+		return impact, nil
+	}
+
+	fed, err := s.store.Topics.FindProducedBy(ctx, consumers)
+	if err != nil {
+		utils.GetLogger().Error("Failed to look up topics produced by consumers")
+		return nil, err
+	}
+	for _, t := range fed {
+		if t.Cluster == topic.Cluster && t.Name == topic.Name {
+			continue
+		}
+		for _, client := range t.Clients {
+			if client.Role == models.ClientProducer && slices.Contains(consumers, client.Application) {
+				impact.Dependents = append(impact.Dependents, models.TopicDependent{
+					Cluster:     t.Cluster,
+					Name:        t.Name,
+					Status:      t.Status,
+					Application: client.Application,
+				})
+			}
+		}
+	}
+	return impact, nil
+}
+
+// RequestTopicDeletion opens a deletion request on an active topic, recording
+// its impact as seen at request time. The request goes through the approval
+// workflow of the topic's cluster like any other change.
+func (s *Service) RequestTopicDeletion(ctx context.Context, cluster, name, requester, reason string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Processing topic deletion request")
+
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		logger.Error("Failed to retrieve topic for deletion")
+		return nil, err
+	}
+	if topic.Status != models.TopicActive {
+		logger.Errorf("Topic is %s, cannot be deleted", topic.Status)
+		return nil, utils.NewConflictError("topic is " + string(topic.Status) + ", only ACTIVE topics can be deleted")
+	}
+	if topic.Change != nil {
+		return nil, changePendingError(topic)
+	}
+
+	impact, err := s.topicImpact(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	approval, err := s.approvalFor(ctx, cluster)
+	if err != nil {
+		logger.Error("Failed to look up approval workflow")
+		return nil, err
+	}
+
+	change := &models.TopicChange{
+		ID:          uuid.New().String(),
+		Kind:        models.ChangeDelete,
+		Status:      models.ChangePending,
+		Reason:      reason,
+		RequestedBy: requester,
+		RequestedAt: time.Now(),
+		Approval:    approval,
+		Impact:      impact,
+	}
+	var requested *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if requested, err = s.requestChange(ctx, topic, change); err != nil {
+			return err
+		}
+		if err := s.recordAudit(ctx, "topic.deletion_request", "topic", topicResource(cluster, name), topic, requested); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.deletion_request", requested)
+	})
+	if err != nil {
+		logger.Error("Failed to store topic deletion request")
+		return nil, err
+	}
+	logger.Info("Topic deletion requested successfully")
+	return requested, nil
+}
+
+// scheduleDeletion approves a deletion and deprecates the topic until the grace
+// period ends
+func (s *Service) scheduleDeletion(ctx context.Context, topic *models.Topic, approver string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+
+	transition, err := newTransition(topic, models.TopicDeprecated, approver, "Deletion approved")
+	if err != nil {
+		logger.Errorf("Topic deprecation rejected: %s", err.Error())
+		return nil, err
+	}
+	removeAfter := transition.At.Add(s.deletionGrace)
+
+	var scheduled *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if scheduled, err = s.store.Topics.ScheduleDeletion(ctx, topic.Cluster, topic.Name, topic.Change.ID, transition, removeAfter); err != nil {
+			return err
+		}
+		if scheduled == nil {
+			logger.Error("Deletion request changed while approving")
+			return utils.NewConflictError("deletion request changed, retry the request")
+		}
+		if err := s.recordAudit(ctx, "topic.deletion_approved", "topic", topicResource(topic.Cluster, topic.Name), topic, scheduled); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.deletion_approved", scheduled)
+	})
+	if err != nil {
+		logger.Error("Topic deletion approval failed")
+		return nil, err
+	}
+	logger.Infof("Topic deprecated, removal scheduled after %s", removeAfter.Format(time.RFC3339))
+	return scheduled, nil
+}
+
+// CancelTopicDeletion withdraws a pending deletion request, or returns a
+// deprecated topic to ACTIVE while its grace period lasts or after removing it
+// failed
+func (s *Service) CancelTopicDeletion(ctx context.Context, cluster, name, actor, reason string) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Processing topic deletion cancellation")
+
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		logger.Error("Failed to retrieve topic for deletion cancellation")
+		return nil, err
+	}
+	if topic.Change == nil || topic.Change.Kind != models.ChangeDelete {
+		return nil, utils.NewNotFoundError("topic has no open deletion request")
+	}
+	if reason == "" {
+		reason = "Deletion cancelled"
+	}
+
+	var cancelled *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if topic.Change.Status == models.ChangePending {
+			cancelled, err = s.store.Topics.CloseChange(ctx, cluster, name, topic.Change.ID, models.ChangePending)
+		} else {
+			var transition models.TopicTransition
+			transition, err = newTransition(topic, models.TopicActive, actor, reason)
+			if err == nil {
+				cancelled, err = s.store.Topics.CancelDeletion(ctx, cluster, name, topic.Change.ID, topic.Change.Status, transition)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if cancelled == nil {
+			logger.Error("Deletion request changed while cancelling")
+			return utils.NewConflictError("deletion request changed, retry the request")
+		}
+		if err := s.recordAudit(ctx, "topic.deletion_cancelled", "topic", topicResource(cluster, name),
+			map[string]interface{}{"status": topic.Status, "change": topic.Change},
+			map[string]interface{}{"status": cancelled.Status, "cancelledBy": actor, "reason": reason},
+		); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.deletion_cancelled", cancelled)
+	})
+	if err != nil {
+		logger.Error("Topic deletion cancellation failed")
+		return nil, err
+	}
+	logger.Info("Topic deletion cancelled successfully")
+	return cancelled, nil
+}
+
+// removeTopic deletes a deprecated topic from its cluster once the grace period
+// of its approved deletion is over, and marks it DELETED
+func (s *Service) removeTopic(ctx context.Context, topic *models.Topic) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	change := topic.Change
+
+	if change.RemoveAfter != nil && time.Now().Before(*change.RemoveAfter) {
+		logger.Error("Topic deletion grace period has not ended")
+		return nil, utils.NewConflictError("topic is deprecated until " + change.RemoveAfter.Format(time.RFC3339))
+	}
+	transition, err := newTransition(topic, models.TopicDeleted, provisionerActor, "Deletion grace period ended")
+	if err != nil {
+		return nil, err
+	}
+
+	admin, err := s.adminForCluster(ctx, topic.Cluster)
+	if err == nil {
+		err = admin.DeleteTopic(ctx, topic.Name)
+	}
+	if err != nil {
+		logger.Errorf("Removing topic from cluster failed: %s", err.Error())
+		s.recordChangeFailure(ctx, topic, err)
+		return nil, err
+	}
+
+	var deleted *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if deleted, err = s.store.Topics.MarkDeleted(ctx, topic.Cluster, topic.Name, change.ID, transition); err != nil {
+			return err
+		}
+		if deleted == nil {
+			logger.Warn("Deletion was cancelled while removing the topic")
+			return utils.NewConflictError("deletion request changed while removing the topic")
+		}
+		if err := s.recordAudit(ctx, "topic.deleted", "topic", topicResource(topic.Cluster, topic.Name), topic, deleted); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.deleted", deleted)
+	})
+	if err != nil {
+		logger.Error("Failed to store topic deletion")
+		return nil, err
+	}
+	logger.Info("Topic removed from cluster")
+	return deleted, nil
+}
+
+// RegisterTopicClient records an application as a producer or consumer of the topic
+func (s *Service) RegisterTopicClient(ctx context.Context, cluster, name string, client models.TopicClient) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Processing topic client registration")
+
+	if err := validateClient(client.Application, client.Role); err != nil {
+		return nil, err
+	}
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		logger.Error("Failed to retrieve topic for client registration")
+		return nil, err
+	}
+	if topic.Status == models.TopicDeleted || topic.Status == models.TopicRejected {
+		return nil, utils.NewConflictError("topic is " + string(topic.Status) + ", clients cannot be registered")
+	}
+
+	client.RegisteredAt = time.Now()
+	var registered *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if registered, err = s.store.Topics.AddClient(ctx, cluster, name, client); err != nil {
+			return err
+		}
+		if registered == nil {
+			return utils.NewAlreadyExistsError("application " + client.Application + " is already registered as " + string(client.Role))
+		}
+		if err := s.recordAudit(ctx, "topic.client_registered", "topic", topicResource(cluster, name), nil, client); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.client_registered", registered)
+	})
+	if err != nil {
+		logger.Error("Failed to store topic client")
+		return nil, err
+	}
+	logger.Info("Topic client registered successfully")
+	return registered, nil
+}
+
+// UnregisterTopicClient removes an application's registration in role
+func (s *Service) UnregisterTopicClient(ctx context.Context, cluster, name, application string, role models.ClientRole) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	logger.Info("Processing topic client removal")
+
+	if err := validateClient(application, role); err != nil {
+		return nil, err
+	}
+	var unregistered *models.Topic
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if unregistered, err = s.store.Topics.RemoveClient(ctx, cluster, name, application, role); err != nil {
+			return err
+		}
+		if unregistered == nil {
+			if _, err := s.store.Topics.Get(ctx, cluster, name); err != nil {
+				return err
+			}
+			return utils.NewNotFoundError("application " + application + " is not registered as " + string(role))
+		}
+		if err := s.recordAudit(ctx, "topic.client_unregistered", "topic", topicResource(cluster, name),
+			map[string]interface{}{"application": application, "role": role}, nil,
+		); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, "topic.client_unregistered", unregistered)
+	})
+	if err != nil {
+		logger.Error("Failed to remove topic client")
+		return nil, err
+	}
+	logger.Info("Topic client removed successfully")
+	return unregistered, nil
+}
+
+func validateClient(application string, role models.ClientRole) error {
+	if !applicationPattern.MatchString(application) {
+		return utils.NewInvalidInputError("Invalid application: use up to 128 letters, digits, ., _, : and -, starting with a letter or digit")
+	}
+	if role != models.ClientProducer && role != models.ClientConsumer {
+		return utils.NewInvalidInputError("Invalid role: expected producer or consumer")
+	}
+	return nil
+}
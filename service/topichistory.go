@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+const (
+	defaultRevisionPageSize = 50
+	maxRevisionPageSize     = 500
+)
+
+// recordRevision stores the topic as it is after a change, under the revision
+// number the repository gave it. It is written in the transaction of the change,
+// so a change is not kept without its revision.
+func (s *Service) recordRevision(ctx context.Context, action string, topic *models.Topic) error {
+	logger := utils.GetLogger()
+
+	actor := utils.RequestInfoFrom(ctx).Actor
+	if actor == "" {
+		actor = systemActor
+	}
+	snapshot := *topic
+	snapshot.Transitions = nil
+	revision := &models.TopicRevision{
+		TopicID:  topic.ID,
+		Revision: topic.Revision,
+		Action:   action,
+		Actor:    actor,
+		At:       time.Now().UTC().Truncate(time.Millisecond),
+		Topic:    &snapshot,
+	}
+
+	if err := s.store.Revisions.Insert(ctx, revision); err != nil {
+		logger.Errorf("Failed to record revision %d of topic %s: %s", topic.Revision, topicResource(topic.Cluster, topic.Name), err.Error())
+		return err
+	}
+	logger.Debugf("Topic revision %d recorded: %s by %s", topic.Revision, action, actor)
+	return nil
+}
+
+// TopicHistory returns a page of the topic's revisions, newest first and without
+// their snapshots. cursor is the NextCursor of the previous page, or empty for
+// the first page.
+func (s *Service) TopicHistory(ctx context.Context, cluster, name, cursor string, limit int) (*models.TopicRevisionPage, error) {
+	logger := utils.GetLogger()
+	logger.Info("Retrieving topic history")
+
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		logger.Error("Failed to retrieve topic for history")
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultRevisionPageSize
+	}
+	if limit > maxRevisionPageSize {
+		limit = maxRevisionPageSize
+	}
+	before := 0
+	if cursor != "" {
+		if before, err = strconv.Atoi(cursor); err != nil || before <= 1 {
+			return nil, utils.NewInvalidInputError("Invalid cursor")
+		}
+	}
+
+	// Fetch one extra revision to know whether another page follows
+	revisions, err := s.store.Revisions.List(ctx, topic.ID, before, limit+1)
+	if err != nil {
+		logger.Error("Failed to retrieve topic revisions")
+		return nil, err
+	}
+
+	page := &models.TopicRevisionPage{Revisions: revisions}
+	if len(revisions) > limit {
+		page.Revisions = revisions[:limit]
+		page.NextCursor = strconv.Itoa(page.Revisions[limit-1].Revision)
+	}
+	if page.Revisions == nil {
+		page.Revisions = []models.TopicRevision{}
+	}
+	logger.Infof("Topic history retrieved successfully, count: %d", len(page.Revisions))
+	return page, nil
+}
+
+// GetTopicRevision returns the topic as it was at revision
+func (s *Service) GetTopicRevision(ctx context.Context, cluster, name string, revision int) (*models.TopicRevision, error) {
+	logger := utils.GetLogger()
+	logger.Info("Retrieving topic revision")
+
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		logger.Error("Failed to retrieve topic for revision")
+		return nil, err
+	}
+	found, err := s.store.Revisions.Get(ctx, topic.ID, revision)
+	if err != nil {
+		logger.Error("Failed to retrieve topic revision")
+		return nil, err
+	}
+	logger.Info("Topic revision retrieved successfully")
+	return found, nil
+}
+
+// DiffTopicRevisions compares two revisions of a topic field by field. to <= 0
+// compares against the current revision.
+func (s *Service) DiffTopicRevisions(ctx context.Context, cluster, name string, from, to int) (*models.TopicDiff, error) {
+	logger := utils.GetLogger()
+	logger.Info("Comparing topic revisions")
+
+	topic, err := s.store.Topics.Get(ctx, cluster, name)
+	if err != nil {
+		logger.Error("Failed to retrieve topic for revision diff")
+		return nil, err
+	}
+	if to <= 0 {
+		to = topic.Revision
+	}
+	if from <= 0 {
+		return nil, utils.NewInvalidInputError("from must be a positive revision")
+	}
+
+	before, err := s.store.Revisions.Get(ctx, topic.ID, from)
+	if err != nil {
+		logger.Errorf("Failed to retrieve revision %d", from)
+		return nil, err
+	}
+	after, err := s.store.Revisions.Get(ctx, topic.ID, to)
+	if err != nil {
+		logger.Errorf("Failed to retrieve revision %d", to)
+		return nil, err
+	}
+
+	// The revision number differs by definition and is reported as From and To
+	changes := []models.AuditChange{}
+	for _, change := range diffAudit(before.Topic, after.Topic) {
+		if change.Field != "revision" {
+			changes = append(changes, change)
+		}
+	}
+	logger.Infof("Topic revisions compared, changed fields: %d", len(changes))
+	return &models.TopicDiff{From: from, To: to, Changes: changes}, nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"kafka-governance/db"
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+// topicWithRevisions stores a pending topic "orders" with revisions 1 to n
+func topicWithRevisions(t *testing.T, svc *Service, n int) *models.Topic {
+	t.Helper()
+	ctx := context.Background()
+	created, err := svc.CreateTopic(ctx, &models.Topic{Name: "orders", Cluster: "dev", RequestedBy: "alice"})
+	if err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	for revision := 2; revision <= n; revision++ {
+		if err := svc.store.Revisions.Insert(ctx, &models.TopicRevision{TopicID: created.ID, Revision: revision,
+			Action: "topic.ownership_update", Actor: "alice", At: time.Now(), Topic: created}); err != nil {
+			t.Fatalf("inserting revision %d: %v", revision, err)
+		}
+	}
+	return created
+}
+
+func TestTopicHistoryPages(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+	topicWithRevisions(t, svc, 5)
+
+	tests := []struct {
+		limit     int
+		wantPages [][]int
+	}{
+		{limit: 2, wantPages: [][]int{{5, 4}, {3, 2}, {1}}},
+		{limit: 1, wantPages: [][]int{{5}, {4}, {3}, {2}, {1}}},
+		// A page ending on the last revision has no next page
+		{limit: 5, wantPages: [][]int{{5, 4, 3, 2, 1}}},
+		{limit: 4, wantPages: [][]int{{5, 4, 3, 2}, {1}}},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.limit), func(t *testing.T) {
+			var pages [][]int
+			cursor := ""
+			for {
+				page, err := svc.TopicHistory(ctx, "dev", "orders", cursor, tt.limit)
+				if err != nil {
+					t.Fatalf("TopicHistory(%q): %v", cursor, err)
+				}
+				var revisions []int
+				for _, revision := range page.Revisions {
+					revisions = append(revisions, revision.Revision)
+					if revision.Topic != nil {
+						t.Errorf("revision %d has its snapshot in the history", revision.Revision)
+					}
+				}
+				pages = append(pages, revisions)
+				if page.NextCursor == "" {
+					break
+				}
+				if want := strconv.Itoa(revisions[len(revisions)-1]); page.NextCursor != want {
+					t.Errorf("nextCursor = %s, want the last revision of the page, %s", page.NextCursor, want)
+				}
+				if len(pages) > 5 {
+					t.Fatal("history does not end")
+				}
+				cursor = page.NextCursor
+			}
+			if !slices.EqualFunc(pages, tt.wantPages, slices.Equal[[]int]) {
+				t.Errorf("pages = %v, want %v", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestTopicHistoryRejectsInvalidCursors(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+	topicWithRevisions(t, svc, 3)
+
+	// Nothing comes before revision 1, so no page can start there
+	for _, cursor := range []string{"1", "0", "-2", "abc"} {
+		_, err := svc.TopicHistory(context.Background(), "dev", "orders", cursor, 10)
+		var apiErr *utils.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("cursor %q: err = %v, want a 400", cursor, err)
+		}
+	}
+}
+
+func TestDiffTopicRevisionsLeavesOutRevision(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+	topicWithRevisions(t, svc, 1)
+	if _, err := svc.TransitionTopic(ctx, "dev", "orders", models.TopicRejected, "bob", "No"); err != nil {
+		t.Fatalf("TransitionTopic: %v", err)
+	}
+
+	diff, err := svc.DiffTopicRevisions(ctx, "dev", "orders", 1, 0)
+	if err != nil {
+		t.Fatalf("DiffTopicRevisions: %v", err)
+	}
+	if diff.From != 1 || diff.To != 2 {
+		t.Errorf("diff is from %d to %d, want 1 to 2", diff.From, diff.To)
+	}
+	var fields []string
+	for _, change := range diff.Changes {
+		fields = append(fields, change.Field)
+	}
+	if slices.Contains(fields, "revision") {
+		t.Errorf("changed fields %v include the revision", fields)
+	}
+	if !slices.Contains(fields, "status") {
+		t.Errorf("changed fields %v, want status", fields)
+	}
+
+	same, err := svc.DiffTopicRevisions(ctx, "dev", "orders", 2, 2)
+	if err != nil || len(same.Changes) != 0 {
+		t.Errorf("diff of a revision with itself = %+v, %v, want no changes", same, err)
+	}
+}
+
+// failingRevisions fails every insert
+type failingRevisions struct {
+	db.RevisionRepository
+}
+
+func (failingRevisions) Insert(ctx context.Context, revision *models.TopicRevision) error {
+	return errors.New("server selection timeout")
+}
+
+func TestChangeFailsWhenItsRevisionCannotBeWritten(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+	created := topicWithRevisions(t, svc, 1)
+	svc.store.Revisions = failingRevisions{svc.store.Revisions}
+
+	if _, err := svc.CreateTopic(ctx, &models.Topic{Name: "payments", Cluster: "dev", RequestedBy: "alice"}); err == nil {
+		t.Fatal("CreateTopic succeeded without its revision")
+	}
+	var apiErr *utils.APIError
+	if _, err := svc.store.Topics.Get(ctx, "dev", "payments"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("payments was stored without its revision: %v", err)
+	}
+
+	if _, err := svc.TransitionTopic(ctx, "dev", "orders", models.TopicRejected, "bob", "No"); err == nil {
+		t.Fatal("TransitionTopic succeeded without its revision")
+	}
+	if topic := getTopic(t, svc, "orders"); topic.Status != models.TopicPending || topic.Revision != created.Revision {
+		t.Errorf("orders is %s at revision %d, want the change undone", topic.Status, topic.Revision)
+	}
+	// Nor is the audit event of the change kept
+	head, err := svc.store.Audit.Last(ctx)
+	if err != nil || head == nil || head.Action != "topic.create" || head.Resource != topicResource("dev", "orders") {
+		t.Errorf("audit head = %+v, %v, want the creation of orders", head, err)
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"regexp/syntax"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+const (
+	defaultTopicPageSize = 50
+	maxTopicPageSize     = 500
+
+	// topicCountLimit caps how far a listing counts matching topics; past it the
+	// total is reported as a lower bound
+	topicCountLimit = 10000
+
+	maxNameRegexLength = 256
+)
+
+// ParseTopicSort reads a sort order such as "name" or "-createdAt", where a
+// leading "-" sorts descending. An empty order sorts by name.
+func ParseTopicSort(value string) (models.TopicSort, error) {
+	sort := models.TopicSort{Field: strings.TrimPrefix(value, "-"), Desc: strings.HasPrefix(value, "-")}
+	switch sort.Field {
+	case "":
+		sort.Field = models.TopicSortName
+	case models.TopicSortName, models.TopicSortCluster, models.TopicSortStatus, models.TopicSortCreatedAt:
+	default:
+		return sort, utils.NewInvalidInputError("Invalid sort: expected name, cluster, status or createdAt, optionally prefixed with -")
+	}
+	return sort, nil
+}
+
+// sortName is the query form of a sort order, e.g. "-createdAt"
+func sortName(sort models.TopicSort) string {
+	if sort.Desc {
+		return "-" + sort.Field
+	}
+	return sort.Field
+}
+
+// validateTopicFilter rejects malformed filters before they reach the database
+func validateTopicFilter(filter models.TopicFilter) error {
+	for _, status := range filter.Statuses {
+		if !slices.Contains(models.TopicStatuses, status) {
+			return utils.NewInvalidInputError("Invalid status: " + string(status))
+		}
+	}
+	for key := range filter.Labels {
+		if !labelKeyPattern.MatchString(key) {
+			return utils.NewInvalidInputError("Invalid label key: " + key)
+		}
+	}
+	if filter.NameRegex != "" {
+		if len(filter.NameRegex) > maxNameRegexLength {
+			return utils.NewInvalidInputError("Name regex is too long")
+		}
+		if err := checkNameRegex(filter.NameRegex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNameRegex accepts the part of the regular expression syntax that Go and
+// MongoDB's PCRE engine read the same way: ASCII literals, character classes,
+// anchors, alternation, plain and (?:...) groups, escaped punctuation, \d and \w.
+// Flags, named groups and other escapes are rejected, as are repetitions nested
+// inside repetitions, which make PCRE backtrack exponentially.
+func checkNameRegex(pattern string) error {
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c >= utf8.RuneSelf:
+			return utils.NewInvalidInputError("Name regex must be ASCII")
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			if next := pattern[i]; isAlphanumeric(next) && next != 'd' && next != 'w' {
+				return utils.NewInvalidInputError(`Name regex escape \` + string(next) + ` is not supported`)
+			}
+		case c == '(' && strings.HasPrefix(pattern[i:], "(?") && !strings.HasPrefix(pattern[i:], "(?:"):
+			return utils.NewInvalidInputError("Name regex flags and named groups are not supported")
+		}
+	}
+
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return utils.NewInvalidInputError("Invalid name regex")
+	}
+	if nestedRepetition(re, false) {
+		return utils.NewInvalidInputError("Name regex must not repeat a repetition, e.g. (a+)*")
+	}
+	return nil
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// nestedRepetition reports whether a *, + or {n,m} appears inside another one
+func nestedRepetition(re *syntax.Regexp, repeated bool) bool {
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus, syntax.OpRepeat:
+		if repeated {
+			return true
+		}
+		repeated = true
+	}
+	for _, sub := range re.Sub {
+		if nestedRepetition(sub, repeated) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListTopics returns a page of topics matching filter in sort order. cursor is
+// the NextCursor of the previous page, or empty for the first page, and must
+// come from a listing with the same sort.
+func (s *Service) ListTopics(ctx context.Context, filter models.TopicFilter, sort models.TopicSort, cursor string, limit int) (*models.TopicPage, error) {
+	logger := utils.GetLogger()
+	logger.Info("Retrieving topics list")
+
+	if err := validateTopicFilter(filter); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultTopicPageSize
+	}
+	if limit > maxTopicPageSize {
+		limit = maxTopicPageSize
+	}
+
+	var after *models.Topic
+	if cursor != "" {
+		var err error
+		if after, err = decodeTopicCursor(cursor, sort); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fetch one extra topic to know whether another page follows
+	topics, err := s.store.Topics.Page(ctx, filter, sort, after, limit+1)
+	if err != nil {
+		logger.Error("Failed to retrieve topics list")
+		return nil, err
+	}
+	total, err := s.store.Topics.Count(ctx, filter, topicCountLimit)
+	if err != nil {
+		logger.Error("Failed to count topics")
+		return nil, err
+	}
+
+	page := &models.TopicPage{Topics: topics, Total: total, TotalExact: total < topicCountLimit}
+	if len(topics) > limit {
+		page.Topics = topics[:limit]
+		page.NextCursor = encodeTopicCursor(&page.Topics[limit-1], sort)
+	}
+	if page.Topics == nil {
+		page.Topics = []models.Topic{}
+	}
+	logger.Infof("Topics list retrieved successfully, count: %d", len(page.Topics))
+	return page, nil
+}
+
+// topicCursor is the position of the last topic of a page: the value of its sort
+// field and its id. Sort records the order the position belongs to.
+type topicCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func encodeTopicCursor(topic *models.Topic, sort models.TopicSort) string {
+	c := topicCursor{Sort: sortName(sort), ID: topic.ID}
+	switch sort.Field {
+	case models.TopicSortCluster:
+		c.Value = topic.Cluster
+	case models.TopicSortStatus:
+		c.Value = string(topic.Status)
+	case models.TopicSortCreatedAt:
+		c.Value = strconv.FormatInt(topic.CreatedAt.UnixMilli(), 10)
+	default:
+		c.Value = topic.Name
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeTopicCursor returns a topic carrying only the cursor's sort value and id
+func decodeTopicCursor(cursor string, sort models.TopicSort) (*models.Topic, error) {
+	invalid := utils.NewInvalidInputError("Invalid cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	var c topicCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return nil, invalid
+	}
+	if c.Sort != sortName(sort) {
+		return nil, utils.NewInvalidInputError("Cursor belongs to a listing sorted by " + c.Sort)
+	}
+
+	topic := &models.Topic{ID: c.ID}
+	switch sort.Field {
+	case models.TopicSortCluster:
+		topic.Cluster = c.Value
+	case models.TopicSortStatus:
+		topic.Status = models.TopicStatus(c.Value)
+	case models.TopicSortCreatedAt:
+		ms, err := strconv.ParseInt(c.Value, 10, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		topic.CreatedAt = time.UnixMilli(ms).UTC()
+	default:
+		topic.Name = c.Value
+	}
+	return topic, nil
+}
@@ -0,0 +1,33 @@
+package service
+
+import "testing"
+
+func TestCheckNameRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{pattern: `^orders\.`},
+		{pattern: `^(payments|billing)\.[a-z0-9-]+$`},
+		{pattern: `^(?:eu|us)-\d{2}\.\w+`},
+		{pattern: `events\.v[0-9]{1,3}$`},
+		{pattern: `(?i)orders`, wantErr: true},
+		{pattern: `(?P<domain>\w+)\.events`, wantErr: true},
+		{pattern: `orders\z`, wantErr: true},
+		{pattern: `\pL+`, wantErr: true},
+		{pattern: `\Qorders.\E`, wantErr: true},
+		{pattern: `caf\x{e9}`, wantErr: true},
+		{pattern: `café`, wantErr: true},
+		{pattern: `(a+)+$`, wantErr: true},
+		{pattern: `([a-z]*\.)*end`, wantErr: true},
+		{pattern: `(x{2,5}){3}`, wantErr: true},
+		{pattern: `orders[`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if err := checkNameRegex(tt.pattern); (err != nil) != tt.wantErr {
+				t.Errorf("checkNameRegex(%q) = %v, want error %v", tt.pattern, err, tt.wantErr)
+			}
+		})
+	}
+}
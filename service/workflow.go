@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"kafka-governance/models"
+	"kafka-governance/utils"
+)
+
+// 403 reason codes returned by topic approval
+const (
+	ReasonSelfApproval     = "SELF_APPROVAL"
+	ReasonNotStageApprover = "NOT_STAGE_APPROVER"
+	ReasonAlreadyApproved  = "ALREADY_APPROVED"
+)
+
+func (s *Service) CreateWorkflow(ctx context.Context, workflow models.ApprovalWorkflow) (*models.ApprovalWorkflow, error) {
+	logger := utils.GetLogger()
+	logger.Info("Creating new approval workflow")
+
+	if err := s.checkWorkflow(ctx, &workflow); err != nil {
+		return nil, err
+	}
+
+	var created *models.ApprovalWorkflow
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.store.Workflows.Insert(ctx, &workflow); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "workflow.create", "workflow", created.Name, nil, created)
+	})
+	if err != nil {
+		logger.Error("Workflow creation failed")
+		return nil, err
+	}
+	logger.Info("Workflow created successfully")
+	s.startPendingApprovals(ctx, created)
+	return created, nil
+}
+
+func (s *Service) ListWorkflows(ctx context.Context) ([]models.ApprovalWorkflow, error) {
+	logger := utils.GetLogger()
+	logger.Info("Retrieving workflows list")
+
+	workflows, err := s.store.Workflows.List(ctx)
+	if err != nil {
+		logger.Error("Failed to retrieve workflows list")
+		return nil, err
+	}
+	logger.Infof("Workflows list retrieved successfully, count: %d", len(workflows))
+	return workflows, nil
+}
+
+func (s *Service) GetWorkflow(ctx context.Context, name string) (*models.ApprovalWorkflow, error) {
+	logger := utils.GetLogger()
+	logger.Info("Retrieving workflow by name")
+
+	workflow, err := s.store.Workflows.GetByName(ctx, name)
+	if err != nil {
+		logger.Error("Failed to retrieve workflow")
+		return nil, err
+	}
+	logger.Info("Workflow retrieved successfully")
+	return workflow, nil
+}
+
+func (s *Service) UpdateWorkflow(ctx context.Context, name string, workflow models.ApprovalWorkflow) (*models.ApprovalWorkflow, error) {
+	logger := utils.GetLogger()
+	logger.Info("Updating workflow")
+
+	workflow.Name = name
+	if err := s.checkWorkflow(ctx, &workflow); err != nil {
+		return nil, err
+	}
+
+	var updated *models.ApprovalWorkflow
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.store.Workflows.GetByName(ctx, name)
+		if err != nil {
+			logger.Error("Failed to retrieve workflow for update")
+			return err
+		}
+		if updated, err = s.store.Workflows.Update(ctx, name, &workflow); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "workflow.update", "workflow", name, before, updated)
+	})
+	if err != nil {
+		logger.Error("Workflow update failed")
+		return nil, err
+	}
+	logger.Info("Workflow updated successfully")
+	s.startPendingApprovals(ctx, updated)
+	return updated, nil
+}
+
+func (s *Service) DeleteWorkflow(ctx context.Context, name string) error {
+	logger := utils.GetLogger()
+	logger.Info("Deleting workflow")
+
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.store.Workflows.GetByName(ctx, name)
+		if err != nil {
+			logger.Error("Failed to retrieve workflow for deletion")
+			return err
+		}
+		if err := s.store.Workflows.Delete(ctx, name); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "workflow.delete", "workflow", name, before, nil)
+	})
+	if err != nil {
+		logger.Error("Workflow deletion failed")
+		return err
+	}
+	logger.Info("Workflow deleted successfully")
+	return nil
+}
+
+// checkWorkflow makes sure the stage groups exist and that no other workflow is
+// attached to the same cluster or environment
+func (s *Service) checkWorkflow(ctx context.Context, workflow *models.ApprovalWorkflow) error {
+	groups := make([]string, 0, len(workflow.Stages))
+	for _, stage := range workflow.Stages {
+		groups = append(groups, stage.Group)
+	}
+	if err := s.checkGroupsExist(ctx, groups); err != nil {
+		return err
+	}
+
+	var existing *models.ApprovalWorkflow
+	var err error
+	if workflow.Cluster != "" {
+		if _, err := s.store.Clusters.GetByName(ctx, workflow.Cluster); err != nil {
+			if isNotFound(err) {
+				return utils.NewInvalidInputError(fmt.Sprintf("Cluster %q is not registered", workflow.Cluster))
+			}
+			return err
+		}
+		existing, err = s.store.Workflows.GetForCluster(ctx, workflow.Cluster)
+	} else {
+		existing, err = s.store.Workflows.GetForEnvironment(ctx, workflow.Environment)
+	}
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	if existing != nil && existing.Name != workflow.Name {
+		return utils.NewConflictError(fmt.Sprintf("workflow %s already applies to this scope", existing.Name))
+	}
+	return nil
+}
+
+// workflowForCluster returns the workflow that governs topics on a cluster: the
+// cluster's own workflow, else its environment's, else nil for single approval
+func (s *Service) workflowForCluster(ctx context.Context, clusterName string) (*models.ApprovalWorkflow, error) {
+	workflow, err := s.store.Workflows.GetForCluster(ctx, clusterName)
+	if err == nil || !isNotFound(err) {
+		return workflow, err
+	}
+
+	cluster, err := s.store.Clusters.GetByName(ctx, clusterName)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	workflow, err = s.store.Workflows.GetForEnvironment(ctx, cluster.Environment)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	return workflow, err
+}
+
+// approvalFor builds the initial workflow state for a topic on the cluster, or
+// nil when the cluster has no workflow
+func (s *Service) approvalFor(ctx context.Context, clusterName string) (*models.TopicApproval, error) {
+	workflow, err := s.workflowForCluster(ctx, clusterName)
+	if err != nil || workflow == nil {
+		return nil, err
+	}
+	return &models.TopicApproval{
+		Workflow:        workflow.Name,
+		WorkflowVersion: workflow.Version,
+		Stages:          workflow.Stages,
+		Stage:           0,
+		Votes:           []models.ApprovalVote{},
+	}, nil
+}
+
+// startPendingApprovals puts pending topic requests that were made before the
+// workflow governed their cluster into it, so they cannot skip its stages.
+// Requests that already follow a workflow keep the version they recorded.
+func (s *Service) startPendingApprovals(ctx context.Context, workflow *models.ApprovalWorkflow) {
+	logger := utils.GetLogger()
+
+	pending, err := s.store.Topics.ListMatching(ctx, models.TopicFilter{
+		Cluster:  workflow.Cluster,
+		Statuses: []models.TopicStatus{models.TopicPending},
+	})
+	if err != nil {
+		logger.Error("Failed to list pending topics for the workflow")
+		return
+	}
+	for _, topic := range pending {
+		if topic.Approval != nil {
+			continue
+		}
+		approval, err := s.approvalFor(ctx, topic.Cluster)
+		if err != nil {
+			logger.Errorf("Failed to look up the workflow of topic %s", topic.Name)
+			continue
+		}
+		if approval == nil || approval.Workflow != workflow.Name {
+			continue
+		}
+		var started *models.Topic
+		err = s.inTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			if started, err = s.store.Topics.StartApproval(ctx, topic.Cluster, topic.Name, approval); err != nil || started == nil {
+				return err
+			}
+			return s.recordRevision(ctx, "topic.workflow_started", started)
+		})
+		if err != nil {
+			logger.Errorf("Failed to start the workflow of topic %s", topic.Name)
+			continue
+		}
+		if started != nil {
+			logger.Infof("Pending topic %s now follows workflow %s", topic.Name, workflow.Name)
+		}
+	}
+}
+
+// voteOnTopic records the approver's vote on a pending topic request
+func (s *Service) voteOnTopic(ctx context.Context, topic *models.Topic, approver *models.Principal, reason string) (*models.Topic, error) {
+	if topic.Status != models.TopicPending {
+		_, err := newTransition(topic, models.TopicApproved, approver.Subject, reason)
+		return nil, err
+	}
+
+	return s.castVote(ctx, topic, approver, reason, approvalFlow{
+		subject:     "topic",
+		voteAction:  "topic.approval_vote",
+		stageAction: "topic.approval_stage",
+		approval:    func(t *models.Topic) *models.TopicApproval { return t.Approval },
+		record: func(ctx context.Context, vote models.ApprovalVote) (*models.Topic, error) {
+			return s.store.Topics.RecordApprovalVote(ctx, topic.Cluster, topic.Name, vote)
+		},
+		advance: func(ctx context.Context, stage int) (*models.Topic, error) {
+			return s.store.Topics.AdvanceApprovalStage(ctx, topic.Cluster, topic.Name, stage)
+		},
+		finish: func(ctx context.Context, voted *models.Topic) (*models.Topic, error) {
+			return s.finishApproval(ctx, voted, approver.Subject, reason)
+		},
+	})
+}
+
+// approvalFlow binds the stage voting of castVote to one kind of request on a
+// topic: the topic request itself or a change request
+type approvalFlow struct {
+	subject     string // what is being approved, for messages
+	voteAction  string
+	stageAction string
+	approval    func(*models.Topic) *models.TopicApproval
+	record      func(ctx context.Context, vote models.ApprovalVote) (*models.Topic, error)
+	advance     func(ctx context.Context, stage int) (*models.Topic, error)
+	finish      func(ctx context.Context, voted *models.Topic) (*models.Topic, error)
+}
+
+// castVote records the approver's vote against the current stage and advances
+// the request once the stage has its quorum. Approval of the last stage finishes
+// the request. Each user approves a request at most once, so one person cannot
+// satisfy two stages.
+func (s *Service) castVote(ctx context.Context, topic *models.Topic, approver *models.Principal, reason string, flow approvalFlow) (*models.Topic, error) {
+	logger := utils.GetLogger()
+	approval := flow.approval(topic)
+
+	for _, vote := range approval.Votes {
+		if vote.Approver == approver.Subject {
+			logger.Errorf("%s already approved %s", approver.Subject, flow.subject)
+			return nil, utils.NewForbiddenReason(
+				ReasonAlreadyApproved,
+				"You have already approved this "+flow.subject,
+				map[string]interface{}{"topic": topic.Name, "stage": vote.Stage},
+			)
+		}
+	}
+
+	stage := approval.Stages[approval.Stage]
+	member, err := s.inGroup(ctx, approver, stage.Group)
+	if err != nil {
+		return nil, err
+	}
+	if !member {
+		logger.Errorf("%s is not an approver for stage %s", approver.Subject, stage.Name)
+		return nil, utils.NewForbiddenReason(
+			ReasonNotStageApprover,
+			fmt.Sprintf("Stage %q must be approved by a member of %s", stage.Name, stage.Group),
+			map[string]interface{}{"topic": topic.Name, "stage": stage.Name, "group": stage.Group},
+		)
+	}
+
+	vote := models.ApprovalVote{
+		Stage:    approval.Stage,
+		Approver: approver.Subject,
+		Reason:   reason,
+		At:       time.Now(),
+	}
+	var voted *models.Topic
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if voted, err = flow.record(ctx, vote); err != nil {
+			return err
+		}
+		if voted == nil {
+			logger.Errorf("Approval of %s changed while voting", flow.subject)
+			return utils.NewConflictError(flow.subject + " approval changed, retry the request")
+		}
+		if err := s.recordAudit(ctx, flow.voteAction, "topic", topicResource(topic.Cluster, topic.Name), topic, voted); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, flow.voteAction, voted)
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("Approval vote recorded for stage %s", stage.Name)
+
+	votedApproval := flow.approval(voted)
+	votes := 0
+	for _, vote := range votedApproval.Votes {
+		if vote.Stage == votedApproval.Stage {
+			votes++
+		}
+	}
+	if votes < stage.MinApprovals {
+		logger.Infof("Stage %s has %d of %d approvals", stage.Name, votes, stage.MinApprovals)
+		return voted, nil
+	}
+
+	if votedApproval.Stage < len(votedApproval.Stages)-1 {
+		var advanced *models.Topic
+		err := s.inTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			if advanced, err = flow.advance(ctx, votedApproval.Stage); err != nil || advanced == nil {
+				return err
+			}
+			if err := s.recordAudit(ctx, flow.stageAction, "topic", topicResource(topic.Cluster, topic.Name), voted, advanced); err != nil {
+				return err
+			}
+			return s.recordRevision(ctx, flow.stageAction, advanced)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if advanced == nil {
+			// A concurrent vote completed the stage first
+			return s.store.Topics.Get(ctx, topic.Cluster, topic.Name)
+		}
+		logger.Infof("Stage %s approved, %s moved to the next stage", stage.Name, flow.subject)
+		return advanced, nil
+	}
+
+	logger.Info("Final approval stage reached quorum")
+	return flow.finish(ctx, voted)
+}
+
+// inGroup reports whether the principal is in group through their token or the directory
+func (s *Service) inGroup(ctx context.Context, principal *models.Principal, group string) (bool, error) {
+	entity, err := s.ResolvePrincipal(ctx, PrincipalEntity(principal))
+	if err != nil {
+		return false, err
+	}
+	uid := utils.EntityUID("Group", group)
+	for _, parent := range entity.Parents {
+		if parent == uid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isNotFound(err error) bool {
+	var apiErr *utils.APIError
+	return errors.As(err, &apiErr) && apiErr.Type == utils.ErrNotFound
+}
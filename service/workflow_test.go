@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"kafka-governance/models"
+)
+
+func TestWorkflowVersionRecordedOnRequests(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"platform", "security"} {
+		if _, err := svc.store.Groups.Insert(ctx, &models.Group{Name: name}); err != nil {
+			t.Fatalf("inserting group: %v", err)
+		}
+	}
+
+	// Requested before the cluster had a workflow
+	early, err := svc.CreateTopic(ctx, &models.Topic{Name: "orders", Cluster: "dev", Partitions: 3, Replicas: 1, RequestedBy: "alice"})
+	if err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	if early.Approval != nil {
+		t.Fatalf("approval = %+v before any workflow", early.Approval)
+	}
+
+	workflow, err := svc.CreateWorkflow(ctx, models.ApprovalWorkflow{Name: "dev-signoff", Cluster: "dev",
+		Stages: []models.ApprovalStage{{Name: "platform", Group: "platform", MinApprovals: 1}}})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	if workflow.Version != 1 {
+		t.Errorf("version = %d, want 1", workflow.Version)
+	}
+	if approval := getTopic(t, svc, "orders").Approval; approval == nil || approval.Workflow != "dev-signoff" || approval.WorkflowVersion != 1 {
+		t.Fatalf("pending request approval = %+v, want dev-signoff version 1", approval)
+	}
+
+	// Requests keep the version they recorded when the workflow changes
+	updated, err := svc.UpdateWorkflow(ctx, "dev-signoff", models.ApprovalWorkflow{Cluster: "dev",
+		Stages: []models.ApprovalStage{
+			{Name: "platform", Group: "platform", MinApprovals: 1},
+			{Name: "security", Group: "security", MinApprovals: 2},
+		}})
+	if err != nil {
+		t.Fatalf("UpdateWorkflow: %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("version after update = %d, want 2", updated.Version)
+	}
+	if approval := getTopic(t, svc, "orders").Approval; approval.WorkflowVersion != 1 || len(approval.Stages) != 1 {
+		t.Errorf("pending request approval = %+v, want version 1 with one stage", approval)
+	}
+
+	late, err := svc.CreateTopic(ctx, &models.Topic{Name: "payments", Cluster: "dev", Partitions: 3, Replicas: 1, RequestedBy: "alice"})
+	if err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	if late.Approval == nil || late.Approval.WorkflowVersion != 2 || len(late.Approval.Stages) != 2 {
+		t.Errorf("new request approval = %+v, want version 2 with two stages", late.Approval)
+	}
+}
@@ -1,6 +1,118 @@
 package utils
 
-func EvaluatePolicy(inputFile string) (bool, error) {
-	// TODO: integrate Cedar CLI via Docker exec
-	return true, nil
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"kafka-governance/models"
+)
+
+const (
+	EffectPermit = "permit"
+	EffectForbid = "forbid"
+
+	// wildcardScope leaves a policy scope unconstrained, like a bare `principal` in Cedar
+	wildcardScope = "*"
+)
+
+// EntityUID formats a Cedar entity reference, e.g. EntityUID("User", "u_123") -> User::"u_123"
+func EntityUID(entityType, id string) string {
+	return entityType + "::" + strconv.Quote(id)
+}
+
+// ParseEntityUID splits a Cedar entity reference into its type and id. The id
+// starts at the first `::"`, so it may itself contain `::`.
+func ParseEntityUID(uid string) (string, string, error) {
+	idx := strings.Index(uid, `::"`)
+	if idx <= 0 {
+		return "", "", fmt.Errorf("invalid entity reference %q", uid)
+	}
+	id, err := strconv.Unquote(uid[idx+2:])
+	if err != nil {
+		return "", "", fmt.Errorf("invalid entity id in %q", uid)
+	}
+	return uid[:idx], id, nil
+}
+
+// EvaluatePolicy evaluates an authorization request against the given policies using
+// Cedar semantics: a matching forbid always wins, otherwise a matching permit allows,
+// and with no matching policy the request is denied. The decision lists the IDs of
+// the policies that determined it.
+func EvaluatePolicy(req models.AuthzRequest, policies []models.Policy) (models.AuthzDecision, error) {
+	if _, _, err := ParseEntityUID(req.Principal.UID); err != nil {
+		return models.AuthzDecision{}, err
+	}
+	if _, _, err := ParseEntityUID(req.Action); err != nil {
+		return models.AuthzDecision{}, err
+	}
+	if _, _, err := ParseEntityUID(req.Resource.UID); err != nil {
+		return models.AuthzDecision{}, err
+	}
+
+	var permits, forbids []string
+	for _, p := range policies {
+		if !policyApplies(p, req) {
+			continue
+		}
+		switch p.Effect {
+		case EffectForbid:
+			forbids = append(forbids, p.ID)
+		case EffectPermit:
+			permits = append(permits, p.ID)
+		}
+	}
+
+	if len(forbids) > 0 {
+		return models.AuthzDecision{Allowed: false, Policies: forbids}, nil
+	}
+	if len(permits) > 0 {
+		return models.AuthzDecision{Allowed: true, Policies: permits}, nil
+	}
+	return models.AuthzDecision{Allowed: false, Policies: []string{}}, nil
+}
+
+// policyApplies reports whether the policy scope and conditions match the request
+func policyApplies(p models.Policy, req models.AuthzRequest) bool {
+	if !scopeMatches(p.Principal, req.Principal) {
+		return false
+	}
+	if !scopeMatches(p.Action, models.Entity{UID: req.Action}) {
+		return false
+	}
+	if !scopeMatches(p.Resource, req.Resource) {
+		return false
+	}
+	for key, want := range p.Conditions {
+		if got, ok := req.Context[key]; !ok || got != want {
+			return false
+		}
+	}
+	return true
+}
+
+// scopeMatches implements the `in` operator of a Cedar scope: the entity matches
+// when it is the scope entity itself or one of its descendants. `*` matches any
+// entity and Type::"*" matches any entity of that type.
+func scopeMatches(scope string, entity models.Entity) bool {
+	if scope == "" || scope == wildcardScope {
+		return true
+	}
+
+	scopeType, scopeID, err := ParseEntityUID(scope)
+	if err != nil {
+		return false
+	}
+	if scopeID == wildcardScope {
+		entityType, _, err := ParseEntityUID(entity.UID)
+		return err == nil && entityType == scopeType
+	}
+
+	for _, uid := range append([]string{entity.UID}, entity.Parents...) {
+		entityType, entityID, err := ParseEntityUID(uid)
+		if err == nil && entityType == scopeType && entityID == scopeID {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"kafka-governance/models"
+)
+
+func TestEvaluatePolicy(t *testing.T) {
+	alice := models.Entity{UID: `User::"alice"`, Parents: []string{`Group::"payments"`, `Role::"developer"`}}
+	orders := models.Entity{UID: `Topic::"orders"`, Parents: []string{`Cluster::"prod"`, `Environment::"prod"`}}
+	request := models.AuthzRequest{
+		Principal: alice,
+		Action:    `Action::"CreateTopic"`,
+		Resource:  orders,
+		Context:   map[string]string{"environment": "prod", "owner": "true"},
+	}
+
+	tests := []struct {
+		name     string
+		policies []models.Policy
+		req      models.AuthzRequest
+		allowed  bool
+		decided  []string
+	}{
+		{
+			name:    "no policies denies",
+			req:     request,
+			decided: []string{},
+		},
+		{
+			name: "unrelated permit denies",
+			policies: []models.Policy{
+				{ID: "p1", Principal: `User::"bob"`, Action: "*", Resource: "*", Effect: EffectPermit},
+				{ID: "p2", Principal: "*", Action: `Action::"DeleteTopic"`, Resource: "*", Effect: EffectPermit},
+			},
+			req:     request,
+			decided: []string{},
+		},
+		{
+			name:     "exact permit",
+			policies: []models.Policy{{ID: "p1", Principal: `User::"alice"`, Action: `Action::"CreateTopic"`, Resource: `Topic::"orders"`, Effect: EffectPermit}},
+			req:      request,
+			allowed:  true,
+			decided:  []string{"p1"},
+		},
+		{
+			name: "forbid overrides permit",
+			policies: []models.Policy{
+				{ID: "p1", Principal: "*", Action: "*", Resource: "*", Effect: EffectPermit},
+				{ID: "f1", Principal: `Group::"payments"`, Action: `Action::"CreateTopic"`, Resource: `Cluster::"prod"`, Effect: EffectForbid},
+				{ID: "p2", Principal: `User::"alice"`, Action: "*", Resource: "*", Effect: EffectPermit},
+			},
+			req:     request,
+			decided: []string{"f1"},
+		},
+		{
+			name: "forbid that does not apply",
+			policies: []models.Policy{
+				{ID: "p1", Principal: "*", Action: "*", Resource: "*", Effect: EffectPermit},
+				{ID: "f1", Principal: "*", Action: "*", Resource: `Cluster::"staging"`, Effect: EffectForbid},
+			},
+			req:     request,
+			allowed: true,
+			decided: []string{"p1"},
+		},
+		{
+			name:     "wildcard scopes",
+			policies: []models.Policy{{ID: "p1", Principal: "*", Action: "*", Resource: "*", Effect: EffectPermit}},
+			req:      request,
+			allowed:  true,
+			decided:  []string{"p1"},
+		},
+		{
+			name:     "type wildcard matches the type",
+			policies: []models.Policy{{ID: "p1", Principal: `User::"*"`, Action: `Action::"CreateTopic"`, Resource: `Topic::"*"`, Effect: EffectPermit}},
+			req:      request,
+			allowed:  true,
+			decided:  []string{"p1"},
+		},
+		{
+			name:     "type wildcard does not match parents of the type",
+			policies: []models.Policy{{ID: "p1", Principal: `Group::"*"`, Action: "*", Resource: "*", Effect: EffectPermit}},
+			req:      request,
+			decided:  []string{},
+		},
+		{
+			name:     "type wildcard of another type",
+			policies: []models.Policy{{ID: "p1", Principal: "*", Action: "*", Resource: `Cluster::"*"`, Effect: EffectPermit}},
+			req:      request,
+			decided:  []string{},
+		},
+		{
+			name:     "principal in group",
+			policies: []models.Policy{{ID: "p1", Principal: `Group::"payments"`, Action: "*", Resource: "*", Effect: EffectPermit}},
+			req:      request,
+			allowed:  true,
+			decided:  []string{"p1"},
+		},
+		{
+			name:     "principal in role",
+			policies: []models.Policy{{ID: "p1", Principal: `Role::"developer"`, Action: "*", Resource: "*", Effect: EffectPermit}},
+			req:      request,
+			allowed:  true,
+			decided:  []string{"p1"},
+		},
+		{
+			name:     "resource in environment",
+			policies: []models.Policy{{ID: "p1", Principal: "*", Action: "*", Resource: `Environment::"prod"`, Effect: EffectPermit}},
+			req:      request,
+			allowed:  true,
+			decided:  []string{"p1"},
+		},
+		{
+			name:     "group the principal is not in",
+			policies: []models.Policy{{ID: "p1", Principal: `Group::"admins"`, Action: "*", Resource: "*", Effect: EffectPermit}},
+			req:      request,
+			decided:  []string{},
+		},
+		{
+			name: "matching conditions",
+			policies: []models.Policy{{ID: "p1", Principal: "*", Action: "*", Resource: "*", Effect: EffectPermit,
+				Conditions: map[string]string{"environment": "prod", "owner": "true"}}},
+			req:     request,
+			allowed: true,
+			decided: []string{"p1"},
+		},
+		{
+			name: "condition with another value",
+			policies: []models.Policy{{ID: "p1", Principal: "*", Action: "*", Resource: "*", Effect: EffectPermit,
+				Conditions: map[string]string{"environment": "dev"}}},
+			req:     request,
+			decided: []string{},
+		},
+		{
+			name: "condition missing from the context",
+			policies: []models.Policy{{ID: "p1", Principal: "*", Action: "*", Resource: "*", Effect: EffectPermit,
+				Conditions: map[string]string{"team": "payments"}}},
+			req:     request,
+			decided: []string{},
+		},
+		{
+			name: "conditional forbid only applies when it matches",
+			policies: []models.Policy{
+				{ID: "p1", Principal: "*", Action: "*", Resource: "*", Effect: EffectPermit},
+				{ID: "f1", Principal: "*", Action: "*", Resource: "*", Effect: EffectForbid, Conditions: map[string]string{"owner": "false"}},
+			},
+			req:     request,
+			allowed: true,
+			decided: []string{"p1"},
+		},
+		{
+			name: "every matching permit is reported",
+			policies: []models.Policy{
+				{ID: "p1", Principal: `Group::"payments"`, Action: "*", Resource: "*", Effect: EffectPermit},
+				{ID: "p2", Principal: "*", Action: `Action::"CreateTopic"`, Resource: `Cluster::"prod"`, Effect: EffectPermit},
+			},
+			req:     request,
+			allowed: true,
+			decided: []string{"p1", "p2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := EvaluatePolicy(tt.req, tt.policies)
+			if err != nil {
+				t.Fatalf("EvaluatePolicy: %v", err)
+			}
+			if decision.Allowed != tt.allowed || !reflect.DeepEqual(decision.Policies, tt.decided) {
+				t.Errorf("decision = %+v, want allowed %v by %v", decision, tt.allowed, tt.decided)
+			}
+		})
+	}
+}
+
+func TestEvaluatePolicyRejectsMalformedRequests(t *testing.T) {
+	valid := models.AuthzRequest{
+		Principal: models.Entity{UID: `User::"alice"`},
+		Action:    `Action::"CreateTopic"`,
+		Resource:  models.Entity{UID: `Topic::"orders"`},
+	}
+	policies := []models.Policy{{ID: "p1", Principal: "*", Action: "*", Resource: "*", Effect: EffectPermit}}
+
+	for name, mutate := range map[string]func(*models.AuthzRequest){
+		"principal": func(r *models.AuthzRequest) { r.Principal.UID = "alice" },
+		"action":    func(r *models.AuthzRequest) { r.Action = "CreateTopic" },
+		"resource":  func(r *models.AuthzRequest) { r.Resource.UID = `Topic::orders` },
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := valid
+			mutate(&req)
+			if decision, err := EvaluatePolicy(req, policies); err == nil {
+				t.Errorf("EvaluatePolicy = %+v, want an error", decision)
+			}
+		})
+	}
+}
+
+func TestParseEntityUID(t *testing.T) {
+	tests := []struct {
+		uid      string
+		wantType string
+		wantID   string
+		wantErr  bool
+	}{
+		{uid: `User::"alice"`, wantType: "User", wantID: "alice"},
+		{uid: `Acme::Topic::"orders"`, wantType: "Acme::Topic", wantID: "orders"},
+		{uid: `Topic::"a::b"`, wantType: "Topic", wantID: "a::b"},
+		{uid: `Topic::"quoted \"id\""`, wantType: "Topic", wantID: `quoted "id"`},
+		{uid: `Topic::orders`, wantErr: true},
+		{uid: `::"orders"`, wantErr: true},
+		{uid: `orders`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uid, func(t *testing.T) {
+			entityType, id, err := ParseEntityUID(tt.uid)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEntityUID err = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (entityType != tt.wantType || id != tt.wantID) {
+				t.Errorf("ParseEntityUID = %q, %q, want %q, %q", entityType, id, tt.wantType, tt.wantID)
+			}
+			if !tt.wantErr && EntityUID(entityType, id) != tt.uid {
+				t.Errorf("EntityUID(%q, %q) = %q, want %q", entityType, id, EntityUID(entityType, id), tt.uid)
+			}
+		})
+	}
+}
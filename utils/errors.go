@@ -1,6 +1,9 @@
 package utils
 
-import "net/http"
+import (
+	"errors"
+	"net/http"
+)
 
 // ErrorType defines the type of error
 type ErrorType int
@@ -12,13 +15,17 @@ const (
 	ErrUnauthorized
 	ErrForbidden
 	ErrInternalServer
+	ErrConflict
 )
 
-// APIError represents an API error with status code
+// APIError represents an API error with status code. Code and Details are
+// optional and give clients a machine-readable reason, e.g. for 403s.
 type APIError struct {
 	Type       ErrorType
 	Message    string
 	StatusCode int
+	Code       string
+	Details    map[string]interface{}
 }
 
 // Error implements the error interface
@@ -53,6 +60,15 @@ func NewAlreadyExistsError(message string) *APIError {
 	}
 }
 
+// NewConflictError creates a 409 Conflict error for requests that clash with the current state
+func NewConflictError(message string) *APIError {
+	return &APIError{
+		Type:       ErrConflict,
+		Message:    message,
+		StatusCode: http.StatusConflict,
+	}
+}
+
 // NewUnauthorizedError creates a 401 Unauthorized error
 func NewUnauthorizedError(message string) *APIError {
 	return &APIError{
@@ -71,6 +87,28 @@ func NewForbiddenError(message string) *APIError {
 	}
 }
 
+// NewForbiddenReason creates a 403 Forbidden error carrying a reason code and details
+func NewForbiddenReason(code, message string, details map[string]interface{}) *APIError {
+	return &APIError{
+		Type:       ErrForbidden,
+		Message:    message,
+		StatusCode: http.StatusForbidden,
+		Code:       code,
+		Details:    details,
+	}
+}
+
+// NewConflictReason creates a 409 Conflict error with a machine-readable code and details
+func NewConflictReason(code, message string, details map[string]interface{}) *APIError {
+	return &APIError{
+		Type:       ErrConflict,
+		Message:    message,
+		StatusCode: http.StatusConflict,
+		Code:       code,
+		Details:    details,
+	}
+}
+
 // NewInternalServerError creates a 500 Internal Server Error
 func NewInternalServerError(message string) *APIError {
 	return &APIError{
@@ -85,3 +123,31 @@ func IsAPIError(err error) (*APIError, bool) {
 	apiErr, ok := err.(*APIError)
 	return apiErr, ok
 }
+
+// ErrorStatus returns the status code and message to send for err. APIErrors keep
+// their own status and message, anything else is reported as a 500 with fallback.
+func ErrorStatus(err error, fallback string) (int, string) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode, apiErr.Message
+	}
+	return http.StatusInternalServerError, fallback
+}
+
+// ErrorBody returns the status code and JSON body to send for err, including the
+// code and details of APIErrors that carry them
+func ErrorBody(err error, fallback string) (int, map[string]interface{}) {
+	status, msg := ErrorStatus(err, fallback)
+	body := map[string]interface{}{"error": msg}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Code != "" {
+			body["code"] = apiErr.Code
+		}
+		if apiErr.Details != nil {
+			body["details"] = apiErr.Details
+		}
+	}
+	return status, body
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID in and out of the API
+const RequestIDHeader = "X-Request-ID"
+
+// RequestInfo describes who made a request and where it came from, so lower
+// layers such as the audit log can record it without access to the HTTP request
+type RequestInfo struct {
+	RequestID string
+	SourceIP  string
+	Actor     string
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a copy of ctx carrying info
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// WithActor returns a copy of ctx with the actor of the request set
+func WithActor(ctx context.Context, actor string) context.Context {
+	info := RequestInfoFrom(ctx)
+	info.Actor = actor
+	return WithRequestInfo(ctx, info)
+}
+
+// RequestInfoFrom returns the request info stored in ctx, or an empty one
+func RequestInfoFrom(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
+
+// RequestInfoMiddleware stores the request ID and client IP in the request context.
+// A request ID sent by the client is kept, otherwise a new one is generated; either
+// way it is echoed in the response.
+func RequestInfoMiddleware() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.New().String()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		ctx := WithRequestInfo(c.Request.Context(), RequestInfo{
+			RequestID: requestID,
+			SourceIP:  c.ClientIP(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}